@@ -0,0 +1,138 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// flexInt decodes integers sent either as JSON numbers e.g 199
+// or as strings e.g "199". Amounts are sent to SecurionPay as
+// strings but its responses usually have them as bare numbers.
+// Empty strings, e.g the expiry of a card without one, decode to 0.
+type flexInt int
+
+var _ json.Unmarshaler = (*flexInt)(nil)
+
+func (fi *flexInt) UnmarshalJSON(b []byte) error {
+	trimmed := bytes.TrimSpace(b)
+	if bytes.Equal(trimmed, []byte("null")) {
+		return nil
+	}
+
+	if bytes.HasPrefix(trimmed, []byte(`"`)) {
+		var str string
+		if err := json.Unmarshal(trimmed, &str); err != nil {
+			return err
+		}
+		trimmed = bytes.TrimSpace([]byte(str))
+		if len(trimmed) == 0 {
+			*fi = 0
+			return nil
+		}
+	}
+
+	i, err := strconv.Atoi(string(trimmed))
+	if err != nil {
+		return fmt.Errorf("expecting an integer amount, got %s", b)
+	}
+	*fi = flexInt(i)
+	return nil
+}
+
+var (
+	_ json.Unmarshaler = (*Charge)(nil)
+	_ json.Unmarshaler = (*Credit)(nil)
+	_ json.Unmarshaler = (*ThreeDSecureInfo)(nil)
+	_ json.Unmarshaler = (*Card)(nil)
+	_ json.Unmarshaler = (*Token)(nil)
+)
+
+// The JSON methods of this package (un)marshal a plain alias of their
+// type, which has none of its methods, to avoid infinitely recursing.
+// Below, the shallower "amount" field overrides the one with ",string".
+
+func (creq *Charge) UnmarshalJSON(b []byte) error {
+	type plainCharge Charge
+	aux := struct {
+		*plainCharge
+		Amount flexInt `json:"amount"`
+	}{plainCharge: (*plainCharge)(creq)}
+	if err := json.Unmarshal(b, &aux); err != nil {
+		return err
+	}
+	creq.AmountMinorCurrencyUnits = int(aux.Amount)
+	return nil
+}
+
+func (cr *Credit) UnmarshalJSON(b []byte) error {
+	type plainCredit Credit
+	aux := struct {
+		*plainCredit
+		Amount flexInt `json:"amount"`
+	}{plainCredit: (*plainCredit)(cr)}
+	if err := json.Unmarshal(b, &aux); err != nil {
+		return err
+	}
+	cr.AmountMinorCurrencyUnits = int(aux.Amount)
+	return nil
+}
+
+func (tdsi *ThreeDSecureInfo) UnmarshalJSON(b []byte) error {
+	type plainThreeDSecureInfo ThreeDSecureInfo
+	aux := struct {
+		*plainThreeDSecureInfo
+		Amount flexInt `json:"amount"`
+	}{plainThreeDSecureInfo: (*plainThreeDSecureInfo)(tdsi)}
+	if err := json.Unmarshal(b, &aux); err != nil {
+		return err
+	}
+	tdsi.AmountMinorCurrencyUnits = int(aux.Amount)
+	return nil
+}
+
+// Likewise the expiry of cards and tokens is decoded leniently so that
+// a missing expiry, sent as an empty string, doesn't fail the whole object.
+
+func (c *Card) UnmarshalJSON(b []byte) error {
+	type plainCard Card
+	aux := struct {
+		*plainCard
+		ExpiryMonth flexInt `json:"expMonth"`
+		ExpiryYear  flexInt `json:"expYear"`
+	}{plainCard: (*plainCard)(c)}
+	if err := json.Unmarshal(b, &aux); err != nil {
+		return err
+	}
+	c.ExpiryMonth, c.ExpiryYear = int(aux.ExpiryMonth), int(aux.ExpiryYear)
+	return nil
+}
+
+func (t *Token) UnmarshalJSON(b []byte) error {
+	type plainToken Token
+	aux := struct {
+		*plainToken
+		ExpiryMonth flexInt `json:"expMonth"`
+		ExpiryYear  flexInt `json:"expYear"`
+	}{plainToken: (*plainToken)(t)}
+	if err := json.Unmarshal(b, &aux); err != nil {
+		return err
+	}
+	t.ExpiryMonth, t.ExpiryYear = int(aux.ExpiryMonth), int(aux.ExpiryYear)
+	return nil
+}
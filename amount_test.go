@@ -0,0 +1,138 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/orijtech/securionpay"
+)
+
+func TestAmountDecodingTolerance(t *testing.T) {
+	tests := [...]struct {
+		path       string
+		save       interface{}
+		wantAmount func(interface{}) int
+		want       int
+	}{
+		0: {
+			path:       "testdata/credit.json",
+			save:       new(securionpay.Credit),
+			wantAmount: func(v interface{}) int { return v.(*securionpay.Credit).AmountMinorCurrencyUnits },
+			want:       499,
+		},
+		1: {
+			path:       "testdata/credit-amount-string.json",
+			save:       new(securionpay.Credit),
+			wantAmount: func(v interface{}) int { return v.(*securionpay.Credit).AmountMinorCurrencyUnits },
+			want:       199,
+		},
+		2: {
+			path:       "testdata/charge-amount-number.json",
+			save:       new(securionpay.Charge),
+			wantAmount: func(v interface{}) int { return v.(*securionpay.Charge).AmountMinorCurrencyUnits },
+			want:       199,
+		},
+		3: {
+			path:       "testdata/charge-amount-string.json",
+			save:       new(securionpay.Charge),
+			wantAmount: func(v interface{}) int { return v.(*securionpay.Charge).AmountMinorCurrencyUnits },
+			want:       199,
+		},
+	}
+
+	for i, tt := range tests {
+		if err := retrFromFile(tt.path, tt.save); err != nil {
+			t.Errorf("#%d: %s: err: %v", i, tt.path, err)
+			continue
+		}
+		if got := tt.wantAmount(tt.save); got != tt.want {
+			t.Errorf("#%d: %s: gotAmount=%d want=%d", i, tt.path, got, tt.want)
+		}
+	}
+
+	// The other fields are still decoded.
+	credit := new(securionpay.Credit)
+	if err := retrFromFile("testdata/credit-amount-string.json", credit); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if credit.ID != "cr_OwM7B3WWha5SIfjNSw2eUqVb" || credit.CustomerID != "cust_AoR0wvgntQWRUYMdZNLYMz5R" {
+		t.Errorf("got %#v", credit)
+	}
+
+	// Malformed amounts are still rejected.
+	for _, malformed := range []string{`{"amount":"19.9"}`, `{"amount":true}`, `{"amount":"abc"}`} {
+		if err := json.Unmarshal([]byte(malformed), new(securionpay.Charge)); err == nil {
+			t.Errorf("%s: expected an error", malformed)
+		}
+	}
+
+	// Amounts are still sent as strings.
+	blob, err := json.Marshal(&securionpay.ThreeDSecureInfo{AmountMinorCurrencyUnits: 199})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	tdsi := new(securionpay.ThreeDSecureInfo)
+	if err := json.Unmarshal(blob, tdsi); err != nil || tdsi.AmountMinorCurrencyUnits != 199 {
+		t.Errorf("round trip of %s: got %#v err=%v", blob, tdsi, err)
+	}
+}
+
+func TestExpiryDecodingTolerance(t *testing.T) {
+	tests := [...]struct {
+		blob string
+
+		wantMonth, wantYear int
+		wantErr             bool
+	}{
+		0: {blob: `{"id":"card_1","expMonth":"11","expYear":"2022"}`, wantMonth: 11, wantYear: 2022},
+		1: {blob: `{"id":"card_1","expMonth":11,"expYear":2022}`, wantMonth: 11, wantYear: 2022},
+		2: {blob: `{"id":"card_1","expMonth":"","expYear":""}`},
+		3: {blob: `{"id":"card_1"}`},
+		4: {blob: `{"id":"card_1","expMonth":null,"expYear":" "}`},
+		5: {blob: `{"id":"card_1","expMonth":"eleven"}`, wantErr: true},
+	}
+
+	for i, tt := range tests {
+		card := new(securionpay.Card)
+		err := json.Unmarshal([]byte(tt.blob), card)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("#%d: expected a non-nil error", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: err: %v", i, err)
+			continue
+		}
+		if card.ID != "card_1" || card.ExpiryMonth != tt.wantMonth || card.ExpiryYear != tt.wantYear {
+			t.Errorf("#%d: got card=%#v want expiry %d/%d", i, card, tt.wantMonth, tt.wantYear)
+		}
+	}
+
+	// A token whose card has no expiry still decodes in full.
+	tok := new(securionpay.Token)
+	if err := retrFromFile("testdata/token-no-expiry.json", tok); err != nil {
+		t.Fatalf("decoding the token: %v", err)
+	}
+	if tok.ID != "tok_8rWXjHoTa7cFHfsbhbR4bGpO" || tok.ExpiryMonth != 0 || tok.ExpiryYear != 0 {
+		t.Errorf("got token=%#v", tok)
+	}
+	if tok.Card == nil || tok.Card.ID != "card_8P7OWXA5xiTS1ISnyZcum1KV" || tok.Card.Last4Digits != "4242" {
+		t.Errorf("got card=%#v", tok.Card)
+	}
+}
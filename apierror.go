@@ -0,0 +1,84 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// cardErrorType is the type of errors caused by the card being
+// charged e.g an invalid number or a declined card.
+const cardErrorType = "card_error"
+
+// APIError is the error that SecurionPay responds with to requests
+// that it rejects, for example with invalid parameters or a declined
+// card. ParamName, if set, is the parameter that was rejected so that
+// forms can point out the exact field to fix.
+type APIError struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int `json:"-"`
+
+	Type      string `json:"type"`
+	Code      string `json:"code,omitempty"`
+	Message   string `json:"message"`
+	ParamName string `json:"param,omitempty"`
+
+	// ChargeID is the ID of the charge that the error
+	// relates to, for example a declined charge.
+	ChargeID string `json:"chargeId,omitempty"`
+}
+
+func (e *APIError) Error() string {
+	if e.ParamName != "" {
+		return fmt.Sprintf("securionpay: %s: %s (param %q)", e.Type, e.Message, e.ParamName)
+	}
+	return fmt.Sprintf("securionpay: %s: %s", e.Type, e.Message)
+}
+
+// IsCardError reports whether the error was caused
+// by the card e.g because it was declined.
+func (e *APIError) IsCardError() bool {
+	return e != nil && e.Type == cardErrorType
+}
+
+// IsParam reports whether name is the parameter that was rejected.
+// Nested parameters such as "card[expMonth]" match either their full
+// name or just their innermost name, that is "expMonth".
+func (e *APIError) IsParam(name string) bool {
+	if e == nil || e.ParamName == "" {
+		return false
+	}
+	name = strings.TrimSpace(name)
+	return e.ParamName == name || strings.HasSuffix(e.ParamName, "["+name+"]")
+}
+
+type errorEnvelope struct {
+	Error *APIError `json:"error"`
+}
+
+// parseAPIError returns the error in the body of an unsuccessful
+// response or nil if the body isn't a SecurionPay error envelope.
+func parseAPIError(res *http.Response, slurp []byte) *APIError {
+	env := new(errorEnvelope)
+	if err := json.Unmarshal(slurp, env); err != nil || env.Error == nil {
+		return nil
+	}
+	env.Error.StatusCode = res.StatusCode
+	env.Error.Message = RedactPAN(env.Error.Message)
+	return env.Error
+}
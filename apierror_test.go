@@ -0,0 +1,98 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/orijtech/securionpay"
+)
+
+func TestAPIErrorParsing(t *testing.T) {
+	client := newTestClient(t)
+
+	tests := [...]struct {
+		body string
+
+		wantAPIError  bool
+		wantCardError bool
+		wantParam     string
+		notParam      string
+	}{
+		0: {
+			body:          `{"error":{"type":"card_error","code":"invalid_expiry_month","message":"Invalid expiry month","param":"expMonth"}}`,
+			wantAPIError:  true,
+			wantCardError: true,
+			wantParam:     "expMonth",
+			notParam:      "expYear",
+		},
+		1: {
+			body:         `{"error":{"type":"invalid_request","message":"Invalid currency","param":"currency"}}`,
+			wantAPIError: true,
+			wantParam:    "currency",
+			notParam:     "amount",
+		},
+		2: {
+			// Nested parameters match by their innermost name too.
+			body:          `{"error":{"type":"card_error","message":"Invalid number","param":"card[number]"}}`,
+			wantAPIError:  true,
+			wantCardError: true,
+			wantParam:     "number",
+			notParam:      "card",
+		},
+		3: {
+			body:         `{"error":{"type":"invalid_request","message":"Bad request"}}`,
+			wantAPIError: true,
+			notParam:     "amount",
+		},
+		4: {body: "Bad request"},
+	}
+
+	for i, tt := range tests {
+		client.SetHTTPRoundTripper(&cannedRoundTripper{statusCode: http.StatusBadRequest, body: tt.body})
+
+		_, err := client.Charge(&securionpay.Charge{Card: tokenID1, Currency: "USD", AmountMinorCurrencyUnits: 1500})
+		if err == nil {
+			t.Errorf("#%d: expected a non-nil error", i)
+			continue
+		}
+
+		apiErr, ok := err.(*securionpay.APIError)
+		if ok != tt.wantAPIError {
+			t.Errorf("#%d: got err=%#v, wantAPIError=%v", i, err, tt.wantAPIError)
+			continue
+		}
+		if !ok {
+			if got := err.Error(); got != tt.body {
+				t.Errorf("#%d: got err=%q want=%q", i, got, tt.body)
+			}
+			continue
+		}
+
+		if apiErr.StatusCode != http.StatusBadRequest {
+			t.Errorf("#%d: gotStatusCode=%d", i, apiErr.StatusCode)
+		}
+		if got := apiErr.IsCardError(); got != tt.wantCardError {
+			t.Errorf("#%d: gotIsCardError=%v want=%v", i, got, tt.wantCardError)
+		}
+		if tt.wantParam != "" && !apiErr.IsParam(tt.wantParam) {
+			t.Errorf("#%d: expected %q to be the rejected param, got %q", i, tt.wantParam, apiErr.ParamName)
+		}
+		if apiErr.IsParam(tt.notParam) {
+			t.Errorf("#%d: %q unexpectedly matched param %q", i, tt.notParam, apiErr.ParamName)
+		}
+	}
+}
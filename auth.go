@@ -0,0 +1,69 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// AuthScheme is how the API key is sent with every request.
+type AuthScheme int
+
+const (
+	// AuthBasic sends the API key as the username of HTTP basic
+	// authentication with an empty password, as SecurionPay documents.
+	AuthBasic AuthScheme = iota
+
+	// AuthBearer sends the API key as "Authorization: Bearer <key>"
+	// e.g for deployments behind proxies that strip basic authentication.
+	AuthBearer
+)
+
+func (as AuthScheme) String() string {
+	switch as {
+	case AuthBasic:
+		return "basic"
+	case AuthBearer:
+		return "bearer"
+	default:
+		return fmt.Sprintf("AuthScheme(%d)", int(as))
+	}
+}
+
+// SetAuthScheme sets how the API key is sent. The default is AuthBasic.
+func (c *Client) SetAuthScheme(scheme AuthScheme) {
+	c.Lock()
+	c.authScheme = scheme
+	c.Unlock()
+}
+
+func (c *Client) authorize(req *http.Request) {
+	c.RLock()
+	apiKey, scheme := c.apiKey, c.authScheme
+	c.RUnlock()
+
+	if key, ok := req.Context().Value(apiKeyKey{}).(string); ok {
+		// A fallback key being tried by failover.
+		apiKey = key
+	}
+
+	switch scheme {
+	case AuthBearer:
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	default:
+		req.SetBasicAuth(apiKey, "")
+	}
+}
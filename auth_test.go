@@ -0,0 +1,194 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/orijtech/securionpay"
+)
+
+func TestSetAuthScheme(t *testing.T) {
+	client := newTestClient(t)
+	client.SetAPIKey("sk_test_abc")
+
+	tests := [...]struct {
+		scheme   *securionpay.AuthScheme
+		wantAuth string
+	}{
+		// Basic authentication is the default.
+		0: {wantAuth: "Basic c2tfdGVzdF9hYmM6"},
+		1: {scheme: authScheme(securionpay.AuthBearer), wantAuth: "Bearer sk_test_abc"},
+		2: {scheme: authScheme(securionpay.AuthBasic), wantAuth: "Basic c2tfdGVzdF9hYmM6"},
+	}
+
+	for i, tt := range tests {
+		if tt.scheme != nil {
+			client.SetAuthScheme(*tt.scheme)
+		}
+		crt := &cannedRoundTripper{body: `{"id":"` + tokenID1 + `","objectType":"token"}`}
+		client.SetHTTPRoundTripper(crt)
+
+		if _, err := client.FindTokenByID(tokenID1); err != nil {
+			t.Errorf("#%d: err: %v", i, err)
+			continue
+		}
+		reqs := crt.requests()
+		if len(reqs) != 1 {
+			t.Errorf("#%d: got %d requests, want 1", i, len(reqs))
+			continue
+		}
+		if got := reqs[0].Header.Get("Authorization"); got != tt.wantAuth {
+			t.Errorf("#%d: gotAuthorization=%q want=%q", i, got, tt.wantAuth)
+		}
+	}
+
+	if got := securionpay.AuthScheme(7).String(); got != "AuthScheme(7)" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func authScheme(as securionpay.AuthScheme) *securionpay.AuthScheme { return &as }
+
+func TestAPIKeyFailover(t *testing.T) {
+	const primary, secondary, tertiary = "sk_test_primary0001", "sk_test_secondary0002", "sk_test_tertiary0003"
+
+	tests := [...]struct {
+		failover    bool
+		statusCodes []int
+
+		wantErr      bool
+		wantKeys     []string
+		wantKeyInUse string
+	}{
+		0: {
+			// Failover is off by default.
+			statusCodes:  []int{http.StatusUnauthorized, http.StatusOK},
+			wantErr:      true,
+			wantKeys:     []string{primary},
+			wantKeyInUse: "sk_test_****0001",
+		},
+		1: {
+			failover:     true,
+			statusCodes:  []int{http.StatusOK},
+			wantKeys:     []string{primary},
+			wantKeyInUse: "sk_test_****0001",
+		},
+		2: {
+			failover:     true,
+			statusCodes:  []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusOK},
+			wantKeys:     []string{primary, secondary, tertiary},
+			wantKeyInUse: "sk_test_****0003",
+		},
+		3: {
+			failover:    true,
+			statusCodes: []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusUnauthorized},
+			wantErr:     true,
+			wantKeys:    []string{primary, secondary, tertiary},
+		},
+		4: {
+			// Only authentication failures fail over.
+			failover:     true,
+			statusCodes:  []int{http.StatusBadRequest, http.StatusOK},
+			wantErr:      true,
+			wantKeys:     []string{primary},
+			wantKeyInUse: "sk_test_****0001",
+		},
+	}
+
+	for i, tt := range tests {
+		client, err := securionpay.NewClient("", primary, " ", secondary, tertiary)
+		if err != nil {
+			t.Fatalf("#%d: creating client: %v", i, err)
+		}
+		client.SetKeyFailover(tt.failover)
+
+		crt := &cannedRoundTripper{
+			statusCodes: tt.statusCodes,
+			body:        `{"id":"` + tokenID1 + `","objectType":"token"}`,
+		}
+		client.SetHTTPRoundTripper(crt)
+
+		_, err = client.FindTokenByID(tokenID1)
+		if tt.wantErr != (err != nil) {
+			t.Errorf("#%d: err=%v wantErr=%v", i, err, tt.wantErr)
+		}
+
+		reqs := crt.requests()
+		if len(reqs) != len(tt.wantKeys) {
+			t.Errorf("#%d: got %d requests, want %d", i, len(reqs), len(tt.wantKeys))
+			continue
+		}
+		for j, req := range reqs {
+			if user, _, _ := req.BasicAuth(); user != tt.wantKeys[j] {
+				t.Errorf("#%d: req #%d: gotKey=%q want=%q", i, j, user, tt.wantKeys[j])
+			}
+		}
+		if tt.wantKeyInUse != "" {
+			if got := client.APIKeyInUse(); got != tt.wantKeyInUse {
+				t.Errorf("#%d: gotKeyInUse=%q want=%q", i, got, tt.wantKeyInUse)
+			}
+		}
+	}
+}
+
+func TestAPIKeyFailoverWithCorrelationID(t *testing.T) {
+	const primary, secondary = "sk_test_primary0001", "sk_test_secondary0002"
+
+	client, err := securionpay.NewClient(primary, secondary)
+	if err != nil {
+		t.Fatalf("creating client: %v", err)
+	}
+	client.SetKeyFailover(true)
+
+	crt := &cannedRoundTripper{
+		statusCodes: []int{http.StatusUnauthorized, http.StatusOK, http.StatusOK, http.StatusOK},
+		body:        `{"id":"` + tokenID1 + `","objectType":"token"}`,
+	}
+	client.SetHTTPRoundTripper(crt)
+
+	// Failing over within a derived client is seen by its parent
+	// and by the clients derived later, which don't try the
+	// rejected primary key again.
+	for i := 0; i < 2; i++ {
+		err := client.DoWithCorrelationID(context.Background(), fmt.Sprintf("req-%d", i), func(dc *securionpay.Client) error {
+			_, err := dc.FindTokenByID(tokenID1)
+			return err
+		})
+		if err != nil {
+			t.Fatalf("#%d: err: %v", i, err)
+		}
+		if got, want := client.APIKeyInUse(), "sk_test_****0002"; got != want {
+			t.Errorf("#%d: gotKeyInUse=%q want=%q", i, got, want)
+		}
+	}
+	if _, err := client.FindTokenByID(tokenID1); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	wantKeys := []string{primary, secondary, secondary, secondary}
+	reqs := crt.requests()
+	if len(reqs) != len(wantKeys) {
+		t.Fatalf("got %d requests, want %d", len(reqs), len(wantKeys))
+	}
+	for i, req := range reqs {
+		if user, _, _ := req.BasicAuth(); user != wantKeys[i] {
+			t.Errorf("req #%d: gotKey=%q want=%q", i, user, wantKeys[i])
+		}
+	}
+}
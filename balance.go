@@ -0,0 +1,112 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay
+
+import (
+	"net/http"
+)
+
+type BalanceTxType string
+
+const (
+	BalanceTxCharge BalanceTxType = "charge"
+	BalanceTxRefund BalanceTxType = "refund"
+	BalanceTxFee    BalanceTxType = "fee"
+	BalanceTxPayout BalanceTxType = "payout"
+)
+
+// BalanceTransaction is an entry in the account's ledger
+// describing funds moving in or out of the balance.
+type BalanceTransaction struct {
+	ID        string        `json:"id"`
+	CreatedAt int64         `json:"created"`
+	Type      BalanceTxType `json:"type"`
+
+	// Amount, Fee and Net are all in minor currency units,
+	// with Net being Amount minus Fee.
+	Amount   int      `json:"amount"`
+	Fee      int      `json:"fee"`
+	Net      int      `json:"net"`
+	Currency Currency `json:"currency"`
+
+	// SourceID is the ID of the object that caused this
+	// transaction e.g a charge or a refund.
+	SourceID string `json:"sourceId"`
+}
+
+type BalanceTxList struct {
+	BalanceTransactions []*BalanceTransaction `json:"list"`
+
+	HasMore    bool `json:"hasMore"`
+	TotalCount int  `json:"totalCount,omitempty"`
+}
+
+type BalanceTxListRequest struct {
+	Limit int           `json:"limit,omitempty"`
+	Type  BalanceTxType `json:"type,omitempty"`
+
+	CreatedAfter      int64 `json:"gt,omitempty"`
+	CreatedOnOrAfter  int64 `json:"gte,omitempty"`
+	CreatedBefore     int64 `json:"lt,omitempty"`
+	CreatedOnOrBefore int64 `json:"lte,omitempty"`
+
+	StartingAfterId   string `json:"startingAfterId,omitempty"`
+	EndingBeforeId    string `json:"endingBeforeId,omitempty"`
+	IncludeTotalCount bool   `json:"includeTotalCount,omitempty"`
+}
+
+const defaultBalanceTxLimit = 10
+
+func (btr *BalanceTxListRequest) Validate() error {
+	if btr == nil {
+		return nil
+	}
+	return validateCreatedRange(btr.CreatedAfter, btr.CreatedOnOrAfter, btr.CreatedBefore, btr.CreatedOnOrBefore)
+}
+
+func (c *Client) ListBalanceTransactions(btr *BalanceTxListRequest) (*BalanceTxList, error) {
+	if err := btr.Validate(); err != nil {
+		return nil, err
+	}
+
+	breq := new(BalanceTxListRequest)
+	if btr != nil {
+		*breq = *btr
+	}
+
+	if breq.Limit < 1 {
+		breq.Limit = defaultBalanceTxLimit
+	}
+
+	qv, err := listQuery(breq)
+	if err != nil {
+		return nil, err
+	}
+
+	fullURL := buildURL("/balance-transactions", qv)
+	req, err := http.NewRequest("GET", fullURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	slurp, err := c.doAuthThenReqAndSlurpResponse(req)
+	if err != nil {
+		return nil, err
+	}
+	btxList := new(BalanceTxList)
+	if err := c.decodeResponse(slurp, btxList); err != nil {
+		return nil, err
+	}
+	return btxList, nil
+}
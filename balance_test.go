@@ -0,0 +1,63 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay_test
+
+import (
+	"testing"
+
+	"github.com/orijtech/securionpay"
+)
+
+func TestListBalanceTransactions(t *testing.T) {
+	crt := &cannedRoundTripper{body: `{
+	  "list": [
+	    {"id": "txn_1", "created": 1415810511, "type": "charge", "amount": 1000, "fee": 30, "net": 970, "currency": "EUR", "sourceId": "char_ORVCrwOrTkGsDwM3H50OIW7Q"},
+	    {"id": "txn_2", "created": 1415810512, "type": "fee", "amount": -50, "fee": 0, "net": -50, "currency": "EUR", "sourceId": ""}
+	  ],
+	  "hasMore": true
+	}`}
+	client := newClientWithRoundTripper(t, crt)
+
+	btxList, err := client.ListBalanceTransactions(&securionpay.BalanceTxListRequest{
+		Type:            securionpay.BalanceTxCharge,
+		StartingAfterId: "txn_0",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	reqs := crt.requests()
+	if len(reqs) != 1 {
+		t.Fatalf("got %d requests, want 1", len(reqs))
+	}
+	query := reqs[0].URL.Query()
+	wantQuery := map[string]string{"type": "charge", "limit": "10", "startingAfterId": "txn_0"}
+	for key, want := range wantQuery {
+		if got := query.Get(key); got != want {
+			t.Errorf("query %q: got=%q want=%q", key, got, want)
+		}
+	}
+	if reqs[0].URL.Path != "/balance-transactions" {
+		t.Errorf("got path=%q", reqs[0].URL.Path)
+	}
+
+	if !btxList.HasMore || len(btxList.BalanceTransactions) != 2 {
+		t.Fatalf("got %#v", btxList)
+	}
+	first := btxList.BalanceTransactions[0]
+	if first.Net != 970 || first.Fee != 30 || first.SourceID != "char_ORVCrwOrTkGsDwM3H50OIW7Q" {
+		t.Errorf("got %#v", first)
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay
+
+import (
+	"container/list"
+	"sync"
+)
+
+// boundedSet is a size bounded set of keys, each with an optional value,
+// which evicts the least recently added or looked up key once it is full.
+type boundedSet struct {
+	sync.Mutex
+
+	maxSize int
+	order   *list.List
+	byKey   map[string]*list.Element
+}
+
+type boundedSetEntry struct {
+	key   string
+	value interface{}
+}
+
+func newBoundedSet(maxSize int) *boundedSet {
+	return &boundedSet{
+		maxSize: maxSize,
+		order:   list.New(),
+		byKey:   make(map[string]*list.Element),
+	}
+}
+
+// put adds key with value to the set. If key is already in the set, its
+// value is replaced and it is marked as recently used only if replace
+// is set. put reports whether key was newly added.
+func (bs *boundedSet) put(key string, value interface{}, replace bool) (added bool) {
+	bs.Lock()
+	defer bs.Unlock()
+
+	if elem, ok := bs.byKey[key]; ok {
+		if replace {
+			elem.Value.(*boundedSetEntry).value = value
+			bs.order.MoveToFront(elem)
+		}
+		return false
+	}
+	bs.byKey[key] = bs.order.PushFront(&boundedSetEntry{key: key, value: value})
+	for bs.order.Len() > bs.maxSize {
+		oldest := bs.order.Back()
+		bs.order.Remove(oldest)
+		delete(bs.byKey, oldest.Value.(*boundedSetEntry).key)
+	}
+	return true
+}
+
+// get returns the value of key, marking it as recently used.
+func (bs *boundedSet) get(key string) (interface{}, bool) {
+	bs.Lock()
+	defer bs.Unlock()
+
+	elem, ok := bs.byKey[key]
+	if !ok {
+		return nil, false
+	}
+	bs.order.MoveToFront(elem)
+	return elem.Value.(*boundedSetEntry).value, true
+}
+
+func (bs *boundedSet) remove(key string) {
+	bs.Lock()
+	defer bs.Unlock()
+
+	if elem, ok := bs.byKey[key]; ok {
+		bs.order.Remove(elem)
+		delete(bs.byKey, key)
+	}
+}
@@ -0,0 +1,488 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var (
+	errTokenAsSavedCard = errors.New("expecting a saved card ID not a token, tokens are single use")
+
+	errNonPositiveIncrement  = errors.New("expecting a positive amount to increment the authorization by")
+	errChargeAlreadyCaptured = errors.New("the charge has already been captured")
+
+	errRetryingCapturedCharge = errors.New("the charge succeeded and was captured, retrying it would charge twice")
+	errRetryingUnfailedCharge = errors.New("the charge didn't fail, retrying it would charge twice")
+
+	errThreeDSecurePending = errors.New("the customer hasn't completed 3D Secure authentication yet")
+	errThreeDSecureFailed  = errors.New("3D Secure authentication failed, liability wasn't shifted")
+	errNotThreeDSecure     = errors.New("the charge wasn't authenticated with 3D Secure")
+)
+
+type incrementAuthorizationRequest struct {
+	AmountMinorCurrencyUnits int `json:"amount,string"`
+}
+
+// IncrementAuthorization raises the amount authorized
+// for an uncaptured charge by additionalMinor.
+//
+// POST https://api.securionpay.com/charges/{CHARGE_ID}/increment
+func (c *Client) IncrementAuthorization(chargeID string, additionalMinor int) (*ChargeResponse, error) {
+	if additionalMinor <= 0 {
+		return nil, errNonPositiveIncrement
+	}
+
+	charge, err := c.FindChargeByID(chargeID)
+	if err != nil {
+		return nil, err
+	}
+	if charge.Captured {
+		return nil, errChargeAlreadyCaptured
+	}
+
+	blob, err := json.Marshal(&incrementAuthorizationRequest{AmountMinorCurrencyUnits: additionalMinor})
+	if err != nil {
+		return nil, err
+	}
+
+	fullURL := fmt.Sprintf("%s/%s/increment", chargeEndpointURL, strings.TrimSpace(chargeID))
+	req, err := http.NewRequest("POST", fullURL, bytes.NewReader(blob))
+	if err != nil {
+		return nil, err
+	}
+
+	blob, err = c.doAuthThenReqAndSlurpResponse(req)
+	if err != nil {
+		return nil, err
+	}
+
+	cResp := new(ChargeResponse)
+	if err := c.decodeResponse(blob, cResp); err != nil {
+		return nil, err
+	}
+	return cResp, nil
+}
+
+const tokenIDPrefix = "tok_"
+
+// ChargeSavedCard charges one of a customer's saved cards, referenced by
+// its ID. Unlike tokens, which can only be charged once, saved cards can
+// be charged repeatedly hence passing a token ID as cardID is rejected.
+func (c *Client) ChargeSavedCard(customerID, cardID string, amount int, currency Currency, description string) (*ChargeResponse, error) {
+	customerID = strings.TrimSpace(customerID)
+	if customerID == "" {
+		return nil, errInvalidCustomerID
+	}
+	cardID = strings.TrimSpace(cardID)
+	if cardID == "" {
+		return nil, errUnsetCardID
+	}
+	if strings.HasPrefix(cardID, tokenIDPrefix) {
+		return nil, errTokenAsSavedCard
+	}
+
+	return c.Charge(&Charge{
+		CustomerID:  CustomerID(customerID),
+		CardID:      cardID,
+		Currency:    currency,
+		Description: description,
+
+		AmountMinorCurrencyUnits: amount,
+	})
+}
+
+// ErrCurrencyUnknown is returned by ChargeCustomerDefault when
+// the currency of the customer can't be determined because they
+// have never been charged.
+var ErrCurrencyUnknown = errors.New("securionpay: the customer's currency is unknown")
+
+// ChargeCustomerDefault charges the customer's default card in the currency
+// of their most recent successful charge, for merchants that charge each
+// customer in a single currency. If the customer has never been charged
+// successfully, it returns ErrCurrencyUnknown without charging them.
+func (c *Client) ChargeCustomerDefault(customerID CustomerID, amount int, description string) (*ChargeResponse, error) {
+	customerID = CustomerID(strings.TrimSpace(string(customerID)))
+	if customerID == "" {
+		return nil, errInvalidCustomerID
+	}
+
+	currency, err := c.customerCurrency(customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.Charge(&Charge{
+		CustomerID:  customerID,
+		Currency:    currency,
+		Description: description,
+
+		AmountMinorCurrencyUnits: amount,
+	})
+}
+
+// customerCurrencyPageSize is the number of charges fetched at once while
+// looking for the customer's most recent successful charge.
+const customerCurrencyPageSize = 10
+
+// customerCurrency returns the currency of the most recent successful
+// charge of the customer, skipping e.g declined attempts which may
+// have been made in another currency.
+func (c *Client) customerCurrency(customerID CustomerID) (Currency, error) {
+	clreq := &ChargeListRequest{CustomerID: customerID, Limit: customerCurrencyPageSize}
+	for {
+		page, err := c.ListCharges(clreq)
+		if err != nil {
+			return "", err
+		}
+		for _, charge := range page.Charges {
+			if !charge.Succeeded() {
+				continue
+			}
+			if currency := Currency(strings.TrimSpace(string(charge.Currency))); currency != "" {
+				return currency, nil
+			}
+		}
+		if !page.HasMore || len(page.Charges) == 0 {
+			return "", ErrCurrencyUnknown
+		}
+		clreq.StartingAfterId = page.Charges[len(page.Charges)-1].ID
+	}
+}
+
+// RetryChargeWithCard charges a failed charge again with newCardID,
+// a token or a saved card of its customer. Charges that didn't fail
+// are not retried.
+func (c *Client) RetryChargeWithCard(failedChargeID, newCardID string) (*ChargeResponse, error) {
+	newCardID = strings.TrimSpace(newCardID)
+	if newCardID == "" {
+		return nil, errUnsetCardID
+	}
+
+	failed, err := c.FindChargeByID(failedChargeID)
+	if err != nil {
+		return nil, err
+	}
+	if failed.Captured {
+		return nil, errRetryingCapturedCharge
+	}
+	if failed.FailureCode == "" && failed.FailureMessage == "" {
+		return nil, errRetryingUnfailedCharge
+	}
+
+	retry := &Charge{
+		Currency:    failed.Currency,
+		Description: failed.Description,
+		CustomerID:  failed.CustomerID,
+		Metadata:    failed.Metadata,
+
+		AmountMinorCurrencyUnits: int(failed.Amount),
+	}
+	if strings.HasPrefix(newCardID, tokenIDPrefix) {
+		retry.Card = newCardID
+	} else {
+		retry.CardID = newCardID
+	}
+	return c.Charge(retry)
+}
+
+// ChargeAndSaveCard saves the card of token to a customer and charges
+// it. The saved card is returned even if the charge failed.
+func (c *Client) ChargeAndSaveCard(customerID string, token string, amount int, cur Currency) (*ChargeResponse, *Card, error) {
+	customerID = strings.TrimSpace(customerID)
+	if customerID == "" {
+		return nil, nil, errInvalidCustomerID
+	}
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return nil, nil, errBlankTokenID
+	}
+
+	card, err := c.AddCard(&AddCardRequest{CustomerID: customerID, Card: &Card{ID: token}})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cResp, err := c.ChargeSavedCard(customerID, card.ID, amount, cur, "")
+	if err != nil {
+		return nil, card, err
+	}
+	return cResp, card, nil
+}
+
+// TokenizeAndCharge creates a token from treq and charges it. The token
+// is returned even if the charge failed.
+func (c *Client) TokenizeAndCharge(treq *TokenRequest, amount int, currency Currency, description string) (*Token, *ChargeResponse, error) {
+	// Checked upfront so as not to create a token that can't be charged.
+	if amount <= 0 || amount > maxAmountMinorCurrencyUnits {
+		return nil, nil, errAmountOutOfRange
+	}
+
+	tok, err := c.NewToken(treq)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cResp, err := c.Charge(&Charge{
+		Card:        tok.ID,
+		Currency:    currency,
+		Description: description,
+
+		AmountMinorCurrencyUnits: amount,
+	})
+	return tok, cResp, err
+}
+
+// CaptureCharge captures a charge that was only authorized. A charge
+// that was already captured is returned with AlreadyCaptured set.
+//
+// POST https://api.securionpay.com/charges/{CHARGE_ID}/capture
+func (c *Client) CaptureCharge(chargeID string) (*ChargeResponse, error) {
+	return c.captureCharge(context.Background(), chargeID)
+}
+
+func (c *Client) captureCharge(ctx context.Context, chargeID string) (*ChargeResponse, error) {
+	chargeID = strings.TrimSpace(chargeID)
+	if chargeID == "" {
+		return nil, errBlankChargeID
+	}
+
+	fullURL := fmt.Sprintf("%s/%s/capture", chargeEndpointURL, chargeID)
+	req, err := http.NewRequest("POST", fullURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+
+	blob, err := c.doAuthThenReqAndSlurpResponse(req)
+	if alreadyCapturedError(err) {
+		// Only a charge that is confirmed to be captured makes the
+		// capture a no-op, anything else is a genuine failure.
+		charge, findErr := c.FindChargeByID(chargeID)
+		if findErr != nil || !charge.Captured {
+			return nil, err
+		}
+		charge.AlreadyCaptured = true
+		return charge, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cResp := new(ChargeResponse)
+	if err := c.decodeResponse(blob, cResp); err != nil {
+		return nil, err
+	}
+	return cResp, nil
+}
+
+// alreadyCapturedError reports whether err is SecurionPay
+// rejecting the capture of a charge that was already captured.
+func alreadyCapturedError(err error) bool {
+	apiErr, ok := err.(*APIError)
+	if !ok || apiErr.StatusCode != http.StatusBadRequest {
+		return false
+	}
+	if strings.Contains(apiErr.Code, "already_captured") {
+		return true
+	}
+	return strings.Contains(strings.ToLower(apiErr.Message), "already captured")
+}
+
+// CaptureBefore waits until at and then captures the authorized charge,
+// e.g to capture an authorization before it expires without a separate
+// job queue. It blocks the calling goroutine while waiting, so it is
+// usually run in a goroutine of its own, and gives up without capturing
+// if ctx is done first. If at has already passed, it captures right away.
+func (c *Client) CaptureBefore(ctx context.Context, chargeID string, at time.Time) error {
+	if strings.TrimSpace(chargeID) == "" {
+		return errBlankChargeID
+	}
+	if err := sleepContext(ctx, at.Sub(c.now())); err != nil {
+		return err
+	}
+	_, err := c.captureCharge(ctx, chargeID)
+	return err
+}
+
+// Complete3DSCharge captures a charge once its customer is back from
+// its RedirectURL, provided that 3D Secure shifted the liability.
+//
+// POST https://api.securionpay.com/charges/{CHARGE_ID}/capture
+func (c *Client) Complete3DSCharge(chargeID string) (*ChargeResponse, error) {
+	charge, err := c.FindChargeByID(chargeID)
+	if err != nil {
+		return nil, err
+	}
+	if charge.RequiresAction {
+		return nil, errThreeDSecurePending
+	}
+	tdsi := charge.ThreeDSecureInfo
+	if tdsi == nil {
+		return nil, errNotThreeDSecure
+	}
+	if tdsi.LiabilityShift != SuccessfulShift {
+		return nil, errThreeDSecureFailed
+	}
+	if charge.Captured {
+		return charge, nil
+	}
+
+	captured, err := c.CaptureCharge(chargeID)
+	if err != nil {
+		return nil, err
+	}
+	if captured.ThreeDSecureInfo == nil {
+		captured.ThreeDSecureInfo = charge.ThreeDSecureInfo
+	}
+	return captured, nil
+}
+
+type ChargeList struct {
+	Charges []*ChargeResponse `json:"list"`
+
+	HasMore    bool `json:"hasMore"`
+	TotalCount int  `json:"totalCount,omitempty"`
+}
+
+type ChargeListRequest struct {
+	Limit int `json:"limit,omitempty"`
+
+	// CustomerID, if set, keeps only the charges of that customer.
+	CustomerID CustomerID `json:"customerId,omitempty"`
+
+	CreatedAfter      int64 `json:"gt,omitempty"`
+	CreatedOnOrAfter  int64 `json:"gte,omitempty"`
+	CreatedBefore     int64 `json:"lt,omitempty"`
+	CreatedOnOrBefore int64 `json:"lte,omitempty"`
+
+	StartingAfterId   string `json:"startingAfterId,omitempty"`
+	EndingBeforeId    string `json:"endingBeforeId,omitempty"`
+	IncludeTotalCount bool   `json:"includeTotalCount,omitempty"`
+}
+
+const (
+	defaultChargeLimit = 10
+	maxChargeLimit     = 100
+)
+
+func (clr *ChargeListRequest) Validate() error {
+	if clr == nil {
+		return nil
+	}
+	return validateCreatedRange(clr.CreatedAfter, clr.CreatedOnOrAfter, clr.CreatedBefore, clr.CreatedOnOrBefore)
+}
+
+// GET https://api.securionpay.com/charges
+func (c *Client) ListCharges(clr *ChargeListRequest) (*ChargeList, error) {
+	return c.listCharges(context.Background(), clr)
+}
+
+func (c *Client) listCharges(ctx context.Context, clr *ChargeListRequest) (*ChargeList, error) {
+	if err := clr.Validate(); err != nil {
+		return nil, err
+	}
+
+	clreq := new(ChargeListRequest)
+	if clr != nil {
+		*clreq = *clr
+	}
+
+	if clreq.Limit < 1 {
+		clreq.Limit = defaultChargeLimit
+	}
+
+	qv, err := listQuery(clreq)
+	if err != nil {
+		return nil, err
+	}
+
+	fullURL := buildURL("/charges", qv)
+	req, err := http.NewRequest("GET", fullURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	slurp, err := c.doAuthThenReqAndSlurpResponse(req)
+	if err != nil {
+		return nil, err
+	}
+	charges := new(ChargeList)
+	if err := c.decodeResponse(slurp, charges); err != nil {
+		return nil, err
+	}
+	return charges, nil
+}
+
+// ExportCharges writes all the charges matching filter to w as newline
+// delimited JSON, a page at a time, flushing w after every page if it
+// can be flushed.
+func (c *Client) ExportCharges(ctx context.Context, w io.Writer, filter *ChargeListRequest) error {
+	clreq := new(ChargeListRequest)
+	if filter != nil {
+		*clreq = *filter
+	}
+	if clreq.Limit < 1 || clreq.Limit > maxChargeLimit {
+		clreq.Limit = maxChargeLimit
+	}
+
+	enc := json.NewEncoder(w)
+	for {
+		var page *ChargeList
+		err := c.resumePage(ctx, func(ctx context.Context) (err error) {
+			page, err = c.listCharges(ctx, clreq)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, charge := range page.Charges {
+			if err := enc.Encode(charge); err != nil {
+				return err
+			}
+		}
+		if err := flushWriter(w); err != nil {
+			return err
+		}
+
+		if !page.HasMore || len(page.Charges) == 0 {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		clreq.StartingAfterId = page.Charges[len(page.Charges)-1].ID
+	}
+}
+
+// flushWriter flushes w if it buffers its writes.
+func flushWriter(w io.Writer) error {
+	switch f := w.(type) {
+	case interface{ Flush() error }:
+		return f.Flush()
+	case http.Flusher:
+		f.Flush()
+	}
+	return nil
+}
@@ -0,0 +1,1013 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/orijtech/securionpay"
+	"github.com/orijtech/securionpay/securionpaytest"
+)
+
+func TestIncrementAuthorization(t *testing.T) {
+	tests := [...]struct {
+		chargeID   string
+		additional int
+		wantErr    bool
+		wantAmount float32
+		comment    string
+	}{
+		0: {chargeID: chargeID3DS, additional: 0, wantErr: true, comment: "non-positive increment"},
+		1: {chargeID: chargeID3DS, additional: -10, wantErr: true, comment: "non-positive increment"},
+		2: {chargeID: "", additional: 100, wantErr: true, comment: "blank chargeID"},
+		3: {chargeID: chargeID1, additional: 100, wantErr: true, comment: "already captured"},
+		4: {chargeID: chargeID3DS, additional: 1000, wantAmount: 3500},
+	}
+
+	cRTripper := &customRoundTripper{route: chargesRoute}
+	client := newClientWithRoundTripper(t, cRTripper)
+
+	for i, tt := range tests {
+		cResp, err := client.IncrementAuthorization(tt.chargeID, tt.additional)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("#%d: want non-nil error, comment: %s", i, tt.comment)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("#%d gotErr=%q", i, err)
+			continue
+		}
+
+		if cResp.Amount != tt.wantAmount {
+			t.Errorf("#%d: gotAmount=%v wantAmount=%v", i, cResp.Amount, tt.wantAmount)
+		}
+	}
+}
+
+// chargesRoundTrip serves the endpoints under /charges/{CHARGE_ID}.
+func (ct *customRoundTripper) chargesRoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method == "GET" {
+		return ct.findChargeRoundTrip(req)
+	}
+
+	// Otherwise the path is of the form /charges/{CHARGE_ID}/{ACTION}
+	splits := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	if len(splits) != 3 {
+		return makeResp("unknown charge action", http.StatusNotFound), nil
+	}
+	path, known := knownChargeFiles[splits[1]]
+	if !known {
+		return makeResp("invalid chargeID", http.StatusNotFound), nil
+	}
+
+	cResp := new(securionpay.ChargeResponse)
+	if err := retrFromFile(path, cResp); err != nil {
+		return makeResp(err.Error(), http.StatusInternalServerError), nil
+	}
+
+	var slurp []byte
+	if req.Body != nil {
+		var err error
+		if slurp, err = ioutil.ReadAll(req.Body); err != nil {
+			return makeResp(err.Error(), http.StatusBadRequest), nil
+		}
+	}
+
+	switch action := splits[2]; action {
+	case "increment":
+		// The amount is sent as a string, like that of charges,
+		// hence a JSON number is rejected.
+		incr := struct {
+			Amount int `json:"amount,string"`
+		}{}
+		if err := json.Unmarshal(slurp, &incr); err != nil {
+			return makeResp(err.Error(), http.StatusBadRequest), nil
+		}
+		cResp.Amount += float32(incr.Amount)
+
+	case "capture":
+		cResp.Captured = true
+
+	default:
+		return makeResp("unknown charge action "+action, http.StatusNotFound), nil
+	}
+
+	blob, err := json.Marshal(cResp)
+	if err != nil {
+		return nil, err
+	}
+
+	prc, pwc := io.Pipe()
+	okResp := makeResp("200 OK", http.StatusOK)
+	okResp.Body = prc
+	go func() {
+		defer pwc.Close()
+		pwc.Write(blob)
+	}()
+
+	return okResp, nil
+}
+
+func TestChargeSavedCard(t *testing.T) {
+	client := newTestClient(t)
+
+	chargeBody, err := ioutil.ReadFile("testdata/chargeResp1.json")
+	if err != nil {
+		t.Fatalf("reading charge fixture: %v", err)
+	}
+
+	tests := [...]struct {
+		customerID, cardID string
+		wantErr            bool
+		wantBody           string
+	}{
+		0: {customerID: "", cardID: "card_8P7OWXA5xiTS1ISnyZcum1KV", wantErr: true},
+		1: {customerID: "cust_AoR0wvgntQWRUYMdZNLYMz5R", cardID: "  ", wantErr: true},
+		2: {customerID: "cust_AoR0wvgntQWRUYMdZNLYMz5R", cardID: "tok_NGsyDoJQXop5Pqqi6HizbJTe", wantErr: true},
+		3: {
+			customerID: "cust_AoR0wvgntQWRUYMdZNLYMz5R",
+			cardID:     "card_8P7OWXA5xiTS1ISnyZcum1KV",
+			wantBody:   `{"amount":"499","currency":"EUR","description":"Monthly box","card":"card_8P7OWXA5xiTS1ISnyZcum1KV","customerId":"cust_AoR0wvgntQWRUYMdZNLYMz5R"}`,
+		},
+	}
+
+	for i, tt := range tests {
+		crt := &cannedRoundTripper{body: string(chargeBody)}
+		client.SetHTTPRoundTripper(crt)
+
+		cResp, err := client.ChargeSavedCard(tt.customerID, tt.cardID, 499, securionpay.Euros, "Monthly box")
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("#%d: expected an error", i)
+			}
+			if n := len(crt.requests()); n != 0 {
+				t.Errorf("#%d: got %d requests, want none", i, n)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("#%d: err: %v", i, err)
+			continue
+		}
+		if cResp.ID != chargeID1 {
+			t.Errorf("#%d: gotID=%q", i, cResp.ID)
+		}
+
+		bodies := crt.requestBodies()
+		if len(bodies) != 1 {
+			t.Errorf("#%d: got %d requests, want 1", i, len(bodies))
+			continue
+		}
+		if got := string(bodies[0]); got != tt.wantBody {
+			t.Errorf("#%d\ngot:  %s\nwant: %s", i, got, tt.wantBody)
+		}
+	}
+}
+
+func TestRetryChargeWithCard(t *testing.T) {
+	client := newTestClient(t)
+
+	declinedBody, err := ioutil.ReadFile("testdata/chargeRespDeclined.json")
+	if err != nil {
+		t.Fatalf("reading declined charge fixture: %v", err)
+	}
+	capturedBody, err := ioutil.ReadFile("testdata/chargeResp1.json")
+	if err != nil {
+		t.Fatalf("reading charge fixture: %v", err)
+	}
+	authorizedBody := strings.Replace(string(capturedBody), `"captured" : true`, `"captured" : false`, 1)
+
+	tests := [...]struct {
+		newCardID  string
+		failedBody string
+
+		wantErr      bool
+		wantReqs     int
+		wantBody     string
+		wantFirstURL string
+	}{
+		0: {newCardID: "  ", failedBody: string(declinedBody), wantErr: true},
+		1: {
+			// Retrying a captured charge would charge twice.
+			newCardID:  "card_2",
+			failedBody: string(capturedBody),
+			wantErr:    true,
+			wantReqs:   1,
+		},
+		2: {
+			newCardID:  "card_2",
+			failedBody: string(declinedBody),
+			wantReqs:   2,
+			wantBody:   `{"amount":"1999","currency":"EUR","description":"Monthly plan","card":"card_2","customerId":"cust_AoR0wvgntQWRUYMdZNLYMz5R","metadata":{"invoice":"inv_1001"}}`,
+		},
+		3: {
+			newCardID:  tokenID1,
+			failedBody: string(declinedBody),
+			wantReqs:   2,
+			wantBody:   `{"amount":"1999","currency":"EUR","description":"Monthly plan","card":"` + tokenID1 + `","customerId":"cust_AoR0wvgntQWRUYMdZNLYMz5R","metadata":{"invoice":"inv_1001"}}`,
+		},
+		4: {
+			// An uncaptured authorization that succeeded would be authorized twice.
+			newCardID:  "card_2",
+			failedBody: authorizedBody,
+			wantErr:    true,
+			wantReqs:   1,
+		},
+	}
+
+	for i, tt := range tests {
+		crt := &cannedRoundTripper{bodies: []string{tt.failedBody, string(capturedBody)}}
+		client.SetHTTPRoundTripper(crt)
+
+		cResp, err := client.RetryChargeWithCard("char_Zq8fT3mPlkD7vbN2xRcW4yAs", tt.newCardID)
+		if got := len(crt.requests()); got != tt.wantReqs {
+			t.Errorf("#%d: got %d requests, want %d", i, got, tt.wantReqs)
+		}
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("#%d: expected an error", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: err: %v", i, err)
+			continue
+		}
+
+		reqs := crt.requests()
+		if got, want := reqs[0].URL.Path, "/charges/char_Zq8fT3mPlkD7vbN2xRcW4yAs"; got != want {
+			t.Errorf("#%d: gotPath=%q want=%q", i, got, want)
+		}
+		if reqs[1].Method != "POST" || reqs[1].URL.Path != "/charges" {
+			t.Errorf("#%d: got %s %s", i, reqs[1].Method, reqs[1].URL.Path)
+		}
+		if got := string(crt.requestBodies()[1]); got != tt.wantBody {
+			t.Errorf("#%d:\ngotBody:  %s\nwantBody: %s", i, got, tt.wantBody)
+		}
+		if cResp.ID != chargeID1 {
+			t.Errorf("#%d: gotID=%q", i, cResp.ID)
+		}
+	}
+}
+
+func TestChargeAndSaveCard(t *testing.T) {
+	client := newTestClient(t)
+
+	cardBody, err := ioutil.ReadFile("testdata/addcard1.json")
+	if err != nil {
+		t.Fatalf("reading card fixture: %v", err)
+	}
+	chargeBody, err := ioutil.ReadFile("testdata/chargeResp1.json")
+	if err != nil {
+		t.Fatalf("reading charge fixture: %v", err)
+	}
+
+	const savedCardID = "card_8P7OWXA5xiTS1ISnyZcum1KV"
+	customerID := "cust_AoR0wvgntQWRUYMdZNLYMz5R"
+
+	tests := [...]struct {
+		customerID  string
+		token       string
+		statusCodes []int
+
+		wantErr       bool
+		wantReqs      int
+		wantSavedCard bool
+	}{
+		0: {customerID: "  ", token: tokenID1, wantErr: true},
+		1: {customerID: customerID, token: "", wantErr: true},
+		2: {
+			// Nothing is charged if the card couldn't be saved.
+			customerID:  customerID,
+			token:       tokenID1,
+			statusCodes: []int{http.StatusBadRequest},
+			wantErr:     true,
+			wantReqs:    1,
+		},
+		3: {
+			// The saved card is returned even if the charge failed.
+			customerID:    customerID,
+			token:         tokenID1,
+			statusCodes:   []int{http.StatusOK, http.StatusPaymentRequired},
+			wantErr:       true,
+			wantReqs:      2,
+			wantSavedCard: true,
+		},
+		4: {customerID: customerID, token: tokenID1, wantReqs: 2, wantSavedCard: true},
+	}
+
+	for i, tt := range tests {
+		crt := &cannedRoundTripper{
+			statusCodes: tt.statusCodes,
+			bodies:      []string{string(cardBody), string(chargeBody)},
+		}
+		client.SetHTTPRoundTripper(crt)
+
+		cResp, card, err := client.ChargeAndSaveCard(tt.customerID, tt.token, 499, securionpay.Euros)
+		reqs, bodies := crt.requests(), crt.requestBodies()
+		if len(reqs) != tt.wantReqs {
+			t.Errorf("#%d: got %d requests, want %d", i, len(reqs), tt.wantReqs)
+		}
+		if tt.wantSavedCard && (card == nil || card.ID != savedCardID) {
+			t.Errorf("#%d: gotCard=%#v want the saved card", i, card)
+		}
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("#%d: expected an error", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: err: %v", i, err)
+			continue
+		}
+		if cResp.ID != chargeID1 {
+			t.Errorf("#%d: gotChargeID=%q", i, cResp.ID)
+		}
+
+		// The token is only used to save the card while
+		// the charge is made against the saved card.
+		if got, want := reqs[0].URL.Path, "/customers/"+customerID+"/cards"; got != want {
+			t.Errorf("#%d: gotPath=%q want=%q", i, got, want)
+		}
+		if !strings.Contains(string(bodies[0]), tokenID1) {
+			t.Errorf("#%d: saving the card didn't use the token: %s", i, bodies[0])
+		}
+		wantCharge := `{"amount":"499","currency":"EUR","description":"","card":"` + savedCardID + `","customerId":"` + customerID + `"}`
+		if got := string(bodies[1]); got != wantCharge {
+			t.Errorf("#%d:\ngotCharge:  %s\nwantCharge: %s", i, got, wantCharge)
+		}
+	}
+}
+
+func TestTokenizeAndCharge(t *testing.T) {
+	client := newTestClient(t)
+
+	chargeBody, err := ioutil.ReadFile("testdata/chargeResp1.json")
+	if err != nil {
+		t.Fatalf("reading charge fixture: %v", err)
+	}
+	tokenBody := `{"id":"tok_NGsyDoJQXop5Pqqi6HizbJTe","objectType":"token","last4":"4242"}`
+
+	tests := [...]struct {
+		treq        *securionpay.TokenRequest
+		amount      int
+		statusCodes []int
+
+		wantErr   bool
+		wantReqs  int
+		wantToken bool
+	}{
+		0: {treq: nil, amount: 499, wantErr: true},
+		1: {treq: securionpaytest.VisaApproved(), amount: 0, wantErr: true},
+		2: {
+			// Nothing is charged if the token couldn't be created.
+			treq:        securionpaytest.VisaApproved(),
+			amount:      499,
+			statusCodes: []int{http.StatusBadRequest},
+			wantErr:     true,
+			wantReqs:    1,
+		},
+		3: {
+			// The token is returned even if the charge failed.
+			treq:        securionpaytest.VisaApproved(),
+			amount:      499,
+			statusCodes: []int{http.StatusOK, http.StatusBadRequest},
+			wantErr:     true,
+			wantReqs:    2,
+			wantToken:   true,
+		},
+		4: {treq: securionpaytest.VisaApproved(), amount: 499, wantReqs: 2, wantToken: true},
+	}
+
+	for i, tt := range tests {
+		crt := &cannedRoundTripper{
+			statusCodes: tt.statusCodes,
+			bodies:      []string{tokenBody, string(chargeBody)},
+		}
+		client.SetHTTPRoundTripper(crt)
+
+		tok, cResp, err := client.TokenizeAndCharge(tt.treq, tt.amount, securionpay.Euros, "Blue umbrella")
+		reqs, bodies := crt.requests(), crt.requestBodies()
+		if len(reqs) != tt.wantReqs {
+			t.Errorf("#%d: got %d requests, want %d", i, len(reqs), tt.wantReqs)
+		}
+		if tt.wantToken != (tok != nil) {
+			t.Errorf("#%d: gotToken=%#v wantToken=%v", i, tok, tt.wantToken)
+		}
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("#%d: expected an error", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: err: %v", i, err)
+			continue
+		}
+
+		if got, want := reqs[0].URL.Path, "/tokens"; got != want {
+			t.Errorf("#%d: gotPath=%q want=%q", i, got, want)
+		}
+		wantCharge := `{"amount":"499","currency":"EUR","description":"Blue umbrella","card":"tok_NGsyDoJQXop5Pqqi6HizbJTe"}`
+		if got := string(bodies[1]); got != wantCharge {
+			t.Errorf("#%d:\ngotCharge:  %s\nwantCharge: %s", i, got, wantCharge)
+		}
+		if tok.ID != "tok_NGsyDoJQXop5Pqqi6HizbJTe" || cResp.ID != chargeID1 {
+			t.Errorf("#%d: got token %q and charge %q", i, tok.ID, cResp.ID)
+		}
+	}
+}
+
+func TestCaptureBefore(t *testing.T) {
+	client := newTestClient(t)
+
+	now := time.Date(2017, time.March, 14, 15, 9, 26, 0, time.UTC)
+	client.SetClock(func() time.Time { return now })
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := [...]struct {
+		ctx      context.Context
+		chargeID string
+		at       time.Time
+
+		wantErr  bool
+		wantReqs int
+	}{
+		0: {ctx: context.Background(), chargeID: "  ", at: now, wantErr: true},
+		// A deadline that has passed captures right away.
+		1: {ctx: context.Background(), chargeID: chargeID3DS, at: now.Add(-time.Hour), wantReqs: 1},
+		2: {ctx: context.Background(), chargeID: chargeID3DS, at: now.Add(20 * time.Millisecond), wantReqs: 1},
+		// Nothing is captured once the context is done.
+		3: {ctx: cancelled, chargeID: chargeID3DS, at: now.Add(time.Hour), wantErr: true},
+		4: {ctx: context.Background(), chargeID: "char_unknown", at: now, wantErr: true, wantReqs: 1},
+	}
+
+	for i, tt := range tests {
+		hrt := &headerRecordingRoundTripper{rt: &customRoundTripper{route: chargesRoute}}
+		client.SetHTTPRoundTripper(hrt)
+
+		err := client.CaptureBefore(tt.ctx, tt.chargeID, tt.at)
+		if got := len(hrt.headers); got != tt.wantReqs {
+			t.Errorf("#%d: got %d requests, want %d", i, got, tt.wantReqs)
+		}
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("#%d: expected an error", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: err: %v", i, err)
+		}
+	}
+
+	client.SetHTTPRoundTripper(&customRoundTripper{route: chargesRoute})
+	cResp, err := client.CaptureCharge(chargeID3DS)
+	if err != nil {
+		t.Fatalf("capturing: %v", err)
+	}
+	if !cResp.Captured {
+		t.Errorf("expected the charge to have been captured")
+	}
+}
+
+func TestComplete3DSCharge(t *testing.T) {
+	client := newTestClient(t)
+
+	pending, err := ioutil.ReadFile("testdata/chargeResp3DS.json")
+	if err != nil {
+		t.Fatalf("reading 3DS fixture: %v", err)
+	}
+	authenticated := `{"id":"char_8kt2XxvPg4ZSqOmNfCvWCpLj","captured":false,"threeDSecureInfo":{"amount":2500,"currency":"EUR","enrolled":true,"liabilityShift":"successful"}}`
+	failed := `{"id":"char_8kt2XxvPg4ZSqOmNfCvWCpLj","captured":false,"threeDSecureInfo":{"amount":2500,"currency":"EUR","enrolled":true,"liabilityShift":"failed"}}`
+	captured := `{"id":"char_8kt2XxvPg4ZSqOmNfCvWCpLj","captured":true}`
+
+	tests := [...]struct {
+		chargeID string
+		bodies   []string
+
+		wantReqs  int
+		wantErr   bool
+		wantShift securionpay.LiabilityShift
+	}{
+		0: {chargeID: "  ", wantErr: true},
+
+		// The customer hasn't come back from the redirect yet.
+		1: {chargeID: chargeID3DS, bodies: []string{string(pending)}, wantReqs: 1, wantErr: true},
+
+		2: {chargeID: chargeID3DS, bodies: []string{failed}, wantReqs: 1, wantErr: true},
+		3: {
+			chargeID:  chargeID3DS,
+			bodies:    []string{authenticated, captured},
+			wantReqs:  2,
+			wantShift: securionpay.SuccessfulShift,
+		},
+		4: {
+			// Already captured charges aren't captured again.
+			chargeID:  chargeID3DS,
+			bodies:    []string{strings.Replace(authenticated, `"captured":false`, `"captured":true`, 1)},
+			wantReqs:  1,
+			wantShift: securionpay.SuccessfulShift,
+		},
+
+		// Neither authorizations that didn't go through 3D Secure
+		// nor those without a liability shift are captured.
+		5: {chargeID: chargeID3DS, bodies: []string{`{"id":"char_8kt2XxvPg4ZSqOmNfCvWCpLj","captured":false}`}, wantReqs: 1, wantErr: true},
+		6: {chargeID: chargeID3DS, bodies: []string{strings.Replace(failed, `"failed"`, `"not_possible"`, 1)}, wantReqs: 1, wantErr: true},
+	}
+
+	for i, tt := range tests {
+		crt := &cannedRoundTripper{bodies: tt.bodies}
+		client.SetHTTPRoundTripper(crt)
+
+		cResp, err := client.Complete3DSCharge(tt.chargeID)
+		reqs := crt.requests()
+		if got := len(reqs); got != tt.wantReqs {
+			t.Errorf("#%d: got %d requests, want %d", i, got, tt.wantReqs)
+		}
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("#%d: expected a non-nil error", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: err: %v", i, err)
+			continue
+		}
+
+		if !cResp.Captured {
+			t.Errorf("#%d: expected a captured charge", i)
+		}
+		if cResp.ThreeDSecureInfo == nil || cResp.ThreeDSecureInfo.LiabilityShift != tt.wantShift {
+			t.Errorf("#%d: got threeDSecureInfo=%#v, want liabilityShift=%q", i, cResp.ThreeDSecureInfo, tt.wantShift)
+		}
+		if n := len(reqs); n > 1 && !strings.HasSuffix(reqs[n-1].URL.Path, "/capture") {
+			t.Errorf("#%d: got last request path=%q, want a capture", i, reqs[n-1].URL.Path)
+		}
+	}
+}
+
+func TestChargeCloneWithCard(t *testing.T) {
+	original, err := securionpay.NewAuthorization(2500, securionpay.USD, tokenID1)
+	if err != nil {
+		t.Fatalf("creating the charge: %v", err)
+	}
+	original.Description = "Order #42"
+	original.StatementDescriptor = "ACME ORDER 42"
+	original.CustomerID = "cust_AoR0wvgntQWRUYMdZNLYMz5R"
+	original.Shipping = &securionpay.Shipping{Name: "John Doe", Address: &securionpay.Address{Line1: "Cicha 1", Country: "PL"}}
+	original.Billing = &securionpay.Billing{VAT: "PL1234567890", Address: &securionpay.Address{Line1: "Cicha 1", Country: "PL"}}
+	original.Metadata = map[string]interface{}{"order": "42"}
+
+	tests := [...]struct {
+		card interface{}
+
+		wantJSON string
+	}{
+		0: {
+			card:     "tok_NGsyDoJQXop5Pqqi6HizbJTe",
+			wantJSON: `{"amount":"2500","currency":"USD","description":"Order #42","statementDescriptor":"ACME ORDER 42","card":"tok_NGsyDoJQXop5Pqqi6HizbJTe","customerId":"cust_AoR0wvgntQWRUYMdZNLYMz5R","shipping":{"name":"John Doe","address":{"zip":"","line1":"Cicha 1","line2":"","city":"","state":"","country":"PL"}},"billing":{"address":{"zip":"","line1":"Cicha 1","line2":"","city":"","state":"","country":"PL"},"vat":"PL1234567890"},"metadata":{"order":"42"}}`,
+		},
+		1: {
+			// Charging another customer's default card instead.
+			card:     securionpay.CustomerID("cust_other"),
+			wantJSON: `{"amount":"2500","currency":"USD","description":"Order #42","statementDescriptor":"ACME ORDER 42","customerId":"cust_other","shipping":{"name":"John Doe","address":{"zip":"","line1":"Cicha 1","line2":"","city":"","state":"","country":"PL"}},"billing":{"address":{"zip":"","line1":"Cicha 1","line2":"","city":"","state":"","country":"PL"},"vat":"PL1234567890"},"metadata":{"order":"42"}}`,
+		},
+	}
+
+	for i, tt := range tests {
+		clone := original.CloneWithCard(tt.card)
+		if err := clone.Validate(); err != nil {
+			t.Errorf("#%d: validating the clone: %v", i, err)
+		}
+		if got := string(blobify(clone)); got != tt.wantJSON {
+			t.Errorf("#%d\ngot:  %s\nwant: %s", i, got, tt.wantJSON)
+		}
+
+		// The clone doesn't share state with the original.
+		clone.Metadata["order"] = "43"
+		clone.Shipping.Address.Line1 = "Nowa 2"
+		if original.Metadata["order"] != "42" || original.Shipping.Address.Line1 != "Cicha 1" {
+			t.Errorf("#%d: modifying the clone modified the original", i)
+		}
+	}
+
+	if clone := (*securionpay.Charge)(nil).CloneWithCard(tokenID1); clone != nil {
+		t.Errorf("got clone=%#v of a nil charge", clone)
+	}
+}
+
+func TestChargeFetchCustomer(t *testing.T) {
+	client := newTestClient(t)
+
+	embedded, err := ioutil.ReadFile("testdata/chargeRespCustomer.json")
+	if err != nil {
+		t.Fatalf("reading charge fixture: %v", err)
+	}
+	customerBody := `{"id":"cust_1","email":"user@example.com"}`
+
+	tests := [...]struct {
+		chargeBody string
+
+		wantReqs       int
+		wantErr        bool
+		wantCustomerID string
+	}{
+		0: {chargeBody: `{"id":"char_1","customerId":"cust_1"}`, wantReqs: 2, wantCustomerID: "cust_1"},
+
+		// The embedded customer is used as is.
+		1: {chargeBody: string(embedded), wantReqs: 1, wantCustomerID: "cust_AoR0wvgntQWRUYMdZNLYMz5R"},
+
+		2: {chargeBody: `{"id":"char_1","customerId":null}`, wantReqs: 1, wantErr: true},
+	}
+
+	for i, tt := range tests {
+		crt := &cannedRoundTripper{bodies: []string{tt.chargeBody, customerBody}}
+		client.SetHTTPRoundTripper(crt)
+
+		cResp, err := client.FindChargeByID(chargeID1)
+		if err != nil {
+			t.Errorf("#%d: finding the charge: %v", i, err)
+			continue
+		}
+		customer, err := cResp.FetchCustomer(client)
+		if got := len(crt.requests()); got != tt.wantReqs {
+			t.Errorf("#%d: got %d requests, want %d", i, got, tt.wantReqs)
+		}
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("#%d: expected a non-nil error", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: err: %v", i, err)
+			continue
+		}
+		if customer.ID != tt.wantCustomerID {
+			t.Errorf("#%d: gotCustomerID=%q want=%q", i, customer.ID, tt.wantCustomerID)
+		}
+	}
+}
+
+// flushCountingWriter counts the flushes of the bytes written to it.
+type flushCountingWriter struct {
+	strings.Builder
+	flushes int
+	lines   []int
+}
+
+func (fcw *flushCountingWriter) Flush() error {
+	fcw.flushes++
+	fcw.lines = append(fcw.lines, strings.Count(fcw.String(), "\n"))
+	return nil
+}
+
+func TestExportCharges(t *testing.T) {
+	crt := &cannedRoundTripper{bodies: []string{
+		`{"list":[{"id":"char_1","amount":100,"currency":"USD"},{"id":"char_2","amount":200,"currency":"USD"}],"hasMore":true}`,
+		`{"list":[{"id":"char_3","amount":300,"currency":"EUR"}],"hasMore":false}`,
+	}}
+	client := newClientWithRoundTripper(t, crt)
+
+	w := new(flushCountingWriter)
+	filter := &securionpay.ChargeListRequest{CustomerID: "cust_1", CreatedOnOrAfter: 1415810511}
+	if err := client.ExportCharges(context.Background(), w, filter); err != nil {
+		t.Fatalf("exporting: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(w.String()), "\n")
+	wantIDs := []string{"char_1", "char_2", "char_3"}
+	if len(lines) != len(wantIDs) {
+		t.Fatalf("got %d lines, want %d:\n%s", len(lines), len(wantIDs), w.String())
+	}
+	for i, line := range lines {
+		charge := new(securionpay.ChargeResponse)
+		if err := json.Unmarshal([]byte(line), charge); err != nil {
+			t.Errorf("#%d: decoding %q: %v", i, line, err)
+			continue
+		}
+		if charge.ID != wantIDs[i] {
+			t.Errorf("#%d: gotID=%q want=%q", i, charge.ID, wantIDs[i])
+		}
+	}
+
+	// Flushed once per page.
+	if got, want := w.lines, []int{2, 3}; len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("got lines at each flush=%v want=%v", got, want)
+	}
+
+	reqs := crt.requests()
+	if len(reqs) != 2 {
+		t.Fatalf("got %d requests, want 2", len(reqs))
+	}
+	for i, req := range reqs {
+		qv := req.URL.Query()
+		if qv.Get("limit") != "100" || qv.Get("customerId") != "cust_1" || qv.Get("gte") != "1415810511" {
+			t.Errorf("#%d: got query=%q", i, req.URL.RawQuery)
+		}
+	}
+	if got := reqs[1].URL.Query().Get("startingAfterId"); got != "char_2" {
+		t.Errorf("got startingAfterId=%q want %q", got, "char_2")
+	}
+
+	// The filter isn't modified.
+	if filter.Limit != 0 || filter.StartingAfterId != "" {
+		t.Errorf("the filter was modified: %#v", filter)
+	}
+
+	invalid := &securionpay.ChargeListRequest{CreatedAfter: 2, CreatedOnOrAfter: 1}
+	if err := client.ExportCharges(context.Background(), w, invalid); err == nil {
+		t.Errorf("expected an invalid filter to be rejected")
+	}
+}
+
+func TestCaptureChargeIdempotent(t *testing.T) {
+	client := newTestClient(t)
+
+	capturedBody, err := ioutil.ReadFile("testdata/chargeResp1.json")
+	if err != nil {
+		t.Fatalf("reading charge fixture: %v", err)
+	}
+	alreadyCaptured := `{"error":{"type":"invalid_request","code":"charge_already_captured","message":"Charge has already been captured"}}`
+	uncaptured := `{"id":"` + chargeID1 + `","captured":false}`
+
+	tests := [...]struct {
+		statusCodes []int
+		bodies      []string
+
+		wantErr             bool
+		wantReqs            int
+		wantAlreadyCaptured bool
+	}{
+		0: {bodies: []string{string(capturedBody)}, wantReqs: 1},
+		1: {
+			// The charge captured by an earlier attempt is returned.
+			statusCodes:         []int{http.StatusBadRequest, http.StatusOK},
+			bodies:              []string{alreadyCaptured, string(capturedBody)},
+			wantReqs:            2,
+			wantAlreadyCaptured: true,
+		},
+		2: {
+			statusCodes:         []int{http.StatusBadRequest, http.StatusOK},
+			bodies:              []string{`{"error":{"type":"invalid_request","message":"Charge was already captured"}}`, string(capturedBody)},
+			wantReqs:            2,
+			wantAlreadyCaptured: true,
+		},
+		3: {
+			// The charge wasn't actually captured.
+			statusCodes: []int{http.StatusBadRequest, http.StatusOK},
+			bodies:      []string{alreadyCaptured, uncaptured},
+			wantReqs:    2,
+			wantErr:     true,
+		},
+		4: {
+			// Genuine capture failures aren't looked into.
+			statusCodes: []int{http.StatusBadRequest},
+			bodies:      []string{`{"error":{"type":"invalid_request","message":"Authorization has expired"}}`},
+			wantReqs:    1,
+			wantErr:     true,
+		},
+	}
+
+	for i, tt := range tests {
+		crt := &cannedRoundTripper{statusCodes: tt.statusCodes, bodies: tt.bodies}
+		client.SetHTTPRoundTripper(crt)
+
+		charge, err := client.CaptureCharge(chargeID1)
+		reqs := crt.requests()
+		if len(reqs) != tt.wantReqs {
+			t.Errorf("#%d: got %d requests, want %d", i, len(reqs), tt.wantReqs)
+		}
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("#%d: expected an error, got charge %#v", i, charge)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: err: %v", i, err)
+			continue
+		}
+
+		if !charge.Captured || charge.ID != chargeID1 {
+			t.Errorf("#%d: got charge %#v", i, charge)
+		}
+		if charge.AlreadyCaptured != tt.wantAlreadyCaptured {
+			t.Errorf("#%d: gotAlreadyCaptured=%v want=%v", i, charge.AlreadyCaptured, tt.wantAlreadyCaptured)
+		}
+		if tt.wantReqs > 1 {
+			if got, want := reqs[1].Method+" "+reqs[1].URL.Path, "GET /charges/"+chargeID1; got != want {
+				t.Errorf("#%d: got lookup %q want %q", i, got, want)
+			}
+		}
+	}
+}
+
+func TestSetDescriptionTemplate(t *testing.T) {
+	client := newTestClient(t)
+
+	chargeBody, err := ioutil.ReadFile("testdata/chargeResp1.json")
+	if err != nil {
+		t.Fatalf("reading charge fixture: %v", err)
+	}
+
+	if err := client.SetDescriptionTemplate("Order {{.OrderID"); err == nil {
+		t.Fatal("expected an error for an unparseable template")
+	}
+
+	tests := [...]struct {
+		tmpl string
+		creq *securionpay.Charge
+
+		wantErr         bool
+		wantDescription string
+	}{
+		0: {
+			tmpl:            "Order {{.OrderID}} for {{.Store}}",
+			creq:            &securionpay.Charge{Metadata: map[string]interface{}{"OrderID": 1042, "Store": "Berlin"}},
+			wantDescription: "Order 1042 for Berlin",
+		},
+		// An existing description is kept.
+		1: {
+			tmpl:            "Order {{.OrderID}}",
+			creq:            &securionpay.Charge{Description: "Gift card", Metadata: map[string]interface{}{"OrderID": 1042}},
+			wantDescription: "Gift card",
+		},
+		// Metadata missing a key of the template fails before any request.
+		2: {
+			tmpl:    "Order {{.OrderID}}",
+			creq:    &securionpay.Charge{Metadata: map[string]interface{}{"Store": "Berlin"}},
+			wantErr: true,
+		},
+		3: {
+			tmpl:    "Order {{.OrderID}}",
+			creq:    &securionpay.Charge{},
+			wantErr: true,
+		},
+		// A blank template disables it.
+		4: {
+			tmpl:            "  ",
+			creq:            &securionpay.Charge{Metadata: map[string]interface{}{"OrderID": 1042}},
+			wantDescription: "",
+		},
+	}
+
+	for i, tt := range tests {
+		crt := &cannedRoundTripper{body: string(chargeBody)}
+		client.SetHTTPRoundTripper(crt)
+		if err := client.SetDescriptionTemplate(tt.tmpl); err != nil {
+			t.Errorf("#%d: setting the template: %v", i, err)
+			continue
+		}
+
+		tt.creq.Card = tokenID1
+		tt.creq.Currency = securionpay.Euros
+		tt.creq.AmountMinorCurrencyUnits = 499
+		originalDescription := tt.creq.Description
+
+		_, err := client.Charge(tt.creq)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("#%d: expected an error", i)
+			}
+			if n := len(crt.requests()); n != 0 {
+				t.Errorf("#%d: got %d requests, want none", i, n)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: err: %v", i, err)
+			continue
+		}
+		if tt.creq.Description != originalDescription {
+			t.Errorf("#%d: the charge's description was changed to %q", i, tt.creq.Description)
+		}
+
+		bodies := crt.requestBodies()
+		if len(bodies) != 1 {
+			t.Errorf("#%d: got %d requests, want 1", i, len(bodies))
+			continue
+		}
+		sent := new(securionpay.Charge)
+		if err := json.Unmarshal(bodies[0], sent); err != nil {
+			t.Errorf("#%d: unmarshalling the sent charge: %v", i, err)
+			continue
+		}
+		if sent.Description != tt.wantDescription {
+			t.Errorf("#%d: gotDescription=%q wantDescription=%q", i, sent.Description, tt.wantDescription)
+		}
+	}
+}
+
+func TestChargeCustomerDefault(t *testing.T) {
+	client := newTestClient(t)
+
+	chargeBody, err := ioutil.ReadFile("testdata/chargeResp1.json")
+	if err != nil {
+		t.Fatalf("reading charge fixture: %v", err)
+	}
+
+	declinedUSD := `{"id":"char_declined","currency":"USD","customerId":"customerID1","failureCode":"card_declined","failureMessage":"The card was declined."}`
+
+	tests := [...]struct {
+		customerID securionpay.CustomerID
+		bodies     []string
+
+		wantErr      error
+		wantRequests int
+		wantBody     string
+	}{
+		0: {customerID: "  "},
+		1: {
+			customerID:   customerID1,
+			bodies:       []string{`{"list":[],"hasMore":false}`},
+			wantErr:      securionpay.ErrCurrencyUnknown,
+			wantRequests: 1,
+		},
+		2: {
+			customerID:   customerID1,
+			bodies:       []string{`{"list":[` + string(chargeBody) + `],"hasMore":true}`, string(chargeBody)},
+			wantRequests: 2,
+			wantBody:     `{"amount":"499","currency":"EUR","description":"Monthly box","customerId":"customerID1"}`,
+		},
+		3: {
+			// The latest charge was declined, so the currency is
+			// that of the successful charge before it.
+			customerID: customerID1,
+			bodies: []string{
+				`{"list":[` + declinedUSD + `],"hasMore":true}`,
+				`{"list":[` + string(chargeBody) + `],"hasMore":false}`,
+				string(chargeBody),
+			},
+			wantRequests: 3,
+			wantBody:     `{"amount":"499","currency":"EUR","description":"Monthly box","customerId":"customerID1"}`,
+		},
+		4: {
+			customerID:   customerID1,
+			bodies:       []string{`{"list":[` + declinedUSD + `],"hasMore":false}`},
+			wantErr:      securionpay.ErrCurrencyUnknown,
+			wantRequests: 1,
+		},
+	}
+
+	for i, tt := range tests {
+		crt := &cannedRoundTripper{bodies: tt.bodies}
+		client.SetHTTPRoundTripper(crt)
+
+		cResp, err := client.ChargeCustomerDefault(tt.customerID, 499, "Monthly box")
+		if n := len(crt.requests()); n != tt.wantRequests {
+			t.Errorf("#%d: got %d requests, want %d", i, n, tt.wantRequests)
+		}
+		if tt.wantBody == "" {
+			if err == nil {
+				t.Errorf("#%d: expected an error", i)
+			} else if tt.wantErr != nil && err != tt.wantErr {
+				t.Errorf("#%d: gotErr=%v wantErr=%v", i, err, tt.wantErr)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("#%d: err: %v", i, err)
+			continue
+		}
+		if cResp.ID != chargeID1 {
+			t.Errorf("#%d: gotID=%q", i, cResp.ID)
+		}
+		reqs := crt.requests()
+		if got, want := reqs[0].URL.RawQuery, "customerId=customerID1&limit=10"; got != want {
+			t.Errorf("#%d: gotQuery=%q wantQuery=%q", i, got, want)
+		}
+		if got := string(crt.requestBodies()[len(reqs)-1]); got != tt.wantBody {
+			t.Errorf("#%d\ngot:  %s\nwant: %s", i, got, tt.wantBody)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxDescriptionLength is the longest description, in characters,
+// that charges are checked against by ValidateStrict.
+const maxDescriptionLength = 1000
+
+// maxStatementDescriptorLength is the longest statement descriptor, in
+// characters, that charges are checked against by ValidateStrict since
+// card networks truncate longer ones.
+const maxStatementDescriptorLength = 22
+
+// maxAmountMinorCurrencyUnits is the largest amount that charges may be
+// made for. It is the largest 32-bit int so that amounts never wrap
+// around, whatever the size of int on the platform.
+const maxAmountMinorCurrencyUnits = 1<<31 - 1
+
+var (
+	errAmountOutOfRange = fmt.Errorf("expecting a positive amount of at most %d minor currency units", maxAmountMinorCurrencyUnits)
+	errNoFundingSource  = errors.New("expecting one funding source, either `card`, `cardId` or `customerId`")
+
+	currencyCodeRe = regexp.MustCompile(`^[A-Z]{3}$`)
+)
+
+// ValidationErrors are all the problems found while validating
+// e.g so that forms can point out every invalid field at once.
+type ValidationErrors []error
+
+func (ve ValidationErrors) Error() string {
+	msgs := make([]string, 0, len(ve))
+	for _, err := range ve {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the individual errors.
+func (ve ValidationErrors) Unwrap() []error { return ve }
+
+// ValidateStrict is a stricter Validate that reports
+// every problem with the charge as ValidationErrors.
+func (creq *Charge) ValidateStrict() error {
+	if creq == nil {
+		return ValidationErrors{errBlankCharge}
+	}
+
+	var errs ValidationErrors
+	if creq.AmountMinorCurrencyUnits <= 0 || creq.AmountMinorCurrencyUnits > maxAmountMinorCurrencyUnits {
+		errs = append(errs, errAmountOutOfRange)
+	}
+	if !currencyCodeRe.MatchString(string(creq.Currency)) {
+		errs = append(errs, fmt.Errorf("currency %q isn't an ISO 4217 code such as \"USD\"", creq.Currency))
+	}
+
+	blankCard := blankCardSource(creq.Card)
+	switch {
+	case strings.TrimSpace(creq.CardID) != "":
+		if !blankCard {
+			errs = append(errs, errBothCardAndCardIDSet)
+		}
+		if creq.CustomerID == "" {
+			errs = append(errs, errCardIDWithoutCustomerID)
+		}
+	case blankCard && creq.CustomerID == "":
+		errs = append(errs, errNoFundingSource)
+	}
+	if !blankCard {
+		if err := validateCardSource(creq.Card); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if err := creq.Shipping.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := creq.Billing.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if n := utf8.RuneCountInString(creq.Description); n > maxDescriptionLength {
+		errs = append(errs, fmt.Errorf("description has %d characters, more than the %d allowed", n, maxDescriptionLength))
+	}
+	if n := utf8.RuneCountInString(creq.StatementDescriptor); n > maxStatementDescriptorLength {
+		errs = append(errs, fmt.Errorf("statement descriptor has %d characters, more than the %d allowed", n, maxStatementDescriptorLength))
+	}
+	if err := validateMetadata(creq.Metadata); err != nil {
+		errs = append(errs, err)
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
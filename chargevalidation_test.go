@@ -0,0 +1,152 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay_test
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/orijtech/securionpay"
+)
+
+func TestChargeValidateStrict(t *testing.T) {
+	address := &securionpay.Address{Line1: "Cicha 1", Country: "PL"}
+
+	tests := [...]struct {
+		charge *securionpay.Charge
+
+		wantErrs int
+	}{
+		0: {charge: &securionpay.Charge{Card: tokenID1, Currency: "USD", AmountMinorCurrencyUnits: 1500}},
+		1: {
+			charge: &securionpay.Charge{
+				CustomerID: "cust_1",
+				CardID:     "card_1",
+				Currency:   "EUR",
+				Shipping:   &securionpay.Shipping{Name: "John Doe", Address: address},
+				Billing:    &securionpay.Billing{Address: address},
+
+				AmountMinorCurrencyUnits: 1500,
+			},
+		},
+		2: {charge: nil, wantErrs: 1},
+
+		// Every problem is reported at once.
+		3: {charge: &securionpay.Charge{Currency: "usd"}, wantErrs: 3},
+		4: {
+			charge: &securionpay.Charge{
+				Card:        tokenID1,
+				CardID:      "card_1",
+				Currency:    "USD",
+				Description: strings.Repeat("d", 1001),
+				Shipping:    &securionpay.Shipping{Address: address},
+				Billing:     &securionpay.Billing{Address: &securionpay.Address{Line1: "Cicha 1", Country: "Narnia"}},
+
+				StatementDescriptor: "ACME STORE ORDER 1042 WARSAW",
+			},
+			// Non-positive amount, both card and card ID, card ID without
+			// a customer, shipping without a name, an unrecognized country,
+			// a too long description and a too long statement descriptor.
+			wantErrs: 7,
+		},
+		5: {
+			charge: &securionpay.Charge{
+				Card:     tokenID1,
+				Currency: "USD",
+
+				StatementDescriptor:      "ACME STORE ORDER 1042",
+				AmountMinorCurrencyUnits: 1500,
+			},
+		},
+		6: {
+			charge: &securionpay.Charge{
+				Card:     tokenID1,
+				Currency: "USD",
+
+				StatementDescriptor:      "ACME STORE ORDER 10421",
+				AmountMinorCurrencyUnits: 1500,
+			},
+		},
+		7: {
+			charge: &securionpay.Charge{
+				Card:     tokenID1,
+				Currency: "USD",
+
+				StatementDescriptor:      "ACME STORE ORDER 104213",
+				AmountMinorCurrencyUnits: 1500,
+			},
+			wantErrs: 1,
+		},
+	}
+
+	for i, tt := range tests {
+		err := tt.charge.ValidateStrict()
+		if tt.wantErrs == 0 {
+			if err != nil {
+				t.Errorf("#%d: err: %v", i, err)
+			}
+			continue
+		}
+
+		verrs, ok := err.(securionpay.ValidationErrors)
+		if !ok {
+			t.Errorf("#%d: got err=%#v, want ValidationErrors", i, err)
+			continue
+		}
+		if len(verrs) != tt.wantErrs {
+			t.Errorf("#%d: got %d errors, want %d: %v", i, len(verrs), tt.wantErrs, verrs)
+		}
+	}
+
+	// Validate stays lenient.
+	lenient := &securionpay.Charge{Card: tokenID1, Currency: "usd", AmountMinorCurrencyUnits: 499}
+	if err := lenient.Validate(); err != nil {
+		t.Errorf("unexpected error from Validate: %v", err)
+	}
+}
+
+func TestChargeValidateAmountRange(t *testing.T) {
+	tests := [...]struct {
+		amount  int
+		wantErr bool
+	}{
+		0: {amount: 0, wantErr: true},
+		1: {amount: -499, wantErr: true},
+		2: {amount: 1},
+		3: {amount: math.MaxInt32},
+	}
+
+	for i, tt := range tests {
+		charge := &securionpay.Charge{Card: tokenID1, Currency: securionpay.Euros, AmountMinorCurrencyUnits: tt.amount}
+		err := charge.Validate()
+		if gotErr := err != nil; gotErr != tt.wantErr {
+			t.Errorf("#%d: Validate err=%v wantErr=%v", i, err, tt.wantErr)
+		}
+		if err := charge.ValidateStrict(); (err != nil) != tt.wantErr {
+			t.Errorf("#%d: ValidateStrict err=%v wantErr=%v", i, err, tt.wantErr)
+		}
+	}
+
+	if strconv.IntSize > 32 {
+		// Amounts that wouldn't fit in 32 bits are rejected on every platform.
+		tooLarge := int64(math.MaxInt32) + 1
+		charge := &securionpay.Charge{Card: tokenID1, AmountMinorCurrencyUnits: int(tooLarge)}
+		if err := charge.Validate(); err == nil {
+			t.Errorf("expected an error for an amount of %d", tooLarge)
+		}
+	}
+}
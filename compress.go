@@ -0,0 +1,104 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// compressionThreshold is the size in bytes from which request
+// bodies are compressed, smaller bodies aren't worth the overhead.
+const compressionThreshold = 1 << 10
+
+// SetCompressRequests enables gzip compression of request bodies of at
+// least 1KiB, e.g for charges with large metadata in high volume
+// integrations. Compressed bodies are sent with "Content-Encoding: gzip".
+// It is off by default.
+func (c *Client) SetCompressRequests(compress bool) {
+	c.Lock()
+	c.compressRequests = compress
+	c.Unlock()
+}
+
+func (c *Client) compressingRequests() bool {
+	c.RLock()
+	defer c.RUnlock()
+	return c.compressRequests
+}
+
+// compressRequest returns req with its body gzipped if compression
+// is enabled and the body is large enough to be worth compressing.
+// The returned request's body can be rewound for retries either way.
+func (c *Client) compressRequest(req *http.Request) (*http.Request, error) {
+	if !c.compressingRequests() || req.Body == nil || req.Body == http.NoBody {
+		return req, nil
+	}
+	if req.Header.Get("Content-Encoding") != "" {
+		return req, nil
+	}
+
+	body, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	if len(body) >= compressionThreshold {
+		buf := new(bytes.Buffer)
+		gzw := gzip.NewWriter(buf)
+		if _, err := gzw.Write(body); err != nil {
+			return nil, err
+		}
+		if err := gzw.Close(); err != nil {
+			return nil, err
+		}
+		body = buf.Bytes()
+
+		// Copied so that the caller's headers aren't modified.
+		req = req.WithContext(req.Context())
+		req.Header = req.Header.Clone()
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+
+	req.ContentLength = int64(len(body))
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(body)), nil
+	}
+	return req, nil
+}
+
+// loggableBody returns the body of a request with the given headers
+// decompressed if it was gzipped, so that logs are readable and can be
+// redacted. The body is returned as is if it can't be decompressed.
+func loggableBody(header http.Header, body []byte) []byte {
+	if len(body) == 0 || header.Get("Content-Encoding") != "gzip" {
+		return body
+	}
+	gzr, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		return body
+	}
+	defer gzr.Close()
+
+	decompressed, err := ioutil.ReadAll(gzr)
+	if err != nil {
+		return body
+	}
+	return decompressed
+}
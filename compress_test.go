@@ -0,0 +1,142 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/orijtech/securionpay"
+)
+
+func TestCompressRequests(t *testing.T) {
+	client := newTestClient(t)
+
+	chargeBody, err := ioutil.ReadFile("testdata/chargeResp1.json")
+	if err != nil {
+		t.Fatalf("reading charge fixture: %v", err)
+	}
+
+	large := map[string]interface{}{"notes": strings.Repeat("a", 400), "more": strings.Repeat("b", 400), "most": strings.Repeat("c", 400)}
+
+	tests := [...]struct {
+		compress     bool
+		metadata     map[string]interface{}
+		statusCodes  []int
+		wantReqs     int
+		wantEncoding string
+	}{
+		0: {metadata: large, wantReqs: 1},
+		1: {compress: true, metadata: map[string]interface{}{"order": "1"}, wantReqs: 1},
+		2: {compress: true, metadata: large, wantReqs: 1, wantEncoding: "gzip"},
+		3: {
+			// Retries resend the compressed body.
+			compress:     true,
+			metadata:     large,
+			statusCodes:  []int{http.StatusTooManyRequests, http.StatusOK},
+			wantReqs:     2,
+			wantEncoding: "gzip",
+		},
+	}
+
+	for i, tt := range tests {
+		crt := &cannedRoundTripper{body: string(chargeBody), statusCodes: tt.statusCodes, header: http.Header{"Retry-After": {"0"}}}
+		client.SetHTTPRoundTripper(crt)
+		client.SetCompressRequests(tt.compress)
+		client.SetRetryPolicy(&securionpay.RetryPolicy{MaxRetries: 1})
+
+		charge := &securionpay.Charge{Card: tokenID1, Currency: "USD", Metadata: tt.metadata, AmountMinorCurrencyUnits: 1500}
+		if _, err := client.Charge(charge); err != nil {
+			t.Errorf("#%d: err: %v", i, err)
+			continue
+		}
+
+		reqs, bodies := crt.requests(), crt.requestBodies()
+		if len(reqs) != tt.wantReqs {
+			t.Errorf("#%d: got %d requests, want %d", i, len(reqs), tt.wantReqs)
+			continue
+		}
+		for j, req := range reqs {
+			if got := req.Header.Get("Content-Encoding"); got != tt.wantEncoding {
+				t.Errorf("#%d: req #%d: got Content-Encoding=%q want=%q", i, j, got, tt.wantEncoding)
+				continue
+			}
+
+			body := bodies[j]
+			if tt.wantEncoding == "gzip" {
+				gzr, err := gzip.NewReader(bytes.NewReader(body))
+				if err != nil {
+					t.Errorf("#%d: req #%d: %v", i, j, err)
+					continue
+				}
+				body, _ = ioutil.ReadAll(gzr)
+			}
+			sent := new(securionpay.Charge)
+			if err := json.Unmarshal(body, sent); err != nil {
+				t.Errorf("#%d: req #%d: decoding the sent charge: %v", i, j, err)
+				continue
+			}
+			if len(sent.Metadata) != len(tt.metadata) {
+				t.Errorf("#%d: req #%d: got %d metadata keys want %d", i, j, len(sent.Metadata), len(tt.metadata))
+			}
+		}
+	}
+}
+
+func TestCompressedRequestsAreLoggedDecompressed(t *testing.T) {
+	client := newTestClient(t)
+
+	chargeBody, err := ioutil.ReadFile("testdata/chargeResp1.json")
+	if err != nil {
+		t.Fatalf("reading charge fixture: %v", err)
+	}
+
+	var logs []*securionpay.RequestLog
+	client.SetLogger(func(rl *securionpay.RequestLog) { logs = append(logs, rl) })
+	client.SetBodyLogging(true)
+	client.SetCompressRequests(true)
+	crt := &cannedRoundTripper{body: string(chargeBody)}
+	client.SetHTTPRoundTripper(crt)
+
+	charge := &securionpay.Charge{
+		Card:        tokenID1,
+		Currency:    securionpay.USD,
+		Description: "card 4242424242424242 " + strings.Repeat("a", 1200),
+
+		AmountMinorCurrencyUnits: 1500,
+	}
+	if _, err := client.Charge(charge); err != nil {
+		t.Fatalf("charging: %v", err)
+	}
+
+	if got := crt.requests()[0].Header.Get("Content-Encoding"); got != "gzip" {
+		t.Fatalf("got Content-Encoding=%q, want the request to be compressed", got)
+	}
+	if len(logs) != 1 {
+		t.Fatalf("got %d logs, want 1", len(logs))
+	}
+	logged := new(securionpay.Charge)
+	if err := json.Unmarshal(logs[0].RequestBody, logged); err != nil {
+		t.Fatalf("the logged request body isn't readable JSON: %v", err)
+	}
+	if strings.Contains(logged.Description, "4242424242424242") {
+		t.Errorf("the card number wasn't redacted from the logged body: %q", logged.Description[:40])
+	}
+}
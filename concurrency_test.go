@@ -0,0 +1,88 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/orijtech/securionpay"
+)
+
+// TestConcurrentClientUse hammers a single client with concurrent
+// reconfiguration and requests, to be run with the race detector.
+func TestConcurrentClientUse(t *testing.T) {
+	client := newTestClient(t)
+
+	chargeBody, err := ioutil.ReadFile("testdata/chargeResp1.json")
+	if err != nil {
+		t.Fatalf("reading charge fixture: %v", err)
+	}
+	newRoundTripper := func() *cannedRoundTripper {
+		return &cannedRoundTripper{body: string(chargeBody)}
+	}
+	client.SetHTTPRoundTripper(newRoundTripper())
+
+	const n = 50
+	var wg sync.WaitGroup
+	errsChan := make(chan error, 2*n)
+
+	for i := 0; i < n; i++ {
+		wg.Add(5)
+		go func(i int) {
+			defer wg.Done()
+			client.SetAPIKey(fmt.Sprintf("sk_test_key%d", i))
+		}(i)
+		go func() {
+			defer wg.Done()
+			client.SetHTTPRoundTripper(newRoundTripper())
+		}()
+		go func(i int) {
+			defer wg.Done()
+			client.SetTimeouts(time.Second, time.Duration(i+1)*time.Second)
+			client.SetRetryPolicy(&securionpay.RetryPolicy{MaxRetries: i % 3})
+			client.SetAuthScheme(securionpay.AuthScheme(i % 2))
+			client.SetLogger(func(*securionpay.RequestLog) {})
+			_ = client.String()
+		}(i)
+		go func() {
+			defer wg.Done()
+			_, err := client.Charge(&securionpay.Charge{
+				Card:     tokenID1,
+				Currency: securionpay.Euros,
+
+				AmountMinorCurrencyUnits: 499,
+			})
+			errsChan <- err
+		}()
+		go func() {
+			defer wg.Done()
+			_, err := client.FindTokenByID(tokenID1)
+			errsChan <- err
+		}()
+	}
+
+	wg.Wait()
+	close(errsChan)
+
+	for err := range errsChan {
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+}
@@ -0,0 +1,414 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type isoCountry struct {
+	alpha3 string
+	names  []string
+}
+
+// isoCountries are the ISO 3166-1 countries keyed by their alpha-2 code.
+var isoCountries = map[string]isoCountry{
+	"AD": {alpha3: "AND", names: []string{"Andorra", "Principality of Andorra"}},
+	"AE": {alpha3: "ARE", names: []string{"United Arab Emirates"}},
+	"AF": {alpha3: "AFG", names: []string{"Afghanistan", "Islamic Republic of Afghanistan"}},
+	"AG": {alpha3: "ATG", names: []string{"Antigua and Barbuda"}},
+	"AI": {alpha3: "AIA", names: []string{"Anguilla"}},
+	"AL": {alpha3: "ALB", names: []string{"Albania", "Republic of Albania"}},
+	"AM": {alpha3: "ARM", names: []string{"Armenia", "Republic of Armenia"}},
+	"AO": {alpha3: "AGO", names: []string{"Angola", "Republic of Angola"}},
+	"AQ": {alpha3: "ATA", names: []string{"Antarctica"}},
+	"AR": {alpha3: "ARG", names: []string{"Argentina", "Argentine Republic"}},
+	"AS": {alpha3: "ASM", names: []string{"American Samoa"}},
+	"AT": {alpha3: "AUT", names: []string{"Austria", "Republic of Austria"}},
+	"AU": {alpha3: "AUS", names: []string{"Australia"}},
+	"AW": {alpha3: "ABW", names: []string{"Aruba"}},
+	"AX": {alpha3: "ALA", names: []string{"Åland Islands"}},
+	"AZ": {alpha3: "AZE", names: []string{"Azerbaijan", "Republic of Azerbaijan"}},
+	"BA": {alpha3: "BIH", names: []string{"Bosnia and Herzegovina", "Republic of Bosnia and Herzegovina"}},
+	"BB": {alpha3: "BRB", names: []string{"Barbados"}},
+	"BD": {alpha3: "BGD", names: []string{"Bangladesh", "People's Republic of Bangladesh"}},
+	"BE": {alpha3: "BEL", names: []string{"Belgium", "Kingdom of Belgium"}},
+	"BF": {alpha3: "BFA", names: []string{"Burkina Faso"}},
+	"BG": {alpha3: "BGR", names: []string{"Bulgaria", "Republic of Bulgaria"}},
+	"BH": {alpha3: "BHR", names: []string{"Bahrain", "Kingdom of Bahrain"}},
+	"BI": {alpha3: "BDI", names: []string{"Burundi", "Republic of Burundi"}},
+	"BJ": {alpha3: "BEN", names: []string{"Benin", "Republic of Benin"}},
+	"BL": {alpha3: "BLM", names: []string{"Saint Barthélemy"}},
+	"BM": {alpha3: "BMU", names: []string{"Bermuda"}},
+	"BN": {alpha3: "BRN", names: []string{"Brunei Darussalam"}},
+	"BO": {alpha3: "BOL", names: []string{"Bolivia, Plurinational State of", "Bolivia", "Plurinational State of Bolivia"}},
+	"BQ": {alpha3: "BES", names: []string{"Bonaire, Sint Eustatius and Saba"}},
+	"BR": {alpha3: "BRA", names: []string{"Brazil", "Federative Republic of Brazil"}},
+	"BS": {alpha3: "BHS", names: []string{"Bahamas", "Commonwealth of the Bahamas"}},
+	"BT": {alpha3: "BTN", names: []string{"Bhutan", "Kingdom of Bhutan"}},
+	"BV": {alpha3: "BVT", names: []string{"Bouvet Island"}},
+	"BW": {alpha3: "BWA", names: []string{"Botswana", "Republic of Botswana"}},
+	"BY": {alpha3: "BLR", names: []string{"Belarus", "Republic of Belarus"}},
+	"BZ": {alpha3: "BLZ", names: []string{"Belize"}},
+	"CA": {alpha3: "CAN", names: []string{"Canada"}},
+	"CC": {alpha3: "CCK", names: []string{"Cocos (Keeling) Islands"}},
+	"CD": {alpha3: "COD", names: []string{"Congo, The Democratic Republic of the"}},
+	"CF": {alpha3: "CAF", names: []string{"Central African Republic"}},
+	"CG": {alpha3: "COG", names: []string{"Congo", "Republic of the Congo"}},
+	"CH": {alpha3: "CHE", names: []string{"Switzerland", "Swiss Confederation"}},
+	"CI": {alpha3: "CIV", names: []string{"Côte d'Ivoire", "Republic of Côte d'Ivoire"}},
+	"CK": {alpha3: "COK", names: []string{"Cook Islands"}},
+	"CL": {alpha3: "CHL", names: []string{"Chile", "Republic of Chile"}},
+	"CM": {alpha3: "CMR", names: []string{"Cameroon", "Republic of Cameroon"}},
+	"CN": {alpha3: "CHN", names: []string{"China", "People's Republic of China"}},
+	"CO": {alpha3: "COL", names: []string{"Colombia", "Republic of Colombia"}},
+	"CR": {alpha3: "CRI", names: []string{"Costa Rica", "Republic of Costa Rica"}},
+	"CU": {alpha3: "CUB", names: []string{"Cuba", "Republic of Cuba"}},
+	"CV": {alpha3: "CPV", names: []string{"Cabo Verde", "Republic of Cabo Verde"}},
+	"CW": {alpha3: "CUW", names: []string{"Curaçao"}},
+	"CX": {alpha3: "CXR", names: []string{"Christmas Island"}},
+	"CY": {alpha3: "CYP", names: []string{"Cyprus", "Republic of Cyprus"}},
+	"CZ": {alpha3: "CZE", names: []string{"Czechia", "Czech Republic"}},
+	"DE": {alpha3: "DEU", names: []string{"Germany", "Federal Republic of Germany"}},
+	"DJ": {alpha3: "DJI", names: []string{"Djibouti", "Republic of Djibouti"}},
+	"DK": {alpha3: "DNK", names: []string{"Denmark", "Kingdom of Denmark"}},
+	"DM": {alpha3: "DMA", names: []string{"Dominica", "Commonwealth of Dominica"}},
+	"DO": {alpha3: "DOM", names: []string{"Dominican Republic"}},
+	"DZ": {alpha3: "DZA", names: []string{"Algeria", "People's Democratic Republic of Algeria"}},
+	"EC": {alpha3: "ECU", names: []string{"Ecuador", "Republic of Ecuador"}},
+	"EE": {alpha3: "EST", names: []string{"Estonia", "Republic of Estonia"}},
+	"EG": {alpha3: "EGY", names: []string{"Egypt", "Arab Republic of Egypt"}},
+	"EH": {alpha3: "ESH", names: []string{"Western Sahara"}},
+	"ER": {alpha3: "ERI", names: []string{"Eritrea", "the State of Eritrea"}},
+	"ES": {alpha3: "ESP", names: []string{"Spain", "Kingdom of Spain"}},
+	"ET": {alpha3: "ETH", names: []string{"Ethiopia", "Federal Democratic Republic of Ethiopia"}},
+	"FI": {alpha3: "FIN", names: []string{"Finland", "Republic of Finland"}},
+	"FJ": {alpha3: "FJI", names: []string{"Fiji", "Republic of Fiji"}},
+	"FK": {alpha3: "FLK", names: []string{"Falkland Islands (Malvinas)"}},
+	"FM": {alpha3: "FSM", names: []string{"Micronesia, Federated States of", "Federated States of Micronesia"}},
+	"FO": {alpha3: "FRO", names: []string{"Faroe Islands"}},
+	"FR": {alpha3: "FRA", names: []string{"France", "French Republic"}},
+	"GA": {alpha3: "GAB", names: []string{"Gabon", "Gabonese Republic"}},
+	"GB": {alpha3: "GBR", names: []string{"United Kingdom", "United Kingdom of Great Britain and Northern Ireland"}},
+	"GD": {alpha3: "GRD", names: []string{"Grenada"}},
+	"GE": {alpha3: "GEO", names: []string{"Georgia"}},
+	"GF": {alpha3: "GUF", names: []string{"French Guiana"}},
+	"GG": {alpha3: "GGY", names: []string{"Guernsey"}},
+	"GH": {alpha3: "GHA", names: []string{"Ghana", "Republic of Ghana"}},
+	"GI": {alpha3: "GIB", names: []string{"Gibraltar"}},
+	"GL": {alpha3: "GRL", names: []string{"Greenland"}},
+	"GM": {alpha3: "GMB", names: []string{"Gambia", "Republic of the Gambia"}},
+	"GN": {alpha3: "GIN", names: []string{"Guinea", "Republic of Guinea"}},
+	"GP": {alpha3: "GLP", names: []string{"Guadeloupe"}},
+	"GQ": {alpha3: "GNQ", names: []string{"Equatorial Guinea", "Republic of Equatorial Guinea"}},
+	"GR": {alpha3: "GRC", names: []string{"Greece", "Hellenic Republic"}},
+	"GS": {alpha3: "SGS", names: []string{"South Georgia and the South Sandwich Islands"}},
+	"GT": {alpha3: "GTM", names: []string{"Guatemala", "Republic of Guatemala"}},
+	"GU": {alpha3: "GUM", names: []string{"Guam"}},
+	"GW": {alpha3: "GNB", names: []string{"Guinea-Bissau", "Republic of Guinea-Bissau"}},
+	"GY": {alpha3: "GUY", names: []string{"Guyana", "Republic of Guyana"}},
+	"HK": {alpha3: "HKG", names: []string{"Hong Kong", "Hong Kong Special Administrative Region of China"}},
+	"HM": {alpha3: "HMD", names: []string{"Heard Island and McDonald Islands"}},
+	"HN": {alpha3: "HND", names: []string{"Honduras", "Republic of Honduras"}},
+	"HR": {alpha3: "HRV", names: []string{"Croatia", "Republic of Croatia"}},
+	"HT": {alpha3: "HTI", names: []string{"Haiti", "Republic of Haiti"}},
+	"HU": {alpha3: "HUN", names: []string{"Hungary"}},
+	"ID": {alpha3: "IDN", names: []string{"Indonesia", "Republic of Indonesia"}},
+	"IE": {alpha3: "IRL", names: []string{"Ireland"}},
+	"IL": {alpha3: "ISR", names: []string{"Israel", "State of Israel"}},
+	"IM": {alpha3: "IMN", names: []string{"Isle of Man"}},
+	"IN": {alpha3: "IND", names: []string{"India", "Republic of India"}},
+	"IO": {alpha3: "IOT", names: []string{"British Indian Ocean Territory"}},
+	"IQ": {alpha3: "IRQ", names: []string{"Iraq", "Republic of Iraq"}},
+	"IR": {alpha3: "IRN", names: []string{"Iran, Islamic Republic of", "Iran", "Islamic Republic of Iran"}},
+	"IS": {alpha3: "ISL", names: []string{"Iceland", "Republic of Iceland"}},
+	"IT": {alpha3: "ITA", names: []string{"Italy", "Italian Republic"}},
+	"JE": {alpha3: "JEY", names: []string{"Jersey"}},
+	"JM": {alpha3: "JAM", names: []string{"Jamaica"}},
+	"JO": {alpha3: "JOR", names: []string{"Jordan", "Hashemite Kingdom of Jordan"}},
+	"JP": {alpha3: "JPN", names: []string{"Japan"}},
+	"KE": {alpha3: "KEN", names: []string{"Kenya", "Republic of Kenya"}},
+	"KG": {alpha3: "KGZ", names: []string{"Kyrgyzstan", "Kyrgyz Republic"}},
+	"KH": {alpha3: "KHM", names: []string{"Cambodia", "Kingdom of Cambodia"}},
+	"KI": {alpha3: "KIR", names: []string{"Kiribati", "Republic of Kiribati"}},
+	"KM": {alpha3: "COM", names: []string{"Comoros", "Union of the Comoros"}},
+	"KN": {alpha3: "KNA", names: []string{"Saint Kitts and Nevis"}},
+	"KP": {alpha3: "PRK", names: []string{"Korea, Democratic People's Republic of", "North Korea", "Democratic People's Republic of Korea"}},
+	"KR": {alpha3: "KOR", names: []string{"Korea, Republic of", "South Korea"}},
+	"KW": {alpha3: "KWT", names: []string{"Kuwait", "State of Kuwait"}},
+	"KY": {alpha3: "CYM", names: []string{"Cayman Islands"}},
+	"KZ": {alpha3: "KAZ", names: []string{"Kazakhstan", "Republic of Kazakhstan"}},
+	"LA": {alpha3: "LAO", names: []string{"Lao People's Democratic Republic", "Laos"}},
+	"LB": {alpha3: "LBN", names: []string{"Lebanon", "Lebanese Republic"}},
+	"LC": {alpha3: "LCA", names: []string{"Saint Lucia"}},
+	"LI": {alpha3: "LIE", names: []string{"Liechtenstein", "Principality of Liechtenstein"}},
+	"LK": {alpha3: "LKA", names: []string{"Sri Lanka", "Democratic Socialist Republic of Sri Lanka"}},
+	"LR": {alpha3: "LBR", names: []string{"Liberia", "Republic of Liberia"}},
+	"LS": {alpha3: "LSO", names: []string{"Lesotho", "Kingdom of Lesotho"}},
+	"LT": {alpha3: "LTU", names: []string{"Lithuania", "Republic of Lithuania"}},
+	"LU": {alpha3: "LUX", names: []string{"Luxembourg", "Grand Duchy of Luxembourg"}},
+	"LV": {alpha3: "LVA", names: []string{"Latvia", "Republic of Latvia"}},
+	"LY": {alpha3: "LBY", names: []string{"Libya"}},
+	"MA": {alpha3: "MAR", names: []string{"Morocco", "Kingdom of Morocco"}},
+	"MC": {alpha3: "MCO", names: []string{"Monaco", "Principality of Monaco"}},
+	"MD": {alpha3: "MDA", names: []string{"Moldova, Republic of", "Moldova", "Republic of Moldova"}},
+	"ME": {alpha3: "MNE", names: []string{"Montenegro"}},
+	"MF": {alpha3: "MAF", names: []string{"Saint Martin (French part)"}},
+	"MG": {alpha3: "MDG", names: []string{"Madagascar", "Republic of Madagascar"}},
+	"MH": {alpha3: "MHL", names: []string{"Marshall Islands", "Republic of the Marshall Islands"}},
+	"MK": {alpha3: "MKD", names: []string{"North Macedonia", "Republic of North Macedonia"}},
+	"ML": {alpha3: "MLI", names: []string{"Mali", "Republic of Mali"}},
+	"MM": {alpha3: "MMR", names: []string{"Myanmar", "Republic of Myanmar"}},
+	"MN": {alpha3: "MNG", names: []string{"Mongolia"}},
+	"MO": {alpha3: "MAC", names: []string{"Macao", "Macao Special Administrative Region of China"}},
+	"MP": {alpha3: "MNP", names: []string{"Northern Mariana Islands", "Commonwealth of the Northern Mariana Islands"}},
+	"MQ": {alpha3: "MTQ", names: []string{"Martinique"}},
+	"MR": {alpha3: "MRT", names: []string{"Mauritania", "Islamic Republic of Mauritania"}},
+	"MS": {alpha3: "MSR", names: []string{"Montserrat"}},
+	"MT": {alpha3: "MLT", names: []string{"Malta", "Republic of Malta"}},
+	"MU": {alpha3: "MUS", names: []string{"Mauritius", "Republic of Mauritius"}},
+	"MV": {alpha3: "MDV", names: []string{"Maldives", "Republic of Maldives"}},
+	"MW": {alpha3: "MWI", names: []string{"Malawi", "Republic of Malawi"}},
+	"MX": {alpha3: "MEX", names: []string{"Mexico", "United Mexican States"}},
+	"MY": {alpha3: "MYS", names: []string{"Malaysia"}},
+	"MZ": {alpha3: "MOZ", names: []string{"Mozambique", "Republic of Mozambique"}},
+	"NA": {alpha3: "NAM", names: []string{"Namibia", "Republic of Namibia"}},
+	"NC": {alpha3: "NCL", names: []string{"New Caledonia"}},
+	"NE": {alpha3: "NER", names: []string{"Niger", "Republic of the Niger"}},
+	"NF": {alpha3: "NFK", names: []string{"Norfolk Island"}},
+	"NG": {alpha3: "NGA", names: []string{"Nigeria", "Federal Republic of Nigeria"}},
+	"NI": {alpha3: "NIC", names: []string{"Nicaragua", "Republic of Nicaragua"}},
+	"NL": {alpha3: "NLD", names: []string{"Netherlands", "Kingdom of the Netherlands"}},
+	"NO": {alpha3: "NOR", names: []string{"Norway", "Kingdom of Norway"}},
+	"NP": {alpha3: "NPL", names: []string{"Nepal", "Federal Democratic Republic of Nepal"}},
+	"NR": {alpha3: "NRU", names: []string{"Nauru", "Republic of Nauru"}},
+	"NU": {alpha3: "NIU", names: []string{"Niue"}},
+	"NZ": {alpha3: "NZL", names: []string{"New Zealand"}},
+	"OM": {alpha3: "OMN", names: []string{"Oman", "Sultanate of Oman"}},
+	"PA": {alpha3: "PAN", names: []string{"Panama", "Republic of Panama"}},
+	"PE": {alpha3: "PER", names: []string{"Peru", "Republic of Peru"}},
+	"PF": {alpha3: "PYF", names: []string{"French Polynesia"}},
+	"PG": {alpha3: "PNG", names: []string{"Papua New Guinea", "Independent State of Papua New Guinea"}},
+	"PH": {alpha3: "PHL", names: []string{"Philippines", "Republic of the Philippines"}},
+	"PK": {alpha3: "PAK", names: []string{"Pakistan", "Islamic Republic of Pakistan"}},
+	"PL": {alpha3: "POL", names: []string{"Poland", "Republic of Poland"}},
+	"PM": {alpha3: "SPM", names: []string{"Saint Pierre and Miquelon"}},
+	"PN": {alpha3: "PCN", names: []string{"Pitcairn"}},
+	"PR": {alpha3: "PRI", names: []string{"Puerto Rico"}},
+	"PS": {alpha3: "PSE", names: []string{"Palestine, State of", "the State of Palestine"}},
+	"PT": {alpha3: "PRT", names: []string{"Portugal", "Portuguese Republic"}},
+	"PW": {alpha3: "PLW", names: []string{"Palau", "Republic of Palau"}},
+	"PY": {alpha3: "PRY", names: []string{"Paraguay", "Republic of Paraguay"}},
+	"QA": {alpha3: "QAT", names: []string{"Qatar", "State of Qatar"}},
+	"RE": {alpha3: "REU", names: []string{"Réunion"}},
+	"RO": {alpha3: "ROU", names: []string{"Romania"}},
+	"RS": {alpha3: "SRB", names: []string{"Serbia", "Republic of Serbia"}},
+	"RU": {alpha3: "RUS", names: []string{"Russian Federation"}},
+	"RW": {alpha3: "RWA", names: []string{"Rwanda", "Rwandese Republic"}},
+	"SA": {alpha3: "SAU", names: []string{"Saudi Arabia", "Kingdom of Saudi Arabia"}},
+	"SB": {alpha3: "SLB", names: []string{"Solomon Islands"}},
+	"SC": {alpha3: "SYC", names: []string{"Seychelles", "Republic of Seychelles"}},
+	"SD": {alpha3: "SDN", names: []string{"Sudan", "Republic of the Sudan"}},
+	"SE": {alpha3: "SWE", names: []string{"Sweden", "Kingdom of Sweden"}},
+	"SG": {alpha3: "SGP", names: []string{"Singapore", "Republic of Singapore"}},
+	"SH": {alpha3: "SHN", names: []string{"Saint Helena, Ascension and Tristan da Cunha"}},
+	"SI": {alpha3: "SVN", names: []string{"Slovenia", "Republic of Slovenia"}},
+	"SJ": {alpha3: "SJM", names: []string{"Svalbard and Jan Mayen"}},
+	"SK": {alpha3: "SVK", names: []string{"Slovakia", "Slovak Republic"}},
+	"SL": {alpha3: "SLE", names: []string{"Sierra Leone", "Republic of Sierra Leone"}},
+	"SM": {alpha3: "SMR", names: []string{"San Marino", "Republic of San Marino"}},
+	"SN": {alpha3: "SEN", names: []string{"Senegal", "Republic of Senegal"}},
+	"SO": {alpha3: "SOM", names: []string{"Somalia", "Federal Republic of Somalia"}},
+	"SR": {alpha3: "SUR", names: []string{"Suriname", "Republic of Suriname"}},
+	"SS": {alpha3: "SSD", names: []string{"South Sudan", "Republic of South Sudan"}},
+	"ST": {alpha3: "STP", names: []string{"Sao Tome and Principe", "Democratic Republic of Sao Tome and Principe"}},
+	"SV": {alpha3: "SLV", names: []string{"El Salvador", "Republic of El Salvador"}},
+	"SX": {alpha3: "SXM", names: []string{"Sint Maarten (Dutch part)"}},
+	"SY": {alpha3: "SYR", names: []string{"Syrian Arab Republic", "Syria"}},
+	"SZ": {alpha3: "SWZ", names: []string{"Eswatini", "Kingdom of Eswatini"}},
+	"TC": {alpha3: "TCA", names: []string{"Turks and Caicos Islands"}},
+	"TD": {alpha3: "TCD", names: []string{"Chad", "Republic of Chad"}},
+	"TF": {alpha3: "ATF", names: []string{"French Southern Territories"}},
+	"TG": {alpha3: "TGO", names: []string{"Togo", "Togolese Republic"}},
+	"TH": {alpha3: "THA", names: []string{"Thailand", "Kingdom of Thailand"}},
+	"TJ": {alpha3: "TJK", names: []string{"Tajikistan", "Republic of Tajikistan"}},
+	"TK": {alpha3: "TKL", names: []string{"Tokelau"}},
+	"TL": {alpha3: "TLS", names: []string{"Timor-Leste", "Democratic Republic of Timor-Leste"}},
+	"TM": {alpha3: "TKM", names: []string{"Turkmenistan"}},
+	"TN": {alpha3: "TUN", names: []string{"Tunisia", "Republic of Tunisia"}},
+	"TO": {alpha3: "TON", names: []string{"Tonga", "Kingdom of Tonga"}},
+	"TR": {alpha3: "TUR", names: []string{"Türkiye", "Republic of Türkiye"}},
+	"TT": {alpha3: "TTO", names: []string{"Trinidad and Tobago", "Republic of Trinidad and Tobago"}},
+	"TV": {alpha3: "TUV", names: []string{"Tuvalu"}},
+	"TW": {alpha3: "TWN", names: []string{"Taiwan, Province of China", "Taiwan"}},
+	"TZ": {alpha3: "TZA", names: []string{"Tanzania, United Republic of", "Tanzania", "United Republic of Tanzania"}},
+	"UA": {alpha3: "UKR", names: []string{"Ukraine"}},
+	"UG": {alpha3: "UGA", names: []string{"Uganda", "Republic of Uganda"}},
+	"UM": {alpha3: "UMI", names: []string{"United States Minor Outlying Islands"}},
+	"US": {alpha3: "USA", names: []string{"United States", "United States of America"}},
+	"UY": {alpha3: "URY", names: []string{"Uruguay", "Eastern Republic of Uruguay"}},
+	"UZ": {alpha3: "UZB", names: []string{"Uzbekistan", "Republic of Uzbekistan"}},
+	"VA": {alpha3: "VAT", names: []string{"Holy See (Vatican City State)"}},
+	"VC": {alpha3: "VCT", names: []string{"Saint Vincent and the Grenadines"}},
+	"VE": {alpha3: "VEN", names: []string{"Venezuela, Bolivarian Republic of", "Venezuela", "Bolivarian Republic of Venezuela"}},
+	"VG": {alpha3: "VGB", names: []string{"Virgin Islands, British", "British Virgin Islands"}},
+	"VI": {alpha3: "VIR", names: []string{"Virgin Islands, U.S.", "Virgin Islands of the United States"}},
+	"VN": {alpha3: "VNM", names: []string{"Viet Nam", "Vietnam", "Socialist Republic of Viet Nam"}},
+	"VU": {alpha3: "VUT", names: []string{"Vanuatu", "Republic of Vanuatu"}},
+	"WF": {alpha3: "WLF", names: []string{"Wallis and Futuna"}},
+	"WS": {alpha3: "WSM", names: []string{"Samoa", "Independent State of Samoa"}},
+	"YE": {alpha3: "YEM", names: []string{"Yemen", "Republic of Yemen"}},
+	"YT": {alpha3: "MYT", names: []string{"Mayotte"}},
+	"ZA": {alpha3: "ZAF", names: []string{"South Africa", "Republic of South Africa"}},
+	"ZM": {alpha3: "ZMB", names: []string{"Zambia", "Republic of Zambia"}},
+	"ZW": {alpha3: "ZWE", names: []string{"Zimbabwe", "Republic of Zimbabwe"}},
+}
+
+// countryAliases are common names of countries that aren't
+// amongst their ISO 3166-1 names, keyed in their folded form.
+var countryAliases = map[string]string{
+	"america":          "US",
+	"britain":          "GB",
+	"burma":            "MM",
+	"england":          "GB",
+	"great britain":    "GB",
+	"holland":          "NL",
+	"ivory coast":      "CI",
+	"macedonia":        "MK",
+	"northern ireland": "GB",
+	"scotland":         "GB",
+	"swaziland":        "SZ",
+	"turkey":           "TR",
+	"uk":               "GB",
+	"vatican":          "VA",
+	"wales":            "GB",
+}
+
+// countryCodesByName maps the folded alpha-3 codes, names and aliases
+// of countries to their alpha-2 codes.
+var countryCodesByName = func() map[string]string {
+	byName := make(map[string]string, 3*len(isoCountries)+len(countryAliases))
+	for code, country := range isoCountries {
+		byName[foldCountryName(country.alpha3)] = code
+		for _, name := range country.names {
+			byName[foldCountryName(name)] = code
+		}
+	}
+	for alias, code := range countryAliases {
+		byName[alias] = code
+	}
+	return byName
+}()
+
+// foldCountryName lowercases name and drops its dots and extra
+// whitespace so that e.g "U.S.A." and "usa" are looked up alike.
+func foldCountryName(name string) string {
+	name = strings.ToLower(strings.Replace(name, ".", "", -1))
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// NormalizeCountry returns the ISO 3166-1 alpha-2 code of the country
+// that name refers to, be it by its alpha-2 or alpha-3 code or by its
+// name e.g "US", "USA", "U.S.A." and "United States" all return "US".
+// It returns an error if name is blank or the country isn't recognized.
+func NormalizeCountry(name string) (string, error) {
+	if strings.TrimSpace(name) == "" {
+		return "", errBlankCountry
+	}
+	if code := strings.ToUpper(strings.TrimSpace(name)); len(code) == 2 {
+		if _, known := isoCountries[code]; known {
+			return code, nil
+		}
+	}
+	if code, known := countryCodesByName[foldCountryName(name)]; known {
+		return code, nil
+	}
+	return "", fmt.Errorf("unrecognized country %q", name)
+}
+
+var errBlankCountry = errors.New("expecting a non-blank country")
+
+// Country is a country as SecurionPay expects it, that is by its ISO
+// 3166-1 alpha-2 code such as "US". Countries that NormalizeCountry
+// recognizes, e.g "USA" or "United States", are sent as their alpha-2
+// code while any others are sent as is.
+type Country string
+
+var _ json.Marshaler = Country("")
+
+// MarshalJSON encodes the country as its ISO 3166-1 alpha-2 code if it
+// is recognized. It has a value receiver so that countries are encoded
+// the same whether or not they are addressable.
+func (c Country) MarshalJSON() ([]byte, error) {
+	if code, err := NormalizeCountry(string(c)); err == nil {
+		return json.Marshal(code)
+	}
+	return json.Marshal(strings.TrimSpace(string(c)))
+}
+
+// Normalize returns the ISO 3166-1 alpha-2 code of the country,
+// see NormalizeCountry, or an error if it isn't recognized.
+func (c Country) Normalize() (Country, error) {
+	code, err := NormalizeCountry(string(c))
+	if err != nil {
+		return "", err
+	}
+	return Country(code), nil
+}
+
+// Validate checks that the country is recognized by NormalizeCountry,
+// hence that it is sent as an ISO 3166-1 alpha-2 code.
+func (c Country) Validate() error {
+	_, err := c.Normalize()
+	return err
+}
+
+// ValidateCountry checks that country is recognized by NormalizeCountry,
+// be it by its ISO 3166-1 code such as "US" or by its name, which is
+// then sent as the alpha-2 code that SecurionPay expects.
+func ValidateCountry(country string) error {
+	return Country(country).Validate()
+}
+
+// ValidateCountry checks that the address' country, if set, is
+// recognized, see Country.Validate.
+func (a *Address) ValidateCountry() error {
+	if a == nil || strings.TrimSpace(string(a.Country)) == "" {
+		return nil
+	}
+	return a.Country.Validate()
+}
+
+// ValidateCountries checks that the countries of the charge's shipping
+// and billing addresses, if set, are recognized, see Country.Validate.
+func (creq *Charge) ValidateCountries() error {
+	if creq == nil {
+		return errBlankCharge
+	}
+	if creq.Shipping != nil {
+		if err := creq.Shipping.Address.ValidateCountry(); err != nil {
+			return fmt.Errorf("shipping: %v", err)
+		}
+	}
+	if creq.Billing != nil {
+		if err := creq.Billing.Address.ValidateCountry(); err != nil {
+			return fmt.Errorf("billing: %v", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/orijtech/securionpay"
+)
+
+func TestNormalizeCountry(t *testing.T) {
+	tests := [...]struct {
+		name     string
+		wantCode string
+		wantErr  bool
+	}{
+		0:  {name: "US", wantCode: "US"},
+		1:  {name: " us ", wantCode: "US"},
+		2:  {name: "USA", wantCode: "US"},
+		3:  {name: "U.S.A.", wantCode: "US"},
+		4:  {name: "united states of america", wantCode: "US"},
+		5:  {name: "UK", wantCode: "GB"},
+		6:  {name: "Great  Britain", wantCode: "GB"},
+		7:  {name: "Poland", wantCode: "PL"},
+		8:  {name: "South Korea", wantCode: "KR"},
+		9:  {name: "Côte d'Ivoire", wantCode: "CI"},
+		10: {name: "Ivory Coast", wantCode: "CI"},
+		11: {name: "", wantErr: true},
+		12: {name: "XX", wantErr: true},
+		13: {name: "Atlantis", wantErr: true},
+	}
+
+	for i, tt := range tests {
+		code, err := securionpay.NormalizeCountry(tt.name)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("#%d: NormalizeCountry(%q)=%q, expected an error", i, tt.name, code)
+			}
+			continue
+		}
+		if err != nil || code != tt.wantCode {
+			t.Errorf("#%d: NormalizeCountry(%q)=(%q, %v) want %q", i, tt.name, code, err, tt.wantCode)
+		}
+	}
+}
+
+func TestValidateCountries(t *testing.T) {
+	address := func(country securionpay.Country) *securionpay.Address {
+		return &securionpay.Address{Line1: "Cicha 1", Country: country}
+	}
+
+	tests := [...]struct {
+		shipping *securionpay.Shipping
+		billing  *securionpay.Billing
+		wantErr  bool
+	}{
+		0: {},
+		1: {shipping: &securionpay.Shipping{Name: "John Doe"}},
+		2: {shipping: &securionpay.Shipping{Name: "John Doe", Address: address("PL")}, billing: &securionpay.Billing{Address: address("US")}},
+		3: {shipping: &securionpay.Shipping{Name: "John Doe", Address: address("USA")}},
+		4: {billing: &securionpay.Billing{Address: address("us")}},
+		5: {billing: &securionpay.Billing{Address: address("XX")}, wantErr: true},
+		6: {shipping: &securionpay.Shipping{Name: "John Doe", Address: address("Narnia")}, wantErr: true},
+	}
+
+	for i, tt := range tests {
+		charge := &securionpay.Charge{Card: tokenID1, Shipping: tt.shipping, Billing: tt.billing}
+		err := charge.ValidateCountries()
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("#%d: expected a non-nil error", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: err: %v", i, err)
+		}
+	}
+
+	// Validate accepts countries that are sent normalized but
+	// rejects those that SecurionPay would reject.
+	charge := &securionpay.Charge{Card: tokenID1, Billing: &securionpay.Billing{Address: address("USA")}, AmountMinorCurrencyUnits: 499}
+	if err := charge.Validate(); err != nil {
+		t.Errorf("unexpected error from Validate: %v", err)
+	}
+	charge.Billing.Address.Country = "Narnia"
+	if err := charge.Validate(); err == nil {
+		t.Error("expected an unrecognized billing country to be rejected")
+	}
+}
+
+func TestCountry(t *testing.T) {
+	tests := [...]struct {
+		country securionpay.Country
+
+		wantJSON     string
+		wantCode     securionpay.Country
+		wantNormErr  bool
+		wantValidErr bool
+	}{
+		0: {country: "US", wantJSON: `"US"`, wantCode: "US"},
+		1: {country: "USA", wantJSON: `"US"`, wantCode: "US"},
+		2: {country: "United States", wantJSON: `"US"`, wantCode: "US"},
+		3: {country: " pl ", wantJSON: `"PL"`, wantCode: "PL"},
+		4: {country: "Atlantis ", wantJSON: `"Atlantis"`, wantNormErr: true, wantValidErr: true},
+		5: {country: "", wantJSON: `""`, wantNormErr: true, wantValidErr: true},
+	}
+
+	for i, tt := range tests {
+		blob, err := json.Marshal(&securionpay.Address{Country: tt.country})
+		if err != nil {
+			t.Errorf("#%d: marshalling err: %v", i, err)
+			continue
+		}
+		if want := `"country":` + tt.wantJSON + `}`; !strings.HasSuffix(string(blob), want) {
+			t.Errorf("#%d: got %s want it to end with %s", i, blob, want)
+		}
+
+		code, err := tt.country.Normalize()
+		if tt.wantNormErr {
+			if err == nil {
+				t.Errorf("#%d: expected a Normalize error", i)
+			}
+		} else if err != nil || code != tt.wantCode {
+			t.Errorf("#%d: Normalize()=(%q, %v) want %q", i, code, err, tt.wantCode)
+		}
+
+		if err := tt.country.Validate(); (err != nil) != tt.wantValidErr {
+			t.Errorf("#%d: Validate() err=%v wantErr=%v", i, err, tt.wantValidErr)
+		}
+	}
+
+	// Billing VAT numbers are checked against countries given by name too.
+	billing := &securionpay.Billing{VAT: "DE123456789", Address: &securionpay.Address{Line1: "Cicha 1", Country: "Poland"}}
+	if err := billing.Validate(); err == nil {
+		t.Error("expected the German VAT number of a Polish billing address to be rejected")
+	}
+}
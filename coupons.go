@@ -0,0 +1,289 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// CouponDuration is how long a coupon's discount applies
+// to a subscription once the coupon is attached to it.
+type CouponDuration string
+
+const (
+	CouponOnce      CouponDuration = "once"
+	CouponRepeating CouponDuration = "repeating"
+	CouponForever   CouponDuration = "forever"
+)
+
+// Coupon is a discount for subscriptions. A coupon takes off either a
+// percentage or a fixed amount, but not both, of each invoice it applies to.
+type Coupon struct {
+	ID         string     `json:"id,omitempty"`
+	CreatedAt  int64      `json:"created,omitempty"`
+	ObjectType ObjectType `json:"objectType,omitempty"`
+
+	// PercentOff is the percentage, from 1 to 100, discounted.
+	PercentOff int `json:"percentOff,omitempty"`
+
+	// AmountOff is the amount discounted in minor amounts
+	// of Currency, which must then be set.
+	AmountOff int      `json:"amountOff,omitempty"`
+	Currency  Currency `json:"currency,omitempty"`
+
+	Duration CouponDuration `json:"duration,omitempty"`
+
+	// DurationInMonths is the number of months that
+	// a coupon with the repeating duration applies for.
+	DurationInMonths int `json:"durationInMonths,omitempty"`
+
+	// MaxRedemptions is the number of times the coupon can be
+	// redeemed across all customers, or 0 for no limit.
+	MaxRedemptions int `json:"maxRedemptions,omitempty"`
+	TimesRedeemed  int `json:"timesRedeemed,omitempty"`
+
+	// RedeemBy is the time in seconds since the Unix epoch
+	// after which the coupon can no longer be redeemed.
+	RedeemBy int64 `json:"redeemBy,omitempty"`
+
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+	Deleted  bool                   `json:"deleted,omitempty"`
+}
+
+var (
+	errBlankCoupon                  = errors.New("expecting a non-blank coupon")
+	errBlankCouponID                = errors.New("expecting a non-blank coupon ID")
+	errEitherPercentOrAmountOff     = errors.New("expecting exactly one of PercentOff or AmountOff to be set")
+	errPercentOffOutOfRange         = errors.New("expecting PercentOff to be between 1 and 100")
+	errNegativeAmountOff            = errors.New("expecting a non-negative AmountOff")
+	errAmountOffWithoutCurrency     = errors.New("expecting Currency to be set for AmountOff")
+	errNegativeMaxRedemptions       = errors.New("expecting a non-negative MaxRedemptions")
+	errRepeatingWithoutMonths       = errors.New("expecting a positive DurationInMonths for a repeating coupon")
+	errDurationInMonthsNotRepeating = errors.New("DurationInMonths can only be set for repeating coupons")
+)
+
+func (cp *Coupon) Validate() error {
+	if cp == nil {
+		return errBlankCoupon
+	}
+	if cp.PercentOff < 0 || cp.PercentOff > 100 {
+		return errPercentOffOutOfRange
+	}
+	if cp.AmountOff < 0 {
+		return errNegativeAmountOff
+	}
+	if (cp.PercentOff == 0) == (cp.AmountOff == 0) {
+		return errEitherPercentOrAmountOff
+	}
+	if cp.AmountOff > 0 && strings.TrimSpace(string(cp.Currency)) == "" {
+		return errAmountOffWithoutCurrency
+	}
+	if cp.MaxRedemptions < 0 {
+		return errNegativeMaxRedemptions
+	}
+
+	switch cp.Duration {
+	case CouponRepeating:
+		if cp.DurationInMonths < 1 {
+			return errRepeatingWithoutMonths
+		}
+	case "", CouponOnce, CouponForever:
+		if cp.DurationInMonths != 0 {
+			return errDurationInMonthsNotRepeating
+		}
+	default:
+		return fmt.Errorf("unknown coupon duration %q", cp.Duration)
+	}
+	return validateMetadata(cp.Metadata)
+}
+
+type CouponList struct {
+	Coupons []*Coupon `json:"list"`
+
+	HasMore    bool `json:"hasMore"`
+	TotalCount int  `json:"totalCount,omitempty"`
+}
+
+type CouponListRequest struct {
+	Limit int `json:"limit,omitempty"`
+
+	CreatedAfter      int64 `json:"gt,omitempty"`
+	CreatedOnOrAfter  int64 `json:"gte,omitempty"`
+	CreatedBefore     int64 `json:"lt,omitempty"`
+	CreatedOnOrBefore int64 `json:"lte,omitempty"`
+
+	StartingAfterId   string `json:"startingAfterId,omitempty"`
+	EndingBeforeId    string `json:"endingBeforeId,omitempty"`
+	IncludeTotalCount bool   `json:"includeTotalCount,omitempty"`
+}
+
+const (
+	couponsEndpointURL = "https://api.securionpay.com/coupons"
+	defaultCouponLimit = 10
+)
+
+func (clr *CouponListRequest) Validate() error {
+	if clr == nil {
+		return nil
+	}
+	return validateCreatedRange(clr.CreatedAfter, clr.CreatedOnOrAfter, clr.CreatedBefore, clr.CreatedOnOrBefore)
+}
+
+// POST https://api.securionpay.com/coupons
+func (c *Client) CreateCoupon(cp *Coupon) (*Coupon, error) {
+	if err := cp.Validate(); err != nil {
+		return nil, err
+	}
+
+	blob, err := json.Marshal(cp)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", couponsEndpointURL, bytes.NewReader(blob))
+	if err != nil {
+		return nil, err
+	}
+	return c.doCouponRequest(req)
+}
+
+// GET https://api.securionpay.com/coupons/{COUPON_ID}
+func (c *Client) FindCouponByID(couponID string) (*Coupon, error) {
+	couponID = strings.TrimSpace(couponID)
+	if couponID == "" {
+		return nil, errBlankCouponID
+	}
+
+	fullURL := fmt.Sprintf("%s/%s", couponsEndpointURL, couponID)
+	req, err := http.NewRequest("GET", fullURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.doCouponRequest(req)
+}
+
+// DeleteCoupon deletes a coupon so that it can no longer be redeemed.
+// Subscriptions that the coupon was already attached to keep their discount.
+//
+// DELETE https://api.securionpay.com/coupons/{COUPON_ID}
+func (c *Client) DeleteCoupon(couponID string) error {
+	couponID = strings.TrimSpace(couponID)
+	if couponID == "" {
+		return errBlankCouponID
+	}
+
+	fullURL := fmt.Sprintf("%s/%s", couponsEndpointURL, couponID)
+	req, err := http.NewRequest("DELETE", fullURL, nil)
+	if err != nil {
+		return err
+	}
+	_, err = c.doAuthThenReqAndSlurpResponse(req)
+	return err
+}
+
+// GET https://api.securionpay.com/coupons
+func (c *Client) ListCoupons(clr *CouponListRequest) (*CouponList, error) {
+	if err := clr.Validate(); err != nil {
+		return nil, err
+	}
+
+	clreq := new(CouponListRequest)
+	if clr != nil {
+		*clreq = *clr
+	}
+
+	if clreq.Limit < 1 {
+		clreq.Limit = defaultCouponLimit
+	}
+
+	qv, err := listQuery(clreq)
+	if err != nil {
+		return nil, err
+	}
+
+	fullURL := buildURL("/coupons", qv)
+	req, err := http.NewRequest("GET", fullURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	slurp, err := c.doAuthThenReqAndSlurpResponse(req)
+	if err != nil {
+		return nil, err
+	}
+	coupons := new(CouponList)
+	if err := c.decodeResponse(slurp, coupons); err != nil {
+		return nil, err
+	}
+	return coupons, nil
+}
+
+func (c *Client) doCouponRequest(req *http.Request) (*Coupon, error) {
+	blob, err := c.doAuthThenReqAndSlurpResponse(req)
+	if err != nil {
+		return nil, err
+	}
+
+	coupon := new(Coupon)
+	if err := c.decodeResponse(blob, coupon); err != nil {
+		return nil, err
+	}
+	return coupon, nil
+}
+
+type applyCouponRequest struct {
+	CouponID string `json:"couponId"`
+}
+
+// ApplyCoupon attaches a coupon to a subscription so that the
+// coupon's discount applies to the subscription's next invoices.
+//
+// POST https://api.securionpay.com/subscriptions/{SUBSCRIPTION_ID}
+func (c *Client) ApplyCoupon(subscriptionID, couponID string) (*Subscription, error) {
+	subscriptionID = strings.TrimSpace(subscriptionID)
+	if subscriptionID == "" {
+		return nil, errBlankSubscriptionID
+	}
+	couponID = strings.TrimSpace(couponID)
+	if couponID == "" {
+		return nil, errBlankCouponID
+	}
+
+	blob, err := json.Marshal(&applyCouponRequest{CouponID: couponID})
+	if err != nil {
+		return nil, err
+	}
+
+	fullURL := fmt.Sprintf("%s/%s", subscriptionsEndpointURL, subscriptionID)
+	req, err := http.NewRequest("POST", fullURL, bytes.NewReader(blob))
+	if err != nil {
+		return nil, err
+	}
+
+	blob, err = c.doAuthThenReqAndSlurpResponse(req)
+	if err != nil {
+		return nil, err
+	}
+
+	sub := new(Subscription)
+	if err := c.decodeResponse(blob, sub); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
@@ -0,0 +1,147 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay_test
+
+import (
+	"testing"
+
+	"github.com/orijtech/securionpay"
+)
+
+func TestCouponValidate(t *testing.T) {
+	tests := [...]struct {
+		coupon  *securionpay.Coupon
+		wantErr bool
+	}{
+		0: {coupon: nil, wantErr: true},
+		1: {coupon: &securionpay.Coupon{}, wantErr: true},
+		2: {coupon: &securionpay.Coupon{PercentOff: 10, AmountOff: 100, Currency: securionpay.Euros}, wantErr: true},
+		3: {coupon: &securionpay.Coupon{PercentOff: 101}, wantErr: true},
+		4: {coupon: &securionpay.Coupon{AmountOff: 100}, wantErr: true},
+		5: {coupon: &securionpay.Coupon{PercentOff: 10, Duration: securionpay.CouponRepeating}, wantErr: true},
+		6: {coupon: &securionpay.Coupon{PercentOff: 10, DurationInMonths: 3}, wantErr: true},
+		7: {coupon: &securionpay.Coupon{PercentOff: 10, Duration: "weekly"}, wantErr: true},
+		8: {coupon: &securionpay.Coupon{PercentOff: 10, MaxRedemptions: -1}, wantErr: true},
+		9: {coupon: &securionpay.Coupon{PercentOff: 25, Duration: securionpay.CouponForever}},
+		10: {
+			coupon: &securionpay.Coupon{
+				AmountOff:        500,
+				Currency:         securionpay.Euros,
+				Duration:         securionpay.CouponRepeating,
+				DurationInMonths: 3,
+				MaxRedemptions:   100,
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		err := tt.coupon.Validate()
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("#%d: expected an error", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: err: %v", i, err)
+		}
+	}
+}
+
+func TestCouponLifecycle(t *testing.T) {
+	couponBody := `{"id":"SPRING25","created":1415810511,"objectType":"coupon","percentOff":25,"duration":"forever","timesRedeemed":4}`
+	crt := &cannedRoundTripper{bodies: []string{
+		couponBody,
+		couponBody,
+		`{"list":[` + couponBody + `],"hasMore":false}`,
+		`{"id":"sub_1","planId":"plan_1","customerId":"cust_1","status":"active","couponId":"SPRING25"}`,
+		`{"id":"SPRING25","deleted":true}`,
+	}}
+	client := newClientWithRoundTripper(t, crt)
+
+	created, err := client.CreateCoupon(&securionpay.Coupon{
+		ID:         "SPRING25",
+		PercentOff: 25,
+		Duration:   securionpay.CouponForever,
+	})
+	if err != nil {
+		t.Fatalf("creating coupon: %v", err)
+	}
+	if created.ID != "SPRING25" || created.TimesRedeemed != 4 {
+		t.Errorf("got created coupon %#v", created)
+	}
+
+	found, err := client.FindCouponByID(" SPRING25 ")
+	if err != nil {
+		t.Fatalf("finding coupon: %v", err)
+	}
+	if found.PercentOff != 25 {
+		t.Errorf("got percentOff=%d", found.PercentOff)
+	}
+
+	coupons, err := client.ListCoupons(nil)
+	if err != nil {
+		t.Fatalf("listing coupons: %v", err)
+	}
+	if len(coupons.Coupons) != 1 {
+		t.Errorf("got %d coupons, want 1", len(coupons.Coupons))
+	}
+
+	sub, err := client.ApplyCoupon("sub_1", "SPRING25")
+	if err != nil {
+		t.Fatalf("applying coupon: %v", err)
+	}
+	if sub.CouponID != "SPRING25" {
+		t.Errorf("got couponID=%q", sub.CouponID)
+	}
+
+	if err := client.DeleteCoupon("SPRING25"); err != nil {
+		t.Fatalf("deleting coupon: %v", err)
+	}
+
+	wantReqs := [...]struct{ method, path, body string }{
+		0: {"POST", "/coupons", `{"id":"SPRING25","percentOff":25,"duration":"forever"}`},
+		1: {"GET", "/coupons/SPRING25", ""},
+		2: {"GET", "/coupons", ""},
+		3: {"POST", "/subscriptions/sub_1", `{"couponId":"SPRING25"}`},
+		4: {"DELETE", "/coupons/SPRING25", ""},
+	}
+	reqs, bodies := crt.requests(), crt.requestBodies()
+	if len(reqs) != len(wantReqs) {
+		t.Fatalf("got %d requests, want %d", len(reqs), len(wantReqs))
+	}
+	for i, want := range wantReqs {
+		if reqs[i].Method != want.method || reqs[i].URL.Path != want.path {
+			t.Errorf("#%d: got %s %s want %s %s", i, reqs[i].Method, reqs[i].URL.Path, want.method, want.path)
+		}
+		if got := string(bodies[i]); got != want.body {
+			t.Errorf("#%d: gotBody=%s want=%s", i, got, want.body)
+		}
+	}
+
+	// Blank IDs are rejected without making any requests.
+	if _, err := client.FindCouponByID(" "); err == nil {
+		t.Errorf("expected an error for a blank coupon ID")
+	}
+	if _, err := client.ApplyCoupon("sub_1", ""); err == nil {
+		t.Errorf("expected an error for a blank coupon ID")
+	}
+	if err := client.DeleteCoupon(""); err == nil {
+		t.Errorf("expected an error for a blank coupon ID")
+	}
+	if n := len(crt.requests()); n != len(wantReqs) {
+		t.Errorf("got %d requests, want %d", n, len(wantReqs))
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+)
+
+var (
+	chargesCSVHeader = []string{"id", "created", "amount", "currency", "status"}
+	creditsCSVHeader = []string{"id", "created", "amount", "currency", "description", "customerId"}
+)
+
+// EncodeChargesCSV writes charges to w as CSV, e.g for spreadsheets,
+// with a header row followed by one row per charge with its ID, creation
+// time in RFC 3339, amount in major currency units, currency and status.
+func EncodeChargesCSV(w io.Writer, charges []*ChargeResponse) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(chargesCSVHeader); err != nil {
+		return err
+	}
+	for _, cr := range charges {
+		if cr == nil {
+			continue
+		}
+		row := []string{
+			cr.ID,
+			cr.CreatedRFC3339(),
+			FormatAmount(int(cr.Amount), cr.Currency),
+			string(cr.Currency),
+			cr.csvStatus(),
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvStatus summarizes the state of the charge in a word.
+func (cr *ChargeResponse) csvStatus() string {
+	switch {
+	case cr.FailureCode != "" || cr.FailureMessage != "":
+		return "failed"
+	case cr.Disputed:
+		return "disputed"
+	case cr.Refunded:
+		return "refunded"
+	case cr.Captured:
+		return "captured"
+	case cr.RequiresAction:
+		return "requires_action"
+	default:
+		return "authorized"
+	}
+}
+
+// EncodeCreditsCSV writes credits to w as CSV with a header row,
+// escaping descriptions that spreadsheets would run as formulas.
+func EncodeCreditsCSV(w io.Writer, credits []*Credit) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(creditsCSVHeader); err != nil {
+		return err
+	}
+	for _, cr := range credits {
+		if cr == nil {
+			continue
+		}
+		row := []string{
+			cr.ID,
+			cr.CreatedRFC3339(),
+			FormatAmount(cr.AmountMinorCurrencyUnits, cr.Currency),
+			string(cr.Currency),
+			csvEscapeFormula(cr.Description),
+			string(cr.CustomerID),
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvEscapeFormula prefixes s with a single quote if it starts with a
+// character that makes spreadsheets evaluate the cell as a formula, so
+// that user supplied text such as "=HYPERLINK(...)" is shown as is.
+func csvEscapeFormula(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
@@ -0,0 +1,126 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/orijtech/securionpay"
+)
+
+func TestFormatAmount(t *testing.T) {
+	tests := [...]struct {
+		amount int
+		cur    securionpay.Currency
+		want   string
+	}{
+		0:  {amount: 1999, cur: securionpay.Euros, want: "19.99"},
+		1:  {amount: 5, cur: securionpay.USD, want: "0.05"},
+		2:  {amount: 1000, cur: "JPY", want: "1000"},
+		3:  {amount: 1000, cur: "jpy", want: "1000"},
+		4:  {amount: 12345, cur: "KWD", want: "12.345"},
+		5:  {amount: -250, cur: securionpay.CAD, want: "-2.50"},
+		6:  {amount: 0, cur: securionpay.Euros, want: "0.00"},
+		7:  {amount: 1000, cur: securionpay.JPY, want: "1000"},
+		8:  {amount: 4999, cur: securionpay.PLN, want: "49.99"},
+		9:  {amount: 1050, cur: securionpay.GBP, want: "10.50"},
+		10: {amount: 1, cur: securionpay.CHF, want: "0.01"},
+		11: {amount: 12500, cur: securionpay.AUD, want: "125.00"},
+		12: {amount: 9900, cur: securionpay.SEK, want: "99.00"},
+		13: {amount: 9900, cur: securionpay.NOK, want: "99.00"},
+		14: {amount: 7450, cur: securionpay.DKK, want: "74.50"},
+	}
+
+	for i, tt := range tests {
+		if got := securionpay.FormatAmount(tt.amount, tt.cur); got != tt.want {
+			t.Errorf("#%d: FormatAmount(%d, %q)=%q want %q", i, tt.amount, tt.cur, got, tt.want)
+		}
+	}
+}
+
+func TestEncodeChargesCSV(t *testing.T) {
+	var charges []*securionpay.ChargeResponse
+	for _, path := range []string{"testdata/chargeResp1.json", "testdata/chargeRespJPY.json", "testdata/chargeRespRefunded.json", "testdata/chargeResp3DS.json"} {
+		cr := new(securionpay.ChargeResponse)
+		if err := retrFromFile(path, cr); err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		charges = append(charges, cr)
+	}
+	charges = append(charges, nil)
+
+	buf := new(strings.Builder)
+	if err := securionpay.EncodeChargesCSV(buf, charges); err != nil {
+		t.Fatalf("encoding: %v", err)
+	}
+
+	rows := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(rows) != 5 {
+		t.Fatalf("got %d rows, want a header and 4 charges:\n%s", len(rows), buf.String())
+	}
+	if got, want := rows[0], "id,created,amount,currency,status"; got != want {
+		t.Errorf("got header=%q want=%q", got, want)
+	}
+	for i, cr := range charges[:4] {
+		want := strings.Join([]string{cr.ID, cr.CreatedRFC3339(), securionpay.FormatAmount(int(cr.Amount), cr.Currency), string(cr.Currency)}, ",")
+		if !strings.HasPrefix(rows[i+1], want+",") {
+			t.Errorf("#%d: got row=%q want prefix %q", i, rows[i+1], want)
+		}
+	}
+	if got := rows[2]; !strings.Contains(got, ",1000,JPY,") {
+		t.Errorf("got JPY row=%q, want the amount without decimals", got)
+	}
+	if got := rows[3]; !strings.HasSuffix(got, ",refunded") {
+		t.Errorf("got refunded row=%q", got)
+	}
+	if got := rows[4]; !strings.HasSuffix(got, ",requires_action") {
+		t.Errorf("got 3DS row=%q", got)
+	}
+}
+
+func TestEncodeCreditsCSV(t *testing.T) {
+	credit := new(securionpay.Credit)
+	if err := retrFromFile("testdata/credit.json", credit); err != nil {
+		t.Fatalf("decoding the credit: %v", err)
+	}
+
+	tests := [...]struct {
+		description string
+		wantColumn  string
+	}{
+		0: {description: "Refund, for the \"late\" delivery", wantColumn: `"Refund, for the ""late"" delivery"`},
+		1: {description: `=HYPERLINK("http://evil.example","Click")`, wantColumn: `"'=HYPERLINK(""http://evil.example"",""Click"")"`},
+		2: {description: "+1 voucher", wantColumn: "'+1 voucher"},
+		3: {description: "-2+3", wantColumn: "'-2+3"},
+		4: {description: "@SUM(A1:A2)", wantColumn: "'@SUM(A1:A2)"},
+		5: {description: "Loyalty credit = 5%", wantColumn: "Loyalty credit = 5%"},
+	}
+
+	for i, tt := range tests {
+		credit.Description = tt.description
+		buf := new(strings.Builder)
+		if err := securionpay.EncodeCreditsCSV(buf, []*securionpay.Credit{credit}); err != nil {
+			t.Errorf("#%d: encoding: %v", i, err)
+			continue
+		}
+
+		want := "id,created,amount,currency,description,customerId\n" +
+			"cr_OwM7B3WWha5SIfjNSw2eUqVb,2014-11-12T16:41:51Z,4.99,EUR," + tt.wantColumn + ",\n"
+		if got := buf.String(); got != want {
+			t.Errorf("#%d: got:\n%s\nwant:\n%s", i, got, want)
+		}
+	}
+}
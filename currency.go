@@ -0,0 +1,69 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay
+
+import (
+	"fmt"
+	"strings"
+)
+
+// zeroDecimalCurrencies have no minor unit, for example 10¥ is 10.
+var zeroDecimalCurrencies = map[Currency]bool{
+	"BIF": true, "CLP": true, "DJF": true, "GNF": true, "ISK": true,
+	JPY: true, "KMF": true, "KRW": true, "PYG": true, "RWF": true,
+	"UGX": true, "VND": true, "VUV": true, "XAF": true, "XOF": true,
+	"XPF": true,
+}
+
+// threeDecimalCurrencies have a thousandth as their minor unit.
+var threeDecimalCurrencies = map[Currency]bool{
+	"BHD": true, "IQD": true, "JOD": true, "KWD": true, "LYD": true,
+	"OMR": true, "TND": true,
+}
+
+// DecimalPlaces returns the number of decimal places of the currency's
+// minor unit, which amounts in minor currency units are scaled by, for
+// example 2 for EUR since 10€ is 1000 and 0 for JPY since 10¥ is 10.
+func (cur Currency) DecimalPlaces() int {
+	cur = Currency(strings.ToUpper(strings.TrimSpace(string(cur))))
+	switch {
+	case zeroDecimalCurrencies[cur]:
+		return 0
+	case threeDecimalCurrencies[cur]:
+		return 3
+	default:
+		return 2
+	}
+}
+
+// FormatAmount formats amount, in minor units of cur, in major units
+// with the currency's decimal places e.g 1999 EUR as "19.99" and 1000
+// JPY as "1000". The currency code isn't included.
+func FormatAmount(amount int, cur Currency) string {
+	places := cur.DecimalPlaces()
+	if places == 0 {
+		return fmt.Sprintf("%d", amount)
+	}
+
+	sign := ""
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	scale := 1
+	for i := 0; i < places; i++ {
+		scale *= 10
+	}
+	return fmt.Sprintf("%s%d.%0*d", sign, amount/scale, places, amount%scale)
+}
@@ -0,0 +1,220 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const customersEndpointURL = "https://api.securionpay.com/customers"
+
+// GET https://api.securionpay.com/customers/{CUSTOMER_ID}
+func (c *Client) FindCustomerByID(customerID string) (*Customer, error) {
+	customerID = strings.TrimSpace(customerID)
+	if customerID == "" {
+		return nil, errInvalidCustomerID
+	}
+
+	fullURL := fmt.Sprintf("%s/%s", customersEndpointURL, customerID)
+	req, err := http.NewRequest("GET", fullURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	blob, err := c.doAuthThenReqAndSlurpResponse(req)
+	if err != nil {
+		return nil, err
+	}
+
+	customer := new(Customer)
+	if err := c.decodeResponse(blob, customer); err != nil {
+		return nil, err
+	}
+	return customer, nil
+}
+
+type Cards struct {
+	Cards []*Card `json:"list"`
+
+	HasMore    bool `json:"hasMore"`
+	TotalCount int  `json:"totalCount,omitempty"`
+}
+
+const cardsPageLimit = 100
+
+// ListCards retrieves all the cards saved for a customer.
+//
+// GET https://api.securionpay.com/customers/{CUSTOMER_ID}/cards
+func (c *Client) ListCards(customerID string) ([]*Card, error) {
+	customerID = strings.TrimSpace(customerID)
+	if customerID == "" {
+		return nil, errInvalidCustomerID
+	}
+
+	ctx := c.reqCtx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var allCards []*Card
+	startingAfterID := ""
+	for {
+		qv := url.Values{"limit": {fmt.Sprintf("%d", cardsPageLimit)}}
+		if startingAfterID != "" {
+			qv.Set("startingAfterId", startingAfterID)
+		}
+		fullURL := buildURL("/customers/"+url.PathEscape(customerID)+"/cards", qv)
+		req, err := http.NewRequest("GET", fullURL, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var blob []byte
+		err = c.resumePage(ctx, func(ctx context.Context) (err error) {
+			blob, err = c.doAuthThenReqAndSlurpResponse(req.WithContext(ctx))
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		page := new(Cards)
+		if err := c.decodeResponse(blob, page); err != nil {
+			return nil, err
+		}
+		allCards = append(allCards, page.Cards...)
+
+		if !page.HasMore || len(page.Cards) == 0 {
+			return allCards, nil
+		}
+		startingAfterID = page.Cards[len(page.Cards)-1].ID
+	}
+}
+
+// ListCardsWithDefault retrieves all the cards saved for a customer
+// as well as the ID of the customer's default card. The default card,
+// if present amongst the results, has IsDefault set.
+func (c *Client) ListCardsWithDefault(customerID string) ([]*Card, string, error) {
+	customer, err := c.FindCustomerByID(customerID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	cards, err := c.ListCards(customerID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	for _, card := range cards {
+		card.IsDefault = card.ID != "" && card.ID == customer.DefaultCardID
+	}
+	return cards, customer.DefaultCardID, nil
+}
+
+// GET https://api.securionpay.com/customers/{CUSTOMER_ID}/cards/{CARD_ID}
+func (c *Client) FindCard(customerID, cardID string) (*Card, error) {
+	fullURL, err := cardURL(customerID, cardID)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", fullURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	blob, err := c.doAuthThenReqAndSlurpResponse(req)
+	if err != nil {
+		return nil, err
+	}
+
+	card := new(Card)
+	if err := c.decodeResponse(blob, card); err != nil {
+		return nil, err
+	}
+	return card, nil
+}
+
+// DeleteCard removes a card saved for a customer.
+//
+// DELETE https://api.securionpay.com/customers/{CUSTOMER_ID}/cards/{CARD_ID}
+func (c *Client) DeleteCard(customerID, cardID string) error {
+	fullURL, err := cardURL(customerID, cardID)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("DELETE", fullURL, nil)
+	if err != nil {
+		return err
+	}
+	_, err = c.doAuthThenReqAndSlurpResponse(req)
+	return err
+}
+
+// DeleteCardKeepingFingerprint deletes a card saved for a customer and
+// returns the card's fingerprint, which is looked up before deleting,
+// so that the card can still be recognized e.g for fraud analytics.
+// The card isn't deleted if its fingerprint couldn't be retrieved.
+func (c *Client) DeleteCardKeepingFingerprint(customerID, cardID string) (string, error) {
+	card, err := c.FindCard(customerID, cardID)
+	if err != nil {
+		return "", err
+	}
+	if err := c.DeleteCard(customerID, cardID); err != nil {
+		return "", err
+	}
+	return card.FingerPrint, nil
+}
+
+// setDefaultCard makes cardID the customer's default card.
+//
+// POST https://api.securionpay.com/customers/{CUSTOMER_ID}
+func (c *Client) setDefaultCard(customerID, cardID string) error {
+	cardID = strings.TrimSpace(cardID)
+	if cardID == "" {
+		return errUnsetCardID
+	}
+
+	blob, err := json.Marshal(map[string]string{"defaultCardId": cardID})
+	if err != nil {
+		return err
+	}
+	fullURL := fmt.Sprintf("%s/%s", customersEndpointURL, customerID)
+	req, err := http.NewRequest("POST", fullURL, bytes.NewReader(blob))
+	if err != nil {
+		return err
+	}
+	_, err = c.doAuthThenReqAndSlurpResponse(req)
+	return err
+}
+
+func cardURL(customerID, cardID string) (string, error) {
+	customerID = strings.TrimSpace(customerID)
+	if customerID == "" {
+		return "", errInvalidCustomerID
+	}
+	cardID = strings.TrimSpace(cardID)
+	if cardID == "" {
+		return "", errUnsetCardID
+	}
+	return fmt.Sprintf("%s/%s/cards/%s", customersEndpointURL, customerID, cardID), nil
+}
@@ -0,0 +1,217 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/orijtech/securionpay"
+)
+
+func TestListCardsWithDefault(t *testing.T) {
+	tests := [...]struct {
+		customerID string
+		wantErr    bool
+
+		wantDefaultCardID string
+		wantCardIDs       []string
+	}{
+		0: {customerID: "", wantErr: true},
+		1: {customerID: "   ", wantErr: true},
+		2: {customerID: "unknownCustomer", wantErr: true},
+		3: {
+			customerID: customerID1,
+
+			wantDefaultCardID: "card_2",
+			wantCardIDs:       []string{"card_1", "card_2", "card_3"},
+		},
+	}
+
+	cRTripper := &customRoundTripper{route: customersRoute}
+	client := newClientWithRoundTripper(t, cRTripper)
+
+	for i, tt := range tests {
+		cards, defaultCardID, err := client.ListCardsWithDefault(tt.customerID)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("#%d: want non-nil error", i)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("#%d gotErr=%q", i, err)
+			continue
+		}
+
+		if defaultCardID != tt.wantDefaultCardID {
+			t.Errorf("#%d: gotDefaultCardID=%q want=%q", i, defaultCardID, tt.wantDefaultCardID)
+		}
+		if len(cards) != len(tt.wantCardIDs) {
+			t.Errorf("#%d: gotCards=%d wantCards=%d", i, len(cards), len(tt.wantCardIDs))
+			continue
+		}
+		for j, card := range cards {
+			if card.ID != tt.wantCardIDs[j] {
+				t.Errorf("#%d: card #%d gotID=%q wantID=%q", i, j, card.ID, tt.wantCardIDs[j])
+			}
+			if wantDefault := card.ID == tt.wantDefaultCardID; card.IsDefault != wantDefault {
+				t.Errorf("#%d: card #%d gotIsDefault=%v want=%v", i, j, card.IsDefault, wantDefault)
+			}
+		}
+	}
+}
+
+const testCardsPerPage = 2
+
+var testCustomerCardIDs = []string{"card_1", "card_2", "card_3"}
+
+func (ct *customRoundTripper) customersRoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != "GET" {
+		return makeResp("only GET allowed", http.StatusMethodNotAllowed), nil
+	}
+
+	// Paths are either:
+	//  /customers/{CUSTOMER_ID}
+	//  /customers/{CUSTOMER_ID}/cards
+	splits := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	if len(splits) < 2 || !knownCustomerID(splits[1]) {
+		return invalidCustomerID, nil
+	}
+
+	if len(splits) == 2 {
+		f, err := os.Open("testdata/customer1.json")
+		if err != nil {
+			return makeResp(err.Error(), http.StatusInternalServerError), nil
+		}
+		prc, pwc := io.Pipe()
+		okResp := makeResp("200 OK", http.StatusOK)
+		okResp.Body = prc
+		go func() {
+			defer f.Close()
+			defer pwc.Close()
+			io.Copy(pwc, f)
+		}()
+		return okResp, nil
+	}
+
+	start := 0
+	if after := req.URL.Query().Get("startingAfterId"); after != "" {
+		if _, err := fmt.Sscanf(after, "card_%d", &start); err != nil {
+			return makeResp(err.Error(), http.StatusBadRequest), nil
+		}
+	}
+
+	page := new(securionpay.Cards)
+	for i := start; i < len(testCustomerCardIDs) && len(page.Cards) < testCardsPerPage; i++ {
+		page.Cards = append(page.Cards, &securionpay.Card{ID: testCustomerCardIDs[i], CustomerID: customerID1})
+	}
+	page.HasMore = start+len(page.Cards) < len(testCustomerCardIDs)
+
+	blob, err := json.Marshal(page)
+	if err != nil {
+		return nil, err
+	}
+
+	prc, pwc := io.Pipe()
+	okResp := makeResp("200 OK", http.StatusOK)
+	okResp.Body = prc
+	go func() {
+		defer pwc.Close()
+		pwc.Write(blob)
+	}()
+
+	return okResp, nil
+}
+
+func TestDeleteCardKeepingFingerprint(t *testing.T) {
+	client := newTestClient(t)
+
+	tests := [...]struct {
+		customerID, cardID string
+		statusCodes        []int
+
+		wantErr         bool
+		wantReqs        int
+		wantFingerprint string
+	}{
+		0: {customerID: "", cardID: "card_1", wantErr: true},
+		1: {customerID: customerID1, cardID: "  ", wantErr: true},
+		2: {
+			// The card mustn't be deleted if its fingerprint can't be kept.
+			customerID:  customerID1,
+			cardID:      "card_1",
+			statusCodes: []int{http.StatusNotFound},
+			wantErr:     true,
+			wantReqs:    1,
+		},
+		3: {
+			customerID:  customerID1,
+			cardID:      "card_1",
+			statusCodes: []int{http.StatusOK, http.StatusBadRequest},
+			wantErr:     true,
+			wantReqs:    2,
+		},
+		4: {
+			customerID:      customerID1,
+			cardID:          " card_1 ",
+			wantReqs:        2,
+			wantFingerprint: "e3d8suyIDgFg3pE7",
+		},
+	}
+
+	for i, tt := range tests {
+		crt := &cannedRoundTripper{
+			statusCodes: tt.statusCodes,
+			bodies: []string{
+				`{"id":"card_1","objectType":"card","last4":"4242","fingerprint":"e3d8suyIDgFg3pE7","customerId":"` + customerID1 + `"}`,
+				`{"id":"card_1","deleted":true}`,
+			},
+		}
+		client.SetHTTPRoundTripper(crt)
+
+		fingerprint, err := client.DeleteCardKeepingFingerprint(tt.customerID, tt.cardID)
+		reqs := crt.requests()
+		if len(reqs) != tt.wantReqs {
+			t.Errorf("#%d: got %d requests, want %d", i, len(reqs), tt.wantReqs)
+		}
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("#%d: expected an error", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: err: %v", i, err)
+			continue
+		}
+		if fingerprint != tt.wantFingerprint {
+			t.Errorf("#%d: gotFingerprint=%q want=%q", i, fingerprint, tt.wantFingerprint)
+		}
+
+		wantPath := "/customers/" + customerID1 + "/cards/card_1"
+		for j, method := range []string{"GET", "DELETE"} {
+			if reqs[j].Method != method || reqs[j].URL.Path != wantPath {
+				t.Errorf("#%d: req #%d: got %s %s want %s %s", i, j, reqs[j].Method, reqs[j].URL.Path, method, wantPath)
+			}
+		}
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// ErrCardDeclined is returned when SecurionPay declines a charge with a
+// 402 Payment Required response that describes the declined charge.
+// Charge is that declined charge, whose FailureCode and FailureMessage
+// tell why it was declined. Client.Charge also returns it alongside.
+type ErrCardDeclined struct {
+	Charge *ChargeResponse
+}
+
+func (e *ErrCardDeclined) Error() string {
+	if e.Charge == nil {
+		return "securionpay: card declined"
+	}
+	return fmt.Sprintf("securionpay: card declined for charge %q: %s: %s", e.Charge.ID, e.Charge.FailureCode, RedactPAN(e.Charge.FailureMessage))
+}
+
+// declinedChargeError returns an *ErrCardDeclined if the response is a
+// 402 whose body is a charge, otherwise it returns nil.
+func declinedChargeError(res *http.Response, slurp []byte) error {
+	if res.StatusCode != http.StatusPaymentRequired {
+		return nil
+	}
+	cr := new(ChargeResponse)
+	if err := json.Unmarshal(slurp, cr); err != nil || cr.ID == "" {
+		return nil
+	}
+	return &ErrCardDeclined{Charge: cr}
+}
@@ -0,0 +1,80 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/orijtech/securionpay"
+)
+
+func TestChargeDeclinedWithPaymentRequired(t *testing.T) {
+	client := newTestClient(t)
+
+	declinedBody, err := ioutil.ReadFile("testdata/chargeRespDeclined.json")
+	if err != nil {
+		t.Fatalf("reading declined charge fixture: %v", err)
+	}
+
+	tests := [...]struct {
+		statusCode int
+		body       string
+
+		wantDeclined bool
+		wantAPIError bool
+	}{
+		0: {statusCode: http.StatusPaymentRequired, body: string(declinedBody), wantDeclined: true},
+
+		// Bodies that aren't charges are left to the other errors.
+		1: {statusCode: http.StatusPaymentRequired, body: `{"error":{"type":"card_error","code":"card_declined","message":"The card was declined."}}`, wantAPIError: true},
+		2: {statusCode: http.StatusPaymentRequired, body: "Payment Required"},
+		3: {statusCode: http.StatusBadRequest, body: string(declinedBody)},
+	}
+
+	for i, tt := range tests {
+		client.SetHTTPRoundTripper(&cannedRoundTripper{statusCode: tt.statusCode, body: tt.body})
+
+		cResp, err := client.Charge(&securionpay.Charge{Card: tokenID1, Currency: securionpay.Euros, AmountMinorCurrencyUnits: 1999})
+		if err == nil {
+			t.Errorf("#%d: expected a non-nil error", i)
+			continue
+		}
+
+		declined, ok := err.(*securionpay.ErrCardDeclined)
+		if ok != tt.wantDeclined {
+			t.Errorf("#%d: got err=%#v, wantDeclined=%v", i, err, tt.wantDeclined)
+			continue
+		}
+		if !ok {
+			if cResp != nil {
+				t.Errorf("#%d: got charge=%#v, want nil", i, cResp)
+			}
+			if _, isAPIError := err.(*securionpay.APIError); isAPIError != tt.wantAPIError {
+				t.Errorf("#%d: got err=%#v, wantAPIError=%v", i, err, tt.wantAPIError)
+			}
+			continue
+		}
+
+		if cResp == nil || cResp != declined.Charge {
+			t.Errorf("#%d: expected the declined charge to be returned alongside the error", i)
+			continue
+		}
+		if cResp.ID != "char_Zq8fT3mPlkD7vbN2xRcW4yAs" || cResp.FailureCode != "card_declined" || cResp.Succeeded() {
+			t.Errorf("#%d: got charge=%#v", i, cResp)
+		}
+	}
+}
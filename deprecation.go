@@ -0,0 +1,86 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// SetDeprecationHandler sets fn to be invoked with a description of each
+// response with a "Deprecation", "Sunset" or 299 "Warning" header.
+// A nil fn, the default, ignores them.
+func (c *Client) SetDeprecationHandler(fn func(msg string)) {
+	c.Lock()
+	c.deprecationHandler = fn
+	c.Unlock()
+}
+
+func (c *Client) _deprecationHandler() func(string) {
+	c.RLock()
+	defer c.RUnlock()
+
+	return c.deprecationHandler
+}
+
+// warnIfDeprecated invokes the deprecation handler, if one was set,
+// should the response to the operation op be marked as deprecated.
+func (c *Client) warnIfDeprecated(op string, res *http.Response) {
+	fn := c._deprecationHandler()
+	if fn == nil || res == nil {
+		return
+	}
+	if msg := deprecationMessage(op, res.Header); msg != "" {
+		fn(msg)
+	}
+}
+
+// deprecationMessage describes the deprecation of op that the
+// headers announce or returns the empty string if there is none.
+func deprecationMessage(op string, header http.Header) string {
+	deprecation := strings.TrimSpace(header.Get("Deprecation"))
+	sunset := strings.TrimSpace(header.Get("Sunset"))
+	var warnings []string
+	for _, warning := range header.Values("Warning") {
+		// The warn-text follows the "299 <agent>" prefix.
+		parts := strings.SplitN(strings.TrimSpace(warning), " ", 3)
+		if parts[0] != "299" {
+			continue
+		}
+		text := ""
+		if len(parts) == 3 {
+			text = strings.Trim(parts[2], `"`)
+		}
+		warnings = append(warnings, text)
+	}
+	if deprecation == "" && sunset == "" && len(warnings) == 0 {
+		return ""
+	}
+
+	msg := fmt.Sprintf("securionpay: %s is deprecated", op)
+	if deprecation != "" && deprecation != "true" {
+		msg += fmt.Sprintf(" as of %s", deprecation)
+	}
+	if sunset != "" {
+		msg += fmt.Sprintf(" and will be removed at %s", sunset)
+	}
+	for _, warning := range warnings {
+		if warning != "" {
+			msg += ": " + warning
+		}
+	}
+	return msg
+}
@@ -0,0 +1,85 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay_test
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestDeprecationHandler(t *testing.T) {
+	tests := [...]struct {
+		header     http.Header
+		statusCode int
+		want       []string
+	}{
+		0: {header: http.Header{}},
+		1: {
+			header: http.Header{"Deprecation": {"true"}},
+			want:   []string{"securionpay: GET /tokens/{id} is deprecated"},
+		},
+		2: {
+			header: http.Header{
+				"Deprecation": {"@1688169599"},
+				"Sunset":      {"Wed, 31 Dec 2025 23:59:59 GMT"},
+			},
+			want: []string{"securionpay: GET /tokens/{id} is deprecated as of @1688169599 and will be removed at Wed, 31 Dec 2025 23:59:59 GMT"},
+		},
+		3: {
+			header: http.Header{"Warning": {`299 api.securionpay.com "This endpoint will be removed"`}},
+			want:   []string{"securionpay: GET /tokens/{id} is deprecated: This endpoint will be removed"},
+		},
+		4: {
+			// Other warnings aren't about deprecation.
+			header: http.Header{"Warning": {`199 api.securionpay.com "Miscellaneous warning"`}},
+		},
+		5: {
+			// Failed requests are reported too.
+			header:     http.Header{"Deprecation": {"true"}},
+			statusCode: http.StatusNotFound,
+			want:       []string{"securionpay: GET /tokens/{id} is deprecated"},
+		},
+	}
+
+	for i, tt := range tests {
+		client := newTestClient(t)
+		client.SetHTTPRoundTripper(&cannedRoundTripper{
+			statusCode: tt.statusCode,
+			header:     tt.header,
+			body:       `{"id":"` + tokenID1 + `"}`,
+		})
+
+		var got []string
+		client.SetDeprecationHandler(func(msg string) { got = append(got, msg) })
+		client.FindTokenByID(tokenID1)
+
+		if len(got) != len(tt.want) {
+			t.Errorf("#%d: got %d warnings %q, want %q", i, len(got), got, tt.want)
+			continue
+		}
+		for j := range got {
+			if got[j] != tt.want[j] {
+				t.Errorf("#%d: warning #%d\ngot:  %q\nwant: %q", i, j, got[j], tt.want[j])
+			}
+		}
+	}
+
+	// Without a handler, deprecations are ignored.
+	client := newTestClient(t)
+	client.SetHTTPRoundTripper(&cannedRoundTripper{header: http.Header{"Deprecation": {"true"}}, body: `{"id":"` + tokenID1 + `"}`})
+	if _, err := client.FindTokenByID(tokenID1); err != nil {
+		t.Errorf("err: %v", err)
+	}
+}
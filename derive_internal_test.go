@@ -0,0 +1,105 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+	"unsafe"
+)
+
+type nopMetricsSink struct{}
+
+func (nopMetricsSink) ObserveRequest(op string, duration time.Duration, statusCode int) {}
+
+// TestDeriveCopiesEveryField guards against settings that are added to
+// Client but that derive forgets to copy over.
+func TestDeriveCopiesEveryField(t *testing.T) {
+	// notDerived are the fields that derive deliberately doesn't copy.
+	notDerived := map[string]bool{
+		"RWMutex": true,
+	}
+	// interfaceValues are the values that fields of interface types are set to.
+	interfaceValues := map[reflect.Type]interface{}{
+		reflect.TypeOf((*http.RoundTripper)(nil)).Elem(): new(http.Transport),
+		reflect.TypeOf((*context.Context)(nil)).Elem():   context.WithValue(context.Background(), apiKeyKey{}, "k"),
+		reflect.TypeOf((*MetricsSink)(nil)).Elem():       nopMetricsSink{},
+	}
+
+	c := new(Client)
+	rv := reflect.ValueOf(c).Elem()
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if notDerived[field.Name] {
+			continue
+		}
+		// Unexported fields can only be set through their address.
+		fv := reflect.NewAt(field.Type, unsafe.Pointer(rv.Field(i).UnsafeAddr())).Elem()
+		switch kind := field.Type.Kind(); kind {
+		case reflect.String:
+			fv.SetString(field.Name)
+		case reflect.Bool:
+			fv.SetBool(true)
+		case reflect.Int, reflect.Int64:
+			fv.SetInt(int64(i + 1))
+		case reflect.Ptr:
+			fv.Set(reflect.New(field.Type.Elem()))
+		case reflect.Slice:
+			fv.Set(reflect.MakeSlice(field.Type, 1, 1))
+		case reflect.Func:
+			fv.Set(reflect.MakeFunc(field.Type, func(args []reflect.Value) []reflect.Value {
+				results := make([]reflect.Value, field.Type.NumOut())
+				for j := range results {
+					results[j] = reflect.Zero(field.Type.Out(j))
+				}
+				return results
+			}))
+		case reflect.Interface:
+			value, ok := interfaceValues[field.Type]
+			if !ok {
+				t.Fatalf("%s: add a value of %s to interfaceValues", field.Name, field.Type)
+			}
+			fv.Set(reflect.ValueOf(value))
+		default:
+			t.Fatalf("%s: can't set a field of kind %s, add it to this test", field.Name, kind)
+		}
+	}
+
+	dv := reflect.ValueOf(c.derive()).Elem()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if notDerived[field.Name] {
+			continue
+		}
+		want := reflect.NewAt(field.Type, unsafe.Pointer(rv.Field(i).UnsafeAddr())).Elem()
+		got := reflect.NewAt(field.Type, unsafe.Pointer(dv.Field(i).UnsafeAddr())).Elem()
+
+		var same bool
+		switch field.Type.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Func:
+			// Shared state must be the same, not a copy.
+			same = got.Pointer() == want.Pointer()
+		default:
+			same = reflect.DeepEqual(got.Interface(), want.Interface())
+		}
+		if !same {
+			t.Errorf("%s: derive didn't copy it over", field.Name)
+		}
+	}
+}
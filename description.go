@@ -0,0 +1,67 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// SetDescriptionTemplate sets the template, executed with the charge's
+// metadata e.g "Order {{.OrderID}}", that describes charges made without
+// a description. A blank tmpl, the default, disables it.
+func (c *Client) SetDescriptionTemplate(tmpl string) error {
+	var descriptionTmpl *template.Template
+	if strings.TrimSpace(tmpl) != "" {
+		var err error
+		descriptionTmpl, err = template.New("description").Option("missingkey=error").Parse(tmpl)
+		if err != nil {
+			return err
+		}
+	}
+
+	c.Lock()
+	c.descriptionTmpl = descriptionTmpl
+	c.Unlock()
+	return nil
+}
+
+// withTemplatedDescription returns creq as is if it has a description or
+// if no description template is set, otherwise it returns a copy of creq
+// with the description generated by the template.
+func (c *Client) withTemplatedDescription(creq *Charge) (*Charge, error) {
+	c.RLock()
+	tmpl := c.descriptionTmpl
+	c.RUnlock()
+
+	if tmpl == nil || strings.TrimSpace(creq.Description) != "" {
+		return creq, nil
+	}
+
+	metadata := creq.Metadata
+	if metadata == nil {
+		metadata = make(map[string]interface{})
+	}
+	buf := new(bytes.Buffer)
+	if err := tmpl.Execute(buf, metadata); err != nil {
+		return nil, fmt.Errorf("generating the charge description: %v", err)
+	}
+
+	templated := *creq
+	templated.Description = strings.TrimSpace(buf.String())
+	return &templated, nil
+}
@@ -0,0 +1,111 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// DisputeEvidence is the evidence submitted to contest a dispute.
+// Fields ending in "File" hold the IDs of uploaded files.
+type DisputeEvidence struct {
+	ProductDescription string `json:"productDescription,omitempty"`
+
+	CustomerName       string `json:"customerName,omitempty"`
+	CustomerEmail      string `json:"customerEmail,omitempty"`
+	CustomerPurchaseIP string `json:"customerPurchaseIp,omitempty"`
+
+	CustomerSignatureFile     string `json:"customerSignature,omitempty"`
+	CustomerCommunicationFile string `json:"customerCommunication,omitempty"`
+	ReceiptFile               string `json:"receipt,omitempty"`
+	BillingAddress            string `json:"billingAddress,omitempty"`
+
+	ServiceDate          string `json:"serviceDate,omitempty"`
+	ServiceDocumentation string `json:"serviceDocumentation,omitempty"`
+
+	ShippingAddress        string `json:"shippingAddress,omitempty"`
+	ShippingCarrier        string `json:"shippingCarrier,omitempty"`
+	ShippingDate           string `json:"shippingDate,omitempty"`
+	ShippingTrackingNumber string `json:"shippingTrackingNumber,omitempty"`
+	ShippingDocumentation  string `json:"shippingDocumentation,omitempty"`
+
+	RefundPolicyFile         string `json:"refundPolicy,omitempty"`
+	RefundPolicyDisclosure   string `json:"refundPolicyDisclosure,omitempty"`
+	RefundRefusalExplanation string `json:"refundRefusalExplanation,omitempty"`
+
+	CancellationPolicyFile       string `json:"cancellationPolicy,omitempty"`
+	CancellationPolicyDisclosure string `json:"cancellationPolicyDisclosure,omitempty"`
+	CancellationRebuttal         string `json:"cancellationRebuttal,omitempty"`
+	DuplicateChargeDocumentation string `json:"duplicateChargeDocumentation,omitempty"`
+	DuplicateChargeExplanation   string `json:"duplicateChargeExplanation,omitempty"`
+	DuplicateChargeID            string `json:"duplicateChargeId,omitempty"`
+	UncategorizedText            string `json:"uncategorizedText,omitempty"`
+	UncategorizedFile            string `json:"uncategorizedFile,omitempty"`
+}
+
+// IsEmpty reports whether no evidence has been submitted.
+func (de *DisputeEvidence) IsEmpty() bool {
+	return de == nil || *de == DisputeEvidence{}
+}
+
+const disputesEndpointURL = "https://api.securionpay.com/disputes"
+
+var errBlankDisputeID = errors.New("expecting a non-blank dispute ID")
+
+// FindDisputeByID retrieves a dispute
+// including the evidence submitted so far.
+//
+// GET https://api.securionpay.com/disputes/{DISPUTE_ID}
+func (c *Client) FindDisputeByID(disputeID string) (*Dispute, error) {
+	disputeID = strings.TrimSpace(disputeID)
+	if disputeID == "" {
+		return nil, errBlankDisputeID
+	}
+
+	fullURL := fmt.Sprintf("%s/%s", disputesEndpointURL, disputeID)
+	req, err := http.NewRequest("GET", fullURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	blob, err := c.doAuthThenReqAndSlurpResponse(req)
+	if err != nil {
+		return nil, err
+	}
+
+	dispute := new(Dispute)
+	if err := c.decodeResponse(blob, dispute); err != nil {
+		return nil, err
+	}
+	return dispute, nil
+}
+
+// GetDisputeEvidence retrieves the evidence submitted so far
+// for a dispute, which is empty but not nil if there is none.
+//
+// GET https://api.securionpay.com/disputes/{DISPUTE_ID}
+func (c *Client) GetDisputeEvidence(disputeID string) (*DisputeEvidence, error) {
+	dispute, err := c.FindDisputeByID(disputeID)
+	if err != nil {
+		return nil, err
+	}
+	if dispute.Evidence == nil {
+		return new(DisputeEvidence), nil
+	}
+	return dispute.Evidence, nil
+}
@@ -0,0 +1,126 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/orijtech/securionpay"
+)
+
+func TestFindDisputeByID(t *testing.T) {
+	disputeBody, err := ioutil.ReadFile("testdata/dispute1.json")
+	if err != nil {
+		t.Fatalf("reading dispute fixture: %v", err)
+	}
+	crt := &cannedRoundTripper{body: string(disputeBody)}
+	client := newClientWithRoundTripper(t, crt)
+
+	if _, err := client.FindDisputeByID("  "); err == nil {
+		t.Errorf("expected an error for a blank dispute ID")
+	}
+
+	dispute, err := client.FindDisputeByID("dis_8P7OWXA5xiTS1ISnyZcum1KV")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	reqs := crt.requests()
+	if len(reqs) != 1 {
+		t.Fatalf("got %d requests, want 1", len(reqs))
+	}
+	if got, want := reqs[0].URL.Path, "/disputes/dis_8P7OWXA5xiTS1ISnyZcum1KV"; got != want {
+		t.Errorf("gotPath=%q want=%q", got, want)
+	}
+
+	if dispute.Status != securionpay.DisputeChargebackResponseNeeded || dispute.Reason != securionpay.ReasonProductNotReceived {
+		t.Errorf("got status=%q reason=%q", dispute.Status, dispute.Reason)
+	}
+	if dispute.Evidence.IsEmpty() {
+		t.Fatalf("expected the submitted evidence")
+	}
+	if got, want := dispute.Evidence.ShippingTrackingNumber, "JD014600003828123456"; got != want {
+		t.Errorf("gotTrackingNumber=%q want=%q", got, want)
+	}
+	if got, want := dispute.Evidence.CustomerEmail, "john.doe@example.com"; got != want {
+		t.Errorf("gotCustomerEmail=%q want=%q", got, want)
+	}
+
+	// Disputes without evidence, as embedded in charges, have none.
+	cr := new(securionpay.ChargeResponse)
+	if err := retrFromFile("testdata/chargeRespDisputed.json", cr); err != nil {
+		t.Fatalf("loading disputed charge: %v", err)
+	}
+	if active := cr.ActiveDispute(); active == nil || !active.Evidence.IsEmpty() {
+		t.Errorf("got active dispute %#v, want one without evidence", active)
+	}
+}
+
+func TestGetDisputeEvidence(t *testing.T) {
+	client := newTestClient(t)
+
+	disputeBody, err := ioutil.ReadFile("testdata/dispute1.json")
+	if err != nil {
+		t.Fatalf("reading dispute fixture: %v", err)
+	}
+
+	tests := [...]struct {
+		disputeID  string
+		body       string
+		statusCode int
+
+		wantErr            bool
+		wantEmpty          bool
+		wantTrackingNumber string
+	}{
+		0: {disputeID: " ", wantErr: true},
+		1: {disputeID: "dis_1", body: `{"error":{"type":"invalid_request","message":"Dispute not found"}}`, statusCode: http.StatusNotFound, wantErr: true},
+		2: {disputeID: "dis_8P7OWXA5xiTS1ISnyZcum1KV", body: string(disputeBody), wantTrackingNumber: "JD014600003828123456"},
+		3: {disputeID: "dis_2", body: `{"id":"dis_2","objectType":"dispute","status":"CHARGEBACK_NEW"}`, wantEmpty: true},
+	}
+
+	for i, tt := range tests {
+		crt := &cannedRoundTripper{statusCode: tt.statusCode, body: tt.body}
+		client.SetHTTPRoundTripper(crt)
+
+		evidence, err := client.GetDisputeEvidence(tt.disputeID)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("#%d: expected an error", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: err: %v", i, err)
+			continue
+		}
+
+		if got, want := crt.requests()[0].URL.Path, "/disputes/"+tt.disputeID; got != want {
+			t.Errorf("#%d: gotPath=%q want=%q", i, got, want)
+		}
+		if evidence == nil {
+			t.Errorf("#%d: expected non-nil evidence", i)
+			continue
+		}
+		if got := evidence.IsEmpty(); got != tt.wantEmpty {
+			t.Errorf("#%d: gotEmpty=%v want=%v", i, got, tt.wantEmpty)
+		}
+		if evidence.ShippingTrackingNumber != tt.wantTrackingNumber {
+			t.Errorf("#%d: gotTrackingNumber=%q want=%q", i, evidence.ShippingTrackingNumber, tt.wantTrackingNumber)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// duplicateChargeCode is the error code that SecurionPay responds with
+// when a charge is rejected for duplicating an earlier charge.
+const duplicateChargeCode = "duplicate_charge"
+
+// ErrDuplicateCharge is returned when SecurionPay rejects a charge
+// because it duplicates one that was already made, for example one
+// sharing the same unique metadata key. ChargeID is the ID of that
+// original charge, which FindOriginalCharge retrieves.
+type ErrDuplicateCharge struct {
+	Message  string
+	ChargeID string
+}
+
+func (e *ErrDuplicateCharge) Error() string {
+	return fmt.Sprintf("securionpay: duplicate of charge %q: %s", e.ChargeID, e.Message)
+}
+
+// duplicateChargeError returns an *ErrDuplicateCharge if apiErr
+// reports a duplicate charge, otherwise it returns nil.
+func duplicateChargeError(apiErr *APIError) error {
+	if apiErr.StatusCode != http.StatusBadRequest && apiErr.StatusCode != http.StatusConflict {
+		return nil
+	}
+	if apiErr.Code != duplicateChargeCode {
+		return nil
+	}
+	return &ErrDuplicateCharge{Message: apiErr.Message, ChargeID: apiErr.ChargeID}
+}
+
+var errNotDuplicateCharge = errors.New("expecting an *ErrDuplicateCharge with the original charge's ID")
+
+// FindOriginalCharge retrieves the charge that err, as returned when
+// creating a charge, reports was duplicated. It lets retries tell that
+// the customer was already charged and carry on with that charge.
+func (c *Client) FindOriginalCharge(err error) (*ChargeResponse, error) {
+	dup, ok := err.(*ErrDuplicateCharge)
+	if !ok || dup.ChargeID == "" {
+		return nil, errNotDuplicateCharge
+	}
+	return c.FindChargeByID(dup.ChargeID)
+}
+
+// ErrPossibleDuplicate is returned, before any request is made, when a
+// charge of a customer for the same amount, currency and description as
+// one submitted within the duplicate charge window is made, e.g because
+// a webhook fired twice. See SetDuplicateChargeWindow.
+var ErrPossibleDuplicate = errors.New("securionpay: an identical charge of the customer was submitted recently")
+
+// recentCharges remembers when charges were submitted, keyed by
+// their customer, amount, currency and description.
+type recentCharges struct {
+	sync.Mutex
+
+	window      time.Duration
+	submittedAt map[string]time.Time
+}
+
+// claim records the charge as submitted at now and reports false
+// if an identical one was already submitted within the window.
+func (rc *recentCharges) claim(key string, now time.Time) bool {
+	rc.Lock()
+	defer rc.Unlock()
+
+	for k, at := range rc.submittedAt {
+		if now.Sub(at) >= rc.window {
+			delete(rc.submittedAt, k)
+		}
+	}
+	if _, ok := rc.submittedAt[key]; ok {
+		return false
+	}
+	rc.submittedAt[key] = now
+	return true
+}
+
+func (rc *recentCharges) release(key string, at time.Time) {
+	rc.Lock()
+	defer rc.Unlock()
+
+	if rc.submittedAt[key].Equal(at) {
+		delete(rc.submittedAt, key)
+	}
+}
+
+// SetDuplicateChargeWindow makes a charge fail with ErrPossibleDuplicate
+// if the same charge of its customer was submitted within window and not
+// rejected. A window that isn't positive, the default, disables the check.
+func (c *Client) SetDuplicateChargeWindow(window time.Duration) {
+	var rc *recentCharges
+	if window > 0 {
+		rc = &recentCharges{window: window, submittedAt: make(map[string]time.Time)}
+	}
+
+	c.Lock()
+	c.recentCharges = rc
+	c.Unlock()
+}
+
+// definitelyRejected reports whether err, as returned for a charge,
+// means that SecurionPay didn't make the charge. Errors such as timeouts,
+// dropped connections and server errors leave it unknown whether the
+// charge was made hence they aren't definite.
+func definitelyRejected(err error) bool {
+	switch err := err.(type) {
+	case *ErrCardDeclined, *ErrRateLimited, ValidationErrors:
+		return true
+	case *APIError:
+		return err.StatusCode >= 400 && err.StatusCode < 500
+	default:
+		return false
+	}
+}
+
+// claimRecentCharge records creq as submitted and returns a
+// function to forget it again should the charge fail.
+func (c *Client) claimRecentCharge(creq *Charge) (release func(), err error) {
+	c.RLock()
+	rc := c.recentCharges
+	c.RUnlock()
+
+	customerID := strings.TrimSpace(string(creq.CustomerID))
+	if rc == nil || customerID == "" {
+		return func() {}, nil
+	}
+
+	key := fmt.Sprintf("%s\x00%d\x00%s\x00%s", customerID, creq.AmountMinorCurrencyUnits, strings.ToUpper(string(creq.Currency)), strings.TrimSpace(creq.Description))
+	now := c.now()
+	if !rc.claim(key, now) {
+		return nil, ErrPossibleDuplicate
+	}
+	return func() { rc.release(key, now) }, nil
+}
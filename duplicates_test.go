@@ -0,0 +1,205 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/orijtech/securionpay"
+)
+
+func TestDuplicateCharge(t *testing.T) {
+	client := newTestClient(t)
+
+	duplicate := `{"error":{"type":"invalid_request","code":"duplicate_charge","message":"Charge already made","chargeId":"char_original"}}`
+	original := `{"id":"char_original","amount":1500,"currency":"USD","captured":true}`
+
+	tests := [...]struct {
+		statusCode int
+		body       string
+		wantDupID  string
+	}{
+		0: {statusCode: http.StatusBadRequest, body: duplicate, wantDupID: "char_original"},
+		1: {statusCode: http.StatusConflict, body: duplicate, wantDupID: "char_original"},
+
+		// Other errors aren't mistaken for duplicates.
+		2: {statusCode: http.StatusBadRequest, body: `{"error":{"type":"card_error","code":"card_declined","message":"declined"}}`},
+		3: {statusCode: http.StatusBadRequest, body: "not json"},
+		4: {statusCode: http.StatusInternalServerError, body: duplicate},
+	}
+
+	for i, tt := range tests {
+		crt := &cannedRoundTripper{
+			statusCodes: []int{tt.statusCode, http.StatusOK},
+			bodies:      []string{tt.body, original},
+		}
+		client.SetHTTPRoundTripper(crt)
+
+		_, err := client.Charge(&securionpay.Charge{Card: tokenID1, Currency: "USD", AmountMinorCurrencyUnits: 1500})
+		if err == nil {
+			t.Errorf("#%d: expected a non-nil error", i)
+			continue
+		}
+
+		dup, ok := err.(*securionpay.ErrDuplicateCharge)
+		if tt.wantDupID == "" {
+			if ok {
+				t.Errorf("#%d: got unexpected duplicate error %v", i, dup)
+			}
+			if _, err := client.FindOriginalCharge(err); err == nil {
+				t.Errorf("#%d: expected FindOriginalCharge to fail", i)
+			}
+			continue
+		}
+
+		if !ok {
+			t.Errorf("#%d: got err=%#v, want *ErrDuplicateCharge", i, err)
+			continue
+		}
+		if dup.ChargeID != tt.wantDupID {
+			t.Errorf("#%d: gotChargeID=%q want=%q", i, dup.ChargeID, tt.wantDupID)
+		}
+
+		cResp, err := client.FindOriginalCharge(err)
+		if err != nil {
+			t.Errorf("#%d: finding the original charge: %v", i, err)
+			continue
+		}
+		if cResp.ID != tt.wantDupID {
+			t.Errorf("#%d: got original charge %q want %q", i, cResp.ID, tt.wantDupID)
+		}
+		if got, want := crt.requests()[1].URL.Path, "/charges/"+tt.wantDupID; got != want {
+			t.Errorf("#%d: got path=%q want=%q", i, got, want)
+		}
+	}
+
+	if _, err := client.FindOriginalCharge(errors.New("boom")); err == nil {
+		t.Errorf("expected a non-duplicate error to be rejected")
+	}
+}
+
+// brokenConnRoundTripper fails every request as if the
+// connection broke before a response was read.
+type brokenConnRoundTripper struct{}
+
+func (brt *brokenConnRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return nil, io.ErrUnexpectedEOF
+}
+
+func TestDuplicateChargeWindow(t *testing.T) {
+	client := newTestClient(t)
+
+	now := time.Date(2017, time.March, 14, 15, 9, 26, 0, time.UTC)
+	client.SetClock(func() time.Time { return now })
+	client.SetDuplicateChargeWindow(time.Minute)
+
+	newCharge := func(customerID securionpay.CustomerID, amount int, description string) *securionpay.Charge {
+		return &securionpay.Charge{
+			CustomerID:  customerID,
+			Currency:    securionpay.USD,
+			Description: description,
+
+			AmountMinorCurrencyUnits: amount,
+		}
+	}
+
+	tests := [...]struct {
+		charge     *securionpay.Charge
+		advance    time.Duration
+		statusCode int
+		body       string
+		roundTrip  http.RoundTripper
+
+		wantDuplicate bool
+	}{
+		0: {charge: newCharge(customerID1, 1500, "Order #1")},
+		1: {charge: newCharge(customerID1, 1500, "Order #1"), advance: 30 * time.Second, wantDuplicate: true},
+		2: {charge: newCharge(customerID1, 1500, " Order #1 "), wantDuplicate: true},
+		3: {charge: newCharge(customerID1, 1600, "Order #1")},
+		4: {charge: newCharge(customerID1, 1500, "Order #2")},
+		5: {charge: newCharge("cust_other", 1500, "Order #1")},
+		6: {charge: newCharge(customerID1, 1500, "Order #1"), advance: 30 * time.Second},
+		7: {
+			// A rejected charge is forgotten so that it can be retried.
+			charge:     newCharge(customerID1, 2500, "Order #3"),
+			statusCode: http.StatusBadRequest,
+			body:       `{"error":{"type":"invalid_request","message":"Invalid amount"}}`,
+		},
+		8:  {charge: newCharge(customerID1, 2500, "Order #3")},
+		9:  {charge: newCharge(customerID1, 2500, "Order #3"), wantDuplicate: true},
+		10: {charge: &securionpay.Charge{Card: tokenID1, Currency: securionpay.USD, AmountMinorCurrencyUnits: 1500}},
+		11: {charge: &securionpay.Charge{Card: tokenID1, Currency: securionpay.USD, AmountMinorCurrencyUnits: 1500}},
+
+		// A charge whose outcome is unknown, as it may
+		// have been made, isn't forgotten.
+		12: {charge: newCharge(customerID1, 3500, "Order #4"), roundTrip: new(brokenConnRoundTripper)},
+		13: {charge: newCharge(customerID1, 3500, "Order #4"), wantDuplicate: true},
+		14: {charge: newCharge(customerID1, 4500, "Order #5"), statusCode: http.StatusInternalServerError},
+		15: {charge: newCharge(customerID1, 4500, "Order #5"), wantDuplicate: true},
+		16: {
+			charge:     newCharge(customerID1, 5500, "Order #6"),
+			statusCode: http.StatusTooManyRequests,
+			body:       `{"error":{"type":"rate_limit","message":"Too many requests"}}`,
+		},
+		17: {charge: newCharge(customerID1, 5500, "Order #6")},
+	}
+
+	for i, tt := range tests {
+		now = now.Add(tt.advance)
+		body := tt.body
+		if body == "" {
+			body = `{"id":"char_1"}`
+		}
+		crt := &cannedRoundTripper{statusCode: tt.statusCode, body: body}
+		client.SetHTTPRoundTripper(crt)
+		if tt.roundTrip != nil {
+			client.SetHTTPRoundTripper(tt.roundTrip)
+		}
+
+		_, err := client.Charge(tt.charge)
+		if tt.wantDuplicate {
+			if err != securionpay.ErrPossibleDuplicate {
+				t.Errorf("#%d: got err=%v, want ErrPossibleDuplicate", i, err)
+			}
+			if n := len(crt.requests()); n != 0 {
+				t.Errorf("#%d: got %d requests for a duplicate charge", i, n)
+			}
+			continue
+		}
+		if failed := tt.statusCode != 0 || tt.roundTrip != nil; failed != (err != nil) {
+			t.Errorf("#%d: got err=%v, want an error=%t", i, err, failed)
+		}
+		if tt.roundTrip != nil {
+			continue
+		}
+		if n := len(crt.requests()); n != 1 {
+			t.Errorf("#%d: got %d requests, want 1", i, n)
+		}
+	}
+
+	// Once disabled, identical charges are sent.
+	client.SetDuplicateChargeWindow(0)
+	crt := &cannedRoundTripper{body: `{"id":"char_1"}`}
+	client.SetHTTPRoundTripper(crt)
+	for i := 0; i < 2; i++ {
+		if _, err := client.Charge(newCharge(customerID1, 1500, "Order #1")); err != nil {
+			t.Errorf("#%d: err: %v", i, err)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// defaultHost is the host of all the endpoints, which
+// SecurionPay serves both test and live mode requests from.
+const defaultHost = "api.securionpay.com"
+
+var errInvalidBaseURL = errors.New("expecting an absolute http or https base URL")
+
+func parseBaseURL(rawURL string) (*url.URL, error) {
+	rawURL = strings.TrimSpace(rawURL)
+	if rawURL == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, errInvalidBaseURL
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	return u, nil
+}
+
+// SetBaseURL sets the base URL that requests are sent to instead of
+// "https://api.securionpay.com", e.g a proxy or a mock server, with
+// endpoints resolved relative to it. An empty baseURL restores the default.
+func (c *Client) SetBaseURL(baseURL string) error {
+	u, err := parseBaseURL(baseURL)
+	if err != nil {
+		return err
+	}
+	c.Lock()
+	c.baseURL = u
+	c.Unlock()
+	return nil
+}
+
+// SetSandboxBaseURL sets the base URL that requests made with test keys
+// are sent to once SetAutoEnvironment is enabled, e.g a local mock.
+func (c *Client) SetSandboxBaseURL(baseURL string) error {
+	u, err := parseBaseURL(baseURL)
+	if err != nil {
+		return err
+	}
+	c.Lock()
+	c.sandboxBaseURL = u
+	c.Unlock()
+	return nil
+}
+
+// SetAutoEnvironment makes the client pick its base URL from its API
+// key: test keys such as "sk_test_..." use the sandbox base URL if one
+// was set by SetSandboxBaseURL. It is off by default to avoid surprises.
+func (c *Client) SetAutoEnvironment(auto bool) {
+	c.Lock()
+	c.autoEnvironment = auto
+	c.Unlock()
+}
+
+// effectiveBaseURL returns the base URL that requests
+// must be sent to or nil if it is the default one.
+func (c *Client) effectiveBaseURL() *url.URL {
+	c.RLock()
+	defer c.RUnlock()
+
+	if c.autoEnvironment && c.sandboxBaseURL != nil && IsTestKey(c.apiKey) {
+		return c.sandboxBaseURL
+	}
+	return c.baseURL
+}
+
+// rebaseRequest returns req addressed to the effective base URL
+// if it isn't the default one, otherwise it returns req as is.
+func (c *Client) rebaseRequest(req *http.Request) *http.Request {
+	base := c.effectiveBaseURL()
+	if base == nil || req.URL.Host != defaultHost {
+		return req
+	}
+
+	rebased := req.WithContext(req.Context())
+	u := *req.URL
+	u.Scheme, u.Host, u.User = base.Scheme, base.Host, base.User
+	u.Path = base.Path + req.URL.Path
+	if req.URL.RawPath != "" {
+		u.RawPath = base.EscapedPath() + req.URL.RawPath
+	}
+	rebased.URL = &u
+	rebased.Host = ""
+	return rebased
+}
+
+// buildURL returns the URL, on the default host like the other endpoint
+// URLs, of the already escaped path with the encoded query if any.
+func buildURL(path string, query url.Values) string {
+	u := url.URL{Scheme: "https", Host: defaultHost}
+
+	escaped := "/" + strings.TrimLeft(path, "/")
+	unescaped, err := url.PathUnescape(escaped)
+	if err != nil {
+		unescaped = escaped
+	}
+	u.Path, u.RawPath = unescaped, escaped
+	u.RawQuery = query.Encode()
+	return u.String()
+}
@@ -0,0 +1,156 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay_test
+
+import (
+	"testing"
+
+	"github.com/orijtech/securionpay"
+)
+
+func TestBaseURLAndAutoEnvironment(t *testing.T) {
+	tests := [...]struct {
+		apiKey     string
+		baseURL    string
+		sandboxURL string
+		auto       bool
+
+		wantURL string
+	}{
+		0: {apiKey: "sk_test_abc", wantURL: "https://api.securionpay.com/charges/" + chargeID1},
+		1: {apiKey: "sk_test_abc", baseURL: "http://localhost:8080/v1/", wantURL: "http://localhost:8080/v1/charges/" + chargeID1},
+
+		// The sandbox base URL is only used with auto environment on.
+		2: {apiKey: "sk_test_abc", sandboxURL: "http://sandbox.local", wantURL: "https://api.securionpay.com/charges/" + chargeID1},
+		3: {apiKey: "sk_test_abc", sandboxURL: "http://sandbox.local", auto: true, wantURL: "http://sandbox.local/charges/" + chargeID1},
+		4: {
+			apiKey:     "sk_live_abc",
+			baseURL:    "https://proxy.local",
+			sandboxURL: "http://sandbox.local",
+			auto:       true,
+			wantURL:    "https://proxy.local/charges/" + chargeID1,
+		},
+		5: {
+			// SecurionPay serves test keys from the same host, so
+			// without a sandbox base URL nothing changes.
+			apiKey:  "sk_test_abc",
+			auto:    true,
+			wantURL: "https://api.securionpay.com/charges/" + chargeID1,
+		},
+	}
+
+	for i, tt := range tests {
+		client, err := securionpay.NewClient(tt.apiKey)
+		if err != nil {
+			t.Fatalf("#%d: initializing client: %v", i, err)
+		}
+		if err := client.SetBaseURL(tt.baseURL); err != nil {
+			t.Errorf("#%d: setting base URL: %v", i, err)
+			continue
+		}
+		if err := client.SetSandboxBaseURL(tt.sandboxURL); err != nil {
+			t.Errorf("#%d: setting sandbox base URL: %v", i, err)
+			continue
+		}
+		client.SetAutoEnvironment(tt.auto)
+
+		crt := &cannedRoundTripper{body: `{"id":"` + chargeID1 + `"}`}
+		client.SetHTTPRoundTripper(crt)
+		if _, err := client.FindChargeByID(chargeID1); err != nil {
+			t.Errorf("#%d: err: %v", i, err)
+			continue
+		}
+		if got := crt.requests()[0].URL.String(); got != tt.wantURL {
+			t.Errorf("#%d: got URL=%q want=%q", i, got, tt.wantURL)
+		}
+	}
+
+	client := newTestClient(t)
+	for i, rawURL := range []string{"localhost:8080", "ftp://example.com", "/charges"} {
+		if err := client.SetBaseURL(rawURL); err == nil {
+			t.Errorf("#%d: expected %q to be rejected", i, rawURL)
+		}
+	}
+}
+
+func TestListURLsFromBaseURL(t *testing.T) {
+	tests := [...]struct {
+		apiKey     string
+		baseURL    string
+		sandboxURL string
+		list       func(*securionpay.Client) error
+
+		wantURL string
+	}{
+		0: {
+			apiKey:  "sk_test_abc",
+			list:    listChargesOfCustomer1,
+			wantURL: "https://api.securionpay.com/charges?customerId=customerID1&limit=10",
+		},
+		1: {
+			apiKey:  "sk_test_abc",
+			baseURL: "http://localhost:8080/v1/",
+			list:    listChargesOfCustomer1,
+			wantURL: "http://localhost:8080/v1/charges?customerId=customerID1&limit=10",
+		},
+		2: {
+			apiKey:     "sk_test_abc",
+			sandboxURL: "http://sandbox.local/api%20v1",
+			list:       listChargesOfCustomer1,
+			wantURL:    "http://sandbox.local/api%20v1/charges?customerId=customerID1&limit=10",
+		},
+		// IDs are escaped within the path.
+		3: {
+			apiKey:  "sk_test_abc",
+			baseURL: "https://proxy.local",
+			list: func(client *securionpay.Client) error {
+				_, err := client.ListCards("cust 1/2")
+				return err
+			},
+			wantURL: "https://proxy.local/customers/cust%201%2F2/cards?limit=100",
+		},
+	}
+
+	for i, tt := range tests {
+		client, err := securionpay.NewClient(tt.apiKey)
+		if err != nil {
+			t.Fatalf("#%d: initializing client: %v", i, err)
+		}
+		if err := client.SetBaseURL(tt.baseURL); err != nil {
+			t.Errorf("#%d: setting base URL: %v", i, err)
+			continue
+		}
+		if err := client.SetSandboxBaseURL(tt.sandboxURL); err != nil {
+			t.Errorf("#%d: setting sandbox base URL: %v", i, err)
+			continue
+		}
+		client.SetAutoEnvironment(true)
+
+		crt := &cannedRoundTripper{body: `{"list":[],"hasMore":false}`}
+		client.SetHTTPRoundTripper(crt)
+		if err := tt.list(client); err != nil {
+			t.Errorf("#%d: err: %v", i, err)
+			continue
+		}
+		if got := crt.requests()[0].URL.String(); got != tt.wantURL {
+			t.Errorf("#%d: got URL=%q want=%q", i, got, tt.wantURL)
+		}
+	}
+}
+
+func listChargesOfCustomer1(client *securionpay.Client) error {
+	_, err := client.ListCharges(&securionpay.ChargeListRequest{CustomerID: customerID1})
+	return err
+}
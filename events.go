@@ -0,0 +1,193 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// EventType is the type of an event, named after
+// the change to the object that the event is about.
+type EventType string
+
+const (
+	EventChargeSucceeded EventType = "CHARGE_SUCCEEDED"
+	EventChargeFailed    EventType = "CHARGE_FAILED"
+	EventChargeUpdated   EventType = "CHARGE_UPDATED"
+	EventChargeCaptured  EventType = "CHARGE_CAPTURED"
+	EventChargeRefunded  EventType = "CHARGE_REFUNDED"
+
+	EventChargeDisputeCreated        EventType = "CHARGE_DISPUTE_CREATED"
+	EventChargeDisputeUpdated        EventType = "CHARGE_DISPUTE_UPDATED"
+	EventChargeDisputeFundsWithdrawn EventType = "CHARGE_DISPUTE_FUNDS_WITHDRAWN"
+	EventChargeDisputeFundsRestored  EventType = "CHARGE_DISPUTE_FUNDS_RESTORED"
+	EventChargeDisputeWon            EventType = "CHARGE_DISPUTE_WON"
+	EventChargeDisputeLost           EventType = "CHARGE_DISPUTE_LOST"
+
+	EventCustomerCreated EventType = "CUSTOMER_CREATED"
+	EventCustomerUpdated EventType = "CUSTOMER_UPDATED"
+	EventCustomerDeleted EventType = "CUSTOMER_DELETED"
+
+	EventCardCreated EventType = "CARD_CREATED"
+	EventCardUpdated EventType = "CARD_UPDATED"
+	EventCardDeleted EventType = "CARD_DELETED"
+
+	EventSubscriptionCreated  EventType = "SUBSCRIPTION_CREATED"
+	EventSubscriptionUpdated  EventType = "SUBSCRIPTION_UPDATED"
+	EventSubscriptionCanceled EventType = "SUBSCRIPTION_CANCELED"
+
+	EventPlanCreated EventType = "PLAN_CREATED"
+	EventPlanUpdated EventType = "PLAN_UPDATED"
+	EventPlanDeleted EventType = "PLAN_DELETED"
+
+	EventCreditSucceeded EventType = "CREDIT_SUCCEEDED"
+	EventCreditFailed    EventType = "CREDIT_FAILED"
+	EventCreditUpdated   EventType = "CREDIT_UPDATED"
+)
+
+// chargeEventTypes are the types of the events about charges,
+// excluding their disputes.
+var chargeEventTypes = []EventType{
+	EventChargeSucceeded,
+	EventChargeFailed,
+	EventChargeUpdated,
+	EventChargeCaptured,
+	EventChargeRefunded,
+}
+
+type Event struct {
+	ID         string     `json:"id"`
+	CreatedAt  int64      `json:"created"`
+	ObjectType ObjectType `json:"objectType"`
+
+	Type EventType `json:"type"`
+
+	// Data is the object that the event is about, in the state that
+	// it was in when the event happened, e.g a charge for the charge
+	// events, that can be decoded into the matching type.
+	Data json.RawMessage `json:"data"`
+}
+
+type EventList struct {
+	Events []*Event `json:"list"`
+
+	HasMore    bool `json:"hasMore"`
+	TotalCount int  `json:"totalCount,omitempty"`
+
+	// ClientFiltered is set when Events were filtered by this package
+	// after being fetched, for example by Types. HasMore and TotalCount
+	// then describe the unfiltered results.
+	ClientFiltered bool `json:"-"`
+
+	// NextStartingAfterId is the ID of the last event that was fetched,
+	// before any filtering, to set as StartingAfterId to fetch the next
+	// page. Filtering can leave Events empty even though HasMore is set.
+	NextStartingAfterId string `json:"-"`
+}
+
+type EventListRequest struct {
+	Limit int `json:"limit,omitempty"`
+
+	CreatedAfter      int64 `json:"gt,omitempty"`
+	CreatedOnOrAfter  int64 `json:"gte,omitempty"`
+	CreatedBefore     int64 `json:"lt,omitempty"`
+	CreatedOnOrBefore int64 `json:"lte,omitempty"`
+
+	StartingAfterId   string `json:"startingAfterId,omitempty"`
+	EndingBeforeId    string `json:"endingBeforeId,omitempty"`
+	IncludeTotalCount bool   `json:"includeTotalCount,omitempty"`
+
+	// Types, if set, keeps only the events of those types. SecurionPay
+	// doesn't support filtering by type so this filter is applied by
+	// this package to each fetched page, which can thus hold fewer
+	// events than Limit, or none. Page on with NextStartingAfterId.
+	Types []EventType `json:"-"`
+}
+
+// TypeCharges sets Types to the types of the events about charges,
+// such as their capture and refunds, and returns elr for chaining.
+func (elr *EventListRequest) TypeCharges() *EventListRequest {
+	elr.Types = append([]EventType(nil), chargeEventTypes...)
+	return elr
+}
+
+func (elr *EventListRequest) Validate() error {
+	if elr == nil {
+		return nil
+	}
+	return validateCreatedRange(elr.CreatedAfter, elr.CreatedOnOrAfter, elr.CreatedBefore, elr.CreatedOnOrBefore)
+}
+
+func (elr *EventListRequest) matchesClientFilters(event *Event) bool {
+	if elr == nil || len(elr.Types) == 0 {
+		return true
+	}
+	for _, typ := range elr.Types {
+		if event != nil && event.Type == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// ListEvents lists the events that happened to the account's
+// objects, most recent first, e.g to poll for charge captures.
+//
+// GET https://api.securionpay.com/events
+func (c *Client) ListEvents(elr *EventListRequest) (*EventList, error) {
+	if err := elr.Validate(); err != nil {
+		return nil, err
+	}
+
+	ereq := new(EventListRequest)
+	if elr != nil {
+		*ereq = *elr
+	}
+	qv, err := listQuery(ereq)
+	if err != nil {
+		return nil, err
+	}
+
+	fullURL := buildURL("/events", qv)
+	req, err := http.NewRequest("GET", fullURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	slurp, err := c.doAuthThenReqAndSlurpResponse(req)
+	if err != nil {
+		return nil, err
+	}
+	events := new(EventList)
+	if err := c.decodeResponse(slurp, events); err != nil {
+		return nil, err
+	}
+	if n := len(events.Events); n > 0 && events.Events[n-1] != nil {
+		events.NextStartingAfterId = events.Events[n-1].ID
+	}
+	if len(ereq.Types) == 0 {
+		return events, nil
+	}
+
+	filtered := events.Events[:0]
+	for _, event := range events.Events {
+		if ereq.matchesClientFilters(event) {
+			filtered = append(filtered, event)
+		}
+	}
+	events.Events = filtered
+	events.ClientFiltered = true
+	return events, nil
+}
@@ -0,0 +1,128 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"reflect"
+	"testing"
+
+	"github.com/orijtech/securionpay"
+)
+
+func TestListEvents(t *testing.T) {
+	client := newTestClient(t)
+
+	eventsBody, err := ioutil.ReadFile("testdata/events1.json")
+	if err != nil {
+		t.Fatalf("reading events fixture: %v", err)
+	}
+
+	tests := [...]struct {
+		elr *securionpay.EventListRequest
+
+		wantErr            bool
+		wantQuery          string
+		wantIDs            []string
+		wantClientFiltered bool
+	}{
+		0: {
+			elr:     &securionpay.EventListRequest{CreatedAfter: 10, CreatedOnOrAfter: 10},
+			wantErr: true,
+		},
+		1: {
+			elr:     nil,
+			wantIDs: []string{"evt_3tHrz5aW9vkHxwQqgTTdWSjm", "evt_9vkHxwQqgTTdWSjm3tHrz5aW", "evt_QqgTTdWSjm3tHrz5aW9vkHxw"},
+		},
+		2: {
+			elr:                (&securionpay.EventListRequest{Limit: 3}).TypeCharges(),
+			wantQuery:          "limit=3",
+			wantIDs:            []string{"evt_3tHrz5aW9vkHxwQqgTTdWSjm", "evt_QqgTTdWSjm3tHrz5aW9vkHxw"},
+			wantClientFiltered: true,
+		},
+		3: {
+			elr:                &securionpay.EventListRequest{Types: []securionpay.EventType{securionpay.EventChargeCaptured}},
+			wantIDs:            []string{"evt_QqgTTdWSjm3tHrz5aW9vkHxw"},
+			wantClientFiltered: true,
+		},
+		4: {
+			// A page filtered down to nothing can still be paged past.
+			elr:                &securionpay.EventListRequest{Types: []securionpay.EventType{securionpay.EventCustomerCreated}},
+			wantClientFiltered: true,
+		},
+	}
+
+	for i, tt := range tests {
+		crt := &cannedRoundTripper{body: string(eventsBody)}
+		client.SetHTTPRoundTripper(crt)
+
+		events, err := client.ListEvents(tt.elr)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("#%d: expected an error", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: err: %v", i, err)
+			continue
+		}
+
+		req := crt.requests()[0]
+		if req.URL.Path != "/events" {
+			t.Errorf("#%d: gotPath=%q", i, req.URL.Path)
+		}
+		if got := req.URL.Query().Encode(); got != tt.wantQuery {
+			t.Errorf("#%d: gotQuery=%q want=%q", i, got, tt.wantQuery)
+		}
+
+		var gotIDs []string
+		for _, event := range events.Events {
+			gotIDs = append(gotIDs, event.ID)
+		}
+		if !reflect.DeepEqual(gotIDs, tt.wantIDs) {
+			t.Errorf("#%d: gotIDs=%v want=%v", i, gotIDs, tt.wantIDs)
+		}
+		if events.ClientFiltered != tt.wantClientFiltered {
+			t.Errorf("#%d: gotClientFiltered=%v want=%v", i, events.ClientFiltered, tt.wantClientFiltered)
+		}
+		if !events.HasMore {
+			t.Errorf("#%d: HasMore should describe the unfiltered results", i)
+		}
+		if got, want := events.NextStartingAfterId, "evt_QqgTTdWSjm3tHrz5aW9vkHxw"; got != want {
+			t.Errorf("#%d: gotNextStartingAfterId=%q want=%q", i, got, want)
+		}
+	}
+}
+
+func TestEventData(t *testing.T) {
+	events := new(securionpay.EventList)
+	if err := retrFromFile("testdata/events1.json", events); err != nil {
+		t.Fatalf("loading events: %v", err)
+	}
+
+	refunded := events.Events[0]
+	if refunded.Type != securionpay.EventChargeRefunded {
+		t.Fatalf("got type %q", refunded.Type)
+	}
+	charge := new(securionpay.ChargeResponse)
+	if err := json.Unmarshal(refunded.Data, charge); err != nil {
+		t.Fatalf("decoding the event's charge: %v", err)
+	}
+	if charge.ID != chargeID1 || !charge.Refunded {
+		t.Errorf("got charge %#v", charge)
+	}
+}
@@ -67,7 +67,7 @@ func Example_client_Charge() {
 
 		Billing: &securionpay.Billing{
 			Address: &securionpay.Address{
-				Country: "USA",
+				Country: "US",
 				City:    "Washington",
 				State:   "Washington DC",
 				Line1:   "1600 Pennsylvania Ave NW",
@@ -0,0 +1,140 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// keyFailoverState is shared by a client and the clients derived from
+// it, e.g by DoWithCorrelationID, so that they fail over together.
+type keyFailoverState struct {
+	sync.Mutex
+
+	// keyInUse is the last key that SecurionPay
+	// accepted with failover enabled.
+	keyInUse string
+}
+
+func (ks *keyFailoverState) get() string {
+	ks.Lock()
+	defer ks.Unlock()
+
+	return ks.keyInUse
+}
+
+func (ks *keyFailoverState) set(key string) {
+	ks.Lock()
+	ks.keyInUse = key
+	ks.Unlock()
+}
+
+// apiKeyKey is the context key of the API key that a
+// request is sent with when failing over between keys.
+type apiKeyKey struct{}
+
+// SetKeyFailover enables or disables resending requests rejected with
+// 401 or 403 with the next fallback API key. Later requests start
+// with the last key that was accepted.
+func (c *Client) SetKeyFailover(enable bool) {
+	c.Lock()
+	c.keyFailover = enable
+	c.Unlock()
+}
+
+// SetFallbackAPIKeys sets the keys that failover tries, in
+// order, once SecurionPay rejects the client's API key.
+func (c *Client) SetFallbackAPIKeys(keys ...string) {
+	c.Lock()
+	c.fallbackKeys = nonBlankKeysAfter("", keys)
+	c.Unlock()
+}
+
+// APIKeyInUse returns, redacted, the API key that the last request was
+// accepted with when failover is enabled, otherwise the client's API key.
+func (c *Client) APIKeyInUse() string {
+	keyState := c.ensureKeyFailoverState()
+
+	c.RLock()
+	defer c.RUnlock()
+
+	if keyInUse := keyState.get(); c.keyFailover && keyInUse != "" {
+		return redactAPIKey(keyInUse)
+	}
+	return redactAPIKey(c.apiKey)
+}
+
+func (c *Client) ensureKeyFailoverState() *keyFailoverState {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.keyState == nil {
+		c.keyState = new(keyFailoverState)
+	}
+	return c.keyState
+}
+
+// failoverKeys returns the API keys to try in order, starting from the
+// last one that was accepted, or nil if failover is disabled or there
+// are no fallback keys.
+func (c *Client) failoverKeys() []string {
+	keyState := c.ensureKeyFailoverState()
+
+	c.RLock()
+	defer c.RUnlock()
+
+	if !c.keyFailover || len(c.fallbackKeys) == 0 {
+		return nil
+	}
+	keys := append([]string{c.apiKey}, c.fallbackKeys...)
+	keyInUse := keyState.get()
+	for i, key := range keys {
+		if i > 0 && key == keyInUse {
+			return append(append([]string(nil), keys[i:]...), keys[:i]...)
+		}
+	}
+	return keys
+}
+
+func (c *Client) setKeyInUse(key string) {
+	c.ensureKeyFailoverState().set(key)
+}
+
+func authFailure(code int) bool {
+	return code == http.StatusUnauthorized || code == http.StatusForbidden
+}
+
+// nonBlankKeysAfter returns the non blank keys that come after
+// the first occurrence of primary in keys, or all of them if
+// primary is blank.
+func nonBlankKeysAfter(primary string, keys []string) []string {
+	var after []string
+	primary = strings.TrimSpace(primary)
+	seenPrimary := primary == ""
+	for _, key := range keys {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+		if !seenPrimary {
+			seenPrimary = key == primary
+			continue
+		}
+		after = append(after, key)
+	}
+	return after
+}
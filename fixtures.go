@@ -0,0 +1,49 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// RecordResponse saves v, usually a response from SecurionPay, as
+// indented JSON to filename e.g "testdata/chargeResp1.json", creating
+// any missing directories, so that it can be used as a test fixture.
+func RecordResponse(filename string, v interface{}) error {
+	blob, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(filename); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	return ioutil.WriteFile(filename, append(blob, '\n'), 0644)
+}
+
+// LoadResponse reads the JSON fixture in filename into save, which
+// should be a pointer e.g to a ChargeResponse. It is the counterpart
+// of RecordResponse but also reads fixtures that were written by hand.
+func LoadResponse(filename string, save interface{}) error {
+	blob, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(blob, save)
+}
@@ -0,0 +1,59 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/orijtech/securionpay"
+)
+
+func TestRecordAndLoadResponse(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "securionpay-fixtures")
+	if err != nil {
+		t.Fatalf("creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	want := new(securionpay.ChargeResponse)
+	if err := securionpay.LoadResponse("testdata/chargeResp1.json", want); err != nil {
+		t.Fatalf("loading fixture: %v", err)
+	}
+	if want.ID != chargeID1 {
+		t.Fatalf("gotID=%q want=%q", want.ID, chargeID1)
+	}
+
+	// Missing directories are created.
+	path := filepath.Join(tmpDir, "testdata", "charge.json")
+	if err := securionpay.RecordResponse(path, want); err != nil {
+		t.Fatalf("recording fixture: %v", err)
+	}
+
+	got := new(securionpay.ChargeResponse)
+	if err := securionpay.LoadResponse(path, got); err != nil {
+		t.Fatalf("loading recorded fixture: %v", err)
+	}
+	if g, w := blobify(got), blobify(want); !bytes.Equal(g, w) {
+		t.Errorf("round trip mismatch\ngot:  %s\nwant: %s", g, w)
+	}
+
+	if err := securionpay.LoadResponse(filepath.Join(tmpDir, "non-existent.json"), got); err == nil {
+		t.Errorf("expected an error loading a non-existent fixture")
+	}
+}
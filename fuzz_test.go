@@ -0,0 +1,81 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.18
+// +build go1.18
+
+package securionpay_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/orijtech/securionpay"
+)
+
+func FuzzCustomerIDUnmarshal(f *testing.F) {
+	seeds := []string{
+		`null`,
+		`"cust_AoR0wvgntQWRUYMdZNLYMz5R"`,
+		`""`,
+		`12345`,
+		`-1.5e3`,
+		`"été"`,
+		`nul`,
+		`"unterminated`,
+		`{}`,
+		`[]`,
+		``,
+	}
+	for _, seed := range seeds {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+		var cid securionpay.CustomerID
+		if err := cid.UnmarshalJSON(b); err != nil {
+			return
+		}
+
+		// Any successfully decoded ID must survive a round trip.
+		blob, err := json.Marshal(cid)
+		if err != nil {
+			t.Fatalf("failed to marshal %q: %v", cid, err)
+		}
+		var roundTripped securionpay.CustomerID
+		if err := json.Unmarshal(blob, &roundTripped); err != nil {
+			t.Fatalf("failed to unmarshal %s: %v", blob, err)
+		}
+		if roundTripped != cid {
+			t.Fatalf("round trip mismatch: got=%q want=%q", roundTripped, cid)
+		}
+	})
+}
+
+func FuzzObjectTypeMarshal(f *testing.F) {
+	for _, seed := range []string{"", "card", "  charge ", "\x00", "\"quoted\""} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, s string) {
+		ot := securionpay.ObjectType(s)
+		blob, err := ot.MarshalJSON()
+		if err != nil {
+			return
+		}
+		if !json.Valid(blob) {
+			t.Fatalf("invalid JSON produced for %q: %s", s, blob)
+		}
+	})
+}
@@ -0,0 +1,90 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/url"
+	"time"
+)
+
+// PaymentGateway is the set of SecurionPay operations that *Client
+// implements, so that code depending on it can be tested with a fake.
+type PaymentGateway interface {
+	Charge(creq *Charge) (*ChargeResponse, error)
+	ChargeSavedCard(customerID, cardID string, amount int, currency Currency, description string) (*ChargeResponse, error)
+	ChargeCustomerDefault(customerID CustomerID, amount int, description string) (*ChargeResponse, error)
+	ChargeAndSaveCard(customerID string, token string, amount int, cur Currency) (*ChargeResponse, *Card, error)
+	TokenizeAndCharge(treq *TokenRequest, amount int, currency Currency, description string) (*Token, *ChargeResponse, error)
+	FindChargeByID(chargeID string) (*ChargeResponse, error)
+	UpdateChargeMetadata(chargeID string, metadata map[string]interface{}) (*ChargeResponse, error)
+	ListCharges(clr *ChargeListRequest) (*ChargeList, error)
+	ExportCharges(ctx context.Context, w io.Writer, filter *ChargeListRequest) error
+	IncrementAuthorization(chargeID string, additionalMinor int) (*ChargeResponse, error)
+	CaptureCharge(chargeID string) (*ChargeResponse, error)
+	Complete3DSCharge(chargeID string) (*ChargeResponse, error)
+	CaptureBefore(ctx context.Context, chargeID string, at time.Time) error
+	RetryChargeWithCard(failedChargeID, newCardID string) (*ChargeResponse, error)
+	FindOriginalCharge(err error) (*ChargeResponse, error)
+	RefundCharge(rr *RefundRequest) (*Refund, error)
+	RefundCharges(ctx context.Context, reqs []*RefundRequest, concurrency int) ([]*Refund, []error)
+
+	NewToken(treq *TokenRequest) (*Token, error)
+	NewTokenDeduped(treq *TokenRequest, window time.Duration) (*Token, error)
+	FindTokenByID(tokenID string) (*Token, error)
+	GetCachedToken(tokenID string) (*Token, bool)
+	UpdateTokenFraudData(tokenID string, f *FraudCheckData) (*Token, error)
+
+	FindCustomerByID(customerID string) (*Customer, error)
+	UpdateCustomerMetadata(customerID string, metadata map[string]interface{}) (*Customer, error)
+	AddCard(acr *AddCardRequest) (*Card, error)
+	FindCard(customerID, cardID string) (*Card, error)
+	ListCards(customerID string) ([]*Card, error)
+	ListCardsWithDefault(customerID string) ([]*Card, string, error)
+	DeleteCard(customerID, cardID string) error
+	DeleteCardKeepingFingerprint(customerID, cardID string) (string, error)
+
+	ListCredits(cr *CreditRequest) (*Credits, error)
+	ListCustomerCredits(customerID string, cr *CreditRequest) (*Credits, error)
+	StreamCredits(ctx context.Context, cr *CreditRequest) (<-chan *Credit, <-chan error)
+
+	FindDisputeByID(disputeID string) (*Dispute, error)
+	GetDisputeEvidence(disputeID string) (*DisputeEvidence, error)
+
+	ListEvents(elr *EventListRequest) (*EventList, error)
+
+	ListBalanceTransactions(btr *BalanceTxListRequest) (*BalanceTxList, error)
+
+	ListAllSubscriptions(sr *SubscriptionListRequest) (*SubscriptionList, error)
+	ApplyCoupon(subscriptionID, couponID string) (*Subscription, error)
+
+	CreateCoupon(cp *Coupon) (*Coupon, error)
+	FindCouponByID(couponID string) (*Coupon, error)
+	ListCoupons(clr *CouponListRequest) (*CouponList, error)
+	DeleteCoupon(couponID string) error
+
+	ListInvoices(customerID string, ir *InvoiceListRequest) (*InvoiceList, error)
+	FindInvoiceByID(invoiceID string) (*Invoice, error)
+	MarkInvoicePaid(invoiceID string) (*Invoice, error)
+	UpcomingInvoice(subscriptionID string) (*Invoice, error)
+
+	GetRaw(path string, query url.Values) (json.RawMessage, error)
+	PostRaw(path string, body interface{}) (json.RawMessage, error)
+	PostRawIdempotent(path string, body interface{}, idempotencyKey string) (json.RawMessage, error)
+}
+
+var _ PaymentGateway = (*Client)(nil)
@@ -0,0 +1,70 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay_test
+
+import (
+	"testing"
+
+	"github.com/orijtech/securionpay"
+)
+
+// fakeGateway shows how consumers can fake just the
+// operations that their code uses, in their own tests.
+type fakeGateway struct {
+	securionpay.PaymentGateway
+
+	charges []*securionpay.Charge
+}
+
+func (fg *fakeGateway) Charge(creq *securionpay.Charge) (*securionpay.ChargeResponse, error) {
+	fg.charges = append(fg.charges, creq)
+	return &securionpay.ChargeResponse{
+		ID:       "char_fake",
+		Amount:   float32(creq.AmountMinorCurrencyUnits),
+		Currency: creq.Currency,
+		Captured: true,
+	}, nil
+}
+
+// checkout stands in for consumer code that depends on the interface.
+func checkout(gw securionpay.PaymentGateway, tokenID string, amount int) (string, error) {
+	cResp, err := gw.Charge(&securionpay.Charge{
+		Card:     tokenID,
+		Currency: securionpay.Euros,
+
+		AmountMinorCurrencyUnits: amount,
+	})
+	if err != nil {
+		return "", err
+	}
+	return cResp.ID, nil
+}
+
+func TestPaymentGatewayFake(t *testing.T) {
+	fg := new(fakeGateway)
+	chargeID, err := checkout(fg, tokenID1, 499)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if chargeID != "char_fake" {
+		t.Errorf("gotChargeID=%q", chargeID)
+	}
+	if len(fg.charges) != 1 || fg.charges[0].AmountMinorCurrencyUnits != 499 {
+		t.Errorf("got charges %#v", fg.charges)
+	}
+
+	client := newTestClient(t)
+	var _ securionpay.PaymentGateway = client
+}
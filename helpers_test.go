@@ -0,0 +1,102 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/orijtech/securionpay"
+)
+
+func newTestClient(t *testing.T) *securionpay.Client {
+	client, err := securionpay.NewClientFromEnv()
+	if err != nil {
+		t.Fatalf("initializing client from env: %v", err)
+	}
+	return client
+}
+
+// newClientWithRoundTripper returns a client whose requests are made by rt.
+func newClientWithRoundTripper(t *testing.T, rt http.RoundTripper) *securionpay.Client {
+	client := newTestClient(t)
+	client.SetHTTPRoundTripper(rt)
+	return client
+}
+
+// cannedRoundTripper records every request it receives and
+// responds to each with the same status code, header and body,
+// unless statusCodes or bodies are set in which case the nth
+// request gets the nth status code or body respectively.
+type cannedRoundTripper struct {
+	mu        sync.Mutex
+	reqs      []*http.Request
+	reqBodies [][]byte
+
+	statusCode  int
+	statusCodes []int
+	header      http.Header
+	body        string
+	bodies      []string
+}
+
+var _ http.RoundTripper = (*cannedRoundTripper)(nil)
+
+func (crt *cannedRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		reqBody, _ = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+	}
+
+	crt.mu.Lock()
+	crt.reqs = append(crt.reqs, req)
+	crt.reqBodies = append(crt.reqBodies, reqBody)
+	body, statusCode := crt.body, crt.statusCode
+	if n := len(crt.reqs); n <= len(crt.bodies) {
+		body = crt.bodies[n-1]
+	}
+	if n := len(crt.reqs); n <= len(crt.statusCodes) {
+		statusCode = crt.statusCodes[n-1]
+	}
+	crt.mu.Unlock()
+
+	if statusCode == 0 {
+		statusCode = http.StatusOK
+	}
+	resp := makeResp(http.StatusText(statusCode), statusCode)
+	for key, values := range crt.header {
+		resp.Header[key] = values
+	}
+	resp.Body = ioutil.NopCloser(strings.NewReader(body))
+	return resp, nil
+}
+
+func (crt *cannedRoundTripper) requests() []*http.Request {
+	crt.mu.Lock()
+	defer crt.mu.Unlock()
+
+	return append([]*http.Request(nil), crt.reqs...)
+}
+
+func (crt *cannedRoundTripper) requestBodies() [][]byte {
+	crt.mu.Lock()
+	defer crt.mu.Unlock()
+
+	return append([][]byte(nil), crt.reqBodies...)
+}
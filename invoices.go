@@ -0,0 +1,252 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+type InvoiceStatus string
+
+const (
+	InvoiceDraft InvoiceStatus = "draft"
+	InvoiceOpen  InvoiceStatus = "open"
+	InvoicePaid  InvoiceStatus = "paid"
+	InvoiceVoid  InvoiceStatus = "void"
+)
+
+type Invoice struct {
+	ID         string     `json:"id"`
+	CreatedAt  int64      `json:"created"`
+	ObjectType ObjectType `json:"objectType"`
+
+	CustomerID     CustomerID    `json:"customerId"`
+	SubscriptionID string        `json:"subscriptionId,omitempty"`
+	Status         InvoiceStatus `json:"status,omitempty"`
+
+	// Currency is the 3 digit ISO currency code
+	// for example: EUR, USD, CAD
+	Currency Currency `json:"currency"`
+
+	// Subtotal and Total are in minor currency units.
+	Subtotal int `json:"subtotal"`
+	Total    int `json:"total"`
+
+	// PeriodStart and PeriodEnd delimit the billing period that
+	// this invoice covers while NextPaymentAttempt is when the
+	// customer is next going to be charged for it. All of them
+	// are in seconds since the Unix epoch.
+	PeriodStart        int64 `json:"periodStart"`
+	PeriodEnd          int64 `json:"periodEnd"`
+	NextPaymentAttempt int64 `json:"nextPaymentAttempt,omitempty"`
+
+	Lines []*InvoiceLineItem `json:"lines,omitempty"`
+
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
+type InvoiceLineItem struct {
+	ID          string `json:"id"`
+	Description string `json:"description"`
+
+	// Amount is in minor currency units.
+	Amount   int      `json:"amount"`
+	Currency Currency `json:"currency"`
+	Quantity int      `json:"quantity"`
+
+	PlanID         string `json:"planId,omitempty"`
+	SubscriptionID string `json:"subscriptionId,omitempty"`
+
+	PeriodStart int64 `json:"periodStart"`
+	PeriodEnd   int64 `json:"periodEnd"`
+}
+
+type InvoiceList struct {
+	Invoices []*Invoice `json:"list"`
+
+	HasMore    bool `json:"hasMore"`
+	TotalCount int  `json:"totalCount,omitempty"`
+}
+
+type InvoiceListRequest struct {
+	Limit      int           `json:"limit,omitempty"`
+	CustomerID CustomerID    `json:"customerId,omitempty"`
+	Status     InvoiceStatus `json:"status,omitempty"`
+
+	CreatedAfter      int64 `json:"gt,omitempty"`
+	CreatedOnOrAfter  int64 `json:"gte,omitempty"`
+	CreatedBefore     int64 `json:"lt,omitempty"`
+	CreatedOnOrBefore int64 `json:"lte,omitempty"`
+
+	StartingAfterId   string `json:"startingAfterId,omitempty"`
+	EndingBeforeId    string `json:"endingBeforeId,omitempty"`
+	IncludeTotalCount bool   `json:"includeTotalCount,omitempty"`
+}
+
+const (
+	invoicesEndpointURL = "https://api.securionpay.com/invoices"
+	defaultInvoiceLimit = 10
+)
+
+func (ir *InvoiceListRequest) Validate() error {
+	if ir == nil {
+		return nil
+	}
+	return validateCreatedRange(ir.CreatedAfter, ir.CreatedOnOrAfter, ir.CreatedBefore, ir.CreatedOnOrBefore)
+}
+
+// ListInvoices lists the invoices of a customer e.g to build their billing
+// history. The customerID argument takes precedence over ir.CustomerID.
+func (c *Client) ListInvoices(customerID string, ir *InvoiceListRequest) (*InvoiceList, error) {
+	customerID = strings.TrimSpace(customerID)
+	if customerID == "" {
+		return nil, errInvalidCustomerID
+	}
+	if err := ir.Validate(); err != nil {
+		return nil, err
+	}
+
+	ireq := new(InvoiceListRequest)
+	if ir != nil {
+		*ireq = *ir
+	}
+	ireq.CustomerID = CustomerID(customerID)
+
+	if ireq.Limit < 1 {
+		ireq.Limit = defaultInvoiceLimit
+	}
+
+	qv, err := listQuery(ireq)
+	if err != nil {
+		return nil, err
+	}
+
+	fullURL := buildURL("/invoices", qv)
+	req, err := http.NewRequest("GET", fullURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	slurp, err := c.doAuthThenReqAndSlurpResponse(req)
+	if err != nil {
+		return nil, err
+	}
+	invoices := new(InvoiceList)
+	if err := c.decodeResponse(slurp, invoices); err != nil {
+		return nil, err
+	}
+	return invoices, nil
+}
+
+var errBlankInvoiceID = errors.New("expecting a non-blank invoice ID")
+
+// GET https://api.securionpay.com/invoices/{INVOICE_ID}
+func (c *Client) FindInvoiceByID(invoiceID string) (*Invoice, error) {
+	invoiceID = strings.TrimSpace(invoiceID)
+	if invoiceID == "" {
+		return nil, errBlankInvoiceID
+	}
+
+	fullURL := fmt.Sprintf("%s/%s", invoicesEndpointURL, invoiceID)
+	req, err := http.NewRequest("GET", fullURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	blob, err := c.doAuthThenReqAndSlurpResponse(req)
+	if err != nil {
+		return nil, err
+	}
+
+	invoice := new(Invoice)
+	if err := c.decodeResponse(blob, invoice); err != nil {
+		return nil, err
+	}
+	return invoice, nil
+}
+
+type markInvoicePaidRequest struct {
+	PaidOutOfBand bool `json:"paidOutOfBand"`
+}
+
+// MarkInvoicePaid records an open invoice as paid without charging
+// the customer, e.g once it was paid by a bank transfer.
+//
+// POST https://api.securionpay.com/invoices/{INVOICE_ID}/pay
+func (c *Client) MarkInvoicePaid(invoiceID string) (*Invoice, error) {
+	invoice, err := c.FindInvoiceByID(invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	if invoice.Status != InvoiceOpen {
+		return nil, fmt.Errorf("invoice %q has status %q, only %q invoices can be marked as paid", invoice.ID, invoice.Status, InvoiceOpen)
+	}
+
+	blob, err := json.Marshal(&markInvoicePaidRequest{PaidOutOfBand: true})
+	if err != nil {
+		return nil, err
+	}
+
+	fullURL := fmt.Sprintf("%s/%s/pay", invoicesEndpointURL, strings.TrimSpace(invoiceID))
+	req, err := http.NewRequest("POST", fullURL, bytes.NewReader(blob))
+	if err != nil {
+		return nil, err
+	}
+
+	blob, err = c.doAuthThenReqAndSlurpResponse(req)
+	if err != nil {
+		return nil, err
+	}
+
+	paidInvoice := new(Invoice)
+	if err := c.decodeResponse(blob, paidInvoice); err != nil {
+		return nil, err
+	}
+	return paidInvoice, nil
+}
+
+var errBlankSubscriptionID = errors.New("expecting a non-blank subscription ID")
+
+// UpcomingInvoice previews the next invoice of a subscription i.e
+// how much the customer is going to be charged next and when.
+//
+// GET https://api.securionpay.com/subscriptions/{SUBSCRIPTION_ID}/upcoming-invoice
+func (c *Client) UpcomingInvoice(subscriptionID string) (*Invoice, error) {
+	subscriptionID = strings.TrimSpace(subscriptionID)
+	if subscriptionID == "" {
+		return nil, errBlankSubscriptionID
+	}
+
+	fullURL := fmt.Sprintf("%s/%s/upcoming-invoice", subscriptionsEndpointURL, subscriptionID)
+	req, err := http.NewRequest("GET", fullURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	blob, err := c.doAuthThenReqAndSlurpResponse(req)
+	if err != nil {
+		return nil, err
+	}
+
+	invoice := new(Invoice)
+	if err := c.decodeResponse(blob, invoice); err != nil {
+		return nil, err
+	}
+	return invoice, nil
+}
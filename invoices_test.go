@@ -0,0 +1,171 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay_test
+
+import (
+	"testing"
+
+	"github.com/orijtech/securionpay"
+)
+
+func TestUpcomingInvoice(t *testing.T) {
+	crt := &cannedRoundTripper{body: `{
+	  "id": "",
+	  "objectType": "invoice",
+	  "customerId": "cust_1",
+	  "subscriptionId": "sub_1",
+	  "currency": "EUR",
+	  "subtotal": 2000,
+	  "total": 2000,
+	  "periodStart": 1415810511,
+	  "periodEnd": 1418402511,
+	  "nextPaymentAttempt": 1418402511,
+	  "lines": [
+	    {"id": "ii_1", "description": "Gold plan", "amount": 1000, "currency": "EUR", "quantity": 2, "planId": "plan_gold"}
+	  ]
+	}`}
+	client := newClientWithRoundTripper(t, crt)
+
+	if _, err := client.UpcomingInvoice("  "); err == nil {
+		t.Errorf("expected an error for a blank subscription ID")
+	}
+
+	invoice, err := client.UpcomingInvoice("sub_1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	reqs := crt.requests()
+	if len(reqs) != 1 {
+		t.Fatalf("got %d requests, want 1", len(reqs))
+	}
+	if got, want := reqs[0].URL.Path, "/subscriptions/sub_1/upcoming-invoice"; got != want {
+		t.Errorf("got path=%q want=%q", got, want)
+	}
+
+	if invoice.Total != 2000 || invoice.NextPaymentAttempt != 1418402511 {
+		t.Errorf("got %#v", invoice)
+	}
+	if len(invoice.Lines) != 1 || invoice.Lines[0].PlanID != "plan_gold" {
+		t.Errorf("got lines %#v", invoice.Lines)
+	}
+}
+
+func TestListInvoicesAndFindInvoiceByID(t *testing.T) {
+	crt := &cannedRoundTripper{body: `{
+	  "list": [
+	    {"id": "inv_1", "customerId": "cust_1", "status": "paid", "currency": "EUR", "total": 2000},
+	    {"id": "inv_2", "customerId": "cust_1", "status": "paid", "currency": "EUR", "total": 1000}
+	  ],
+	  "hasMore": false
+	}`}
+	client := newClientWithRoundTripper(t, crt)
+
+	if _, err := client.ListInvoices("", nil); err == nil {
+		t.Errorf("expected an error for a blank customer ID")
+	}
+
+	invoices, err := client.ListInvoices("cust_1", &securionpay.InvoiceListRequest{
+		Status:     securionpay.InvoicePaid,
+		CustomerID: "ignored",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(invoices.Invoices) != 2 || invoices.Invoices[0].Status != securionpay.InvoicePaid {
+		t.Errorf("got %#v", invoices)
+	}
+
+	reqs := crt.requests()
+	if len(reqs) != 1 {
+		t.Fatalf("got %d requests, want 1", len(reqs))
+	}
+	query := reqs[0].URL.Query()
+	if got := query.Get("customerId"); got != "cust_1" {
+		t.Errorf("got customerId=%q", got)
+	}
+	if got := query.Get("status"); got != "paid" {
+		t.Errorf("got status=%q", got)
+	}
+
+	crt = &cannedRoundTripper{body: `{"id": "inv_1", "customerId": "cust_1", "status": "open", "currency": "EUR", "total": 2000}`}
+	client.SetHTTPRoundTripper(crt)
+
+	if _, err := client.FindInvoiceByID(" "); err == nil {
+		t.Errorf("expected an error for a blank invoice ID")
+	}
+	invoice, err := client.FindInvoiceByID("inv_1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if invoice.ID != "inv_1" || invoice.Status != securionpay.InvoiceOpen {
+		t.Errorf("got %#v", invoice)
+	}
+	if got, want := crt.requests()[0].URL.Path, "/invoices/inv_1"; got != want {
+		t.Errorf("got path=%q want=%q", got, want)
+	}
+}
+
+func TestMarkInvoicePaid(t *testing.T) {
+	client := newTestClient(t)
+
+	tests := [...]struct {
+		bodies   []string
+		wantErr  bool
+		wantReqs int
+	}{
+		0: {
+			bodies:   []string{`{"id": "inv_1", "status": "paid"}`},
+			wantErr:  true,
+			wantReqs: 1,
+		},
+		1: {
+			bodies:   []string{`{"id": "inv_1", "status": "draft"}`},
+			wantErr:  true,
+			wantReqs: 1,
+		},
+		2: {
+			bodies:   []string{`{"id": "inv_1", "status": "open"}`, `{"id": "inv_1", "status": "paid"}`},
+			wantReqs: 2,
+		},
+	}
+
+	for i, tt := range tests {
+		crt := &cannedRoundTripper{bodies: tt.bodies}
+		client.SetHTTPRoundTripper(crt)
+
+		invoice, err := client.MarkInvoicePaid("inv_1")
+		if got := len(crt.requests()); got != tt.wantReqs {
+			t.Errorf("#%d: got %d requests, want %d", i, got, tt.wantReqs)
+		}
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("#%d: expected an error", i)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("#%d: err: %v", i, err)
+			continue
+		}
+		if invoice.Status != securionpay.InvoicePaid {
+			t.Errorf("#%d: got status=%q", i, invoice.Status)
+		}
+		if req := crt.requests()[1]; req.Method != "POST" || req.URL.Path != "/invoices/inv_1/pay" {
+			t.Errorf("#%d: got %s %s", i, req.Method, req.URL.Path)
+		}
+	}
+}
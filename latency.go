@@ -0,0 +1,102 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// latencyTracker keeps the durations of the most recent
+// requests in a ring buffer of a fixed size.
+type latencyTracker struct {
+	sync.Mutex
+
+	durations []time.Duration
+	next      int
+	full      bool
+}
+
+func (lt *latencyTracker) record(d time.Duration) {
+	lt.Lock()
+	defer lt.Unlock()
+
+	lt.durations[lt.next] = d
+	lt.next = (lt.next + 1) % len(lt.durations)
+	if lt.next == 0 {
+		lt.full = true
+	}
+}
+
+func (lt *latencyTracker) percentiles(ps ...float64) []time.Duration {
+	lt.Lock()
+	n := lt.next
+	if lt.full {
+		n = len(lt.durations)
+	}
+	sorted := append([]time.Duration(nil), lt.durations[:n]...)
+	lt.Unlock()
+
+	results := make([]time.Duration, len(ps))
+	if n == 0 {
+		return results
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	for i, p := range ps {
+		// The nearest rank method.
+		rank := int(math.Ceil(p * float64(n)))
+		if rank < 1 {
+			rank = 1
+		}
+		results[i] = sorted[rank-1]
+	}
+	return results
+}
+
+// SetLatencyTracking makes the client keep the durations of its last
+// windowSize requests, retries included, for LatencyStats. A windowSize
+// that isn't positive, the default, disables tracking and discards
+// the durations tracked so far.
+func (c *Client) SetLatencyTracking(windowSize int) {
+	var lt *latencyTracker
+	if windowSize > 0 {
+		lt = &latencyTracker{durations: make([]time.Duration, windowSize)}
+	}
+
+	c.Lock()
+	c.latencies = lt
+	c.Unlock()
+}
+
+// LatencyStats returns the 50th, 95th and 99th percentiles of the
+// durations of the recent requests, or zeros if none were tracked.
+// See SetLatencyTracking.
+func (c *Client) LatencyStats() (p50, p95, p99 time.Duration) {
+	lt := c.latencyTracker()
+	if lt == nil {
+		return 0, 0, 0
+	}
+	stats := lt.percentiles(0.50, 0.95, 0.99)
+	return stats[0], stats[1], stats[2]
+}
+
+func (c *Client) latencyTracker() *latencyTracker {
+	c.RLock()
+	defer c.RUnlock()
+
+	return c.latencies
+}
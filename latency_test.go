@@ -0,0 +1,85 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// steppingClock tells times such that every pair of calls, which is
+// how a request is timed, is apart by the next of durations.
+type steppingClock struct {
+	mu        sync.Mutex
+	calls     int
+	durations []time.Duration
+}
+
+func (sc *steppingClock) now() time.Time {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	base := time.Date(2017, time.March, 14, 15, 9, 26, 0, time.UTC)
+	i := sc.calls
+	sc.calls++
+	if i%2 == 0 {
+		return base
+	}
+	return base.Add(sc.durations[(i/2)%len(sc.durations)])
+}
+
+func TestLatencyStats(t *testing.T) {
+	client := newTestClient(t)
+	client.SetHTTPRoundTripper(&cannedRoundTripper{body: `{"id":"` + tokenID1 + `"}`})
+
+	// 1ms to 100ms, so the percentiles are easy to tell.
+	var durations []time.Duration
+	for i := 1; i <= 100; i++ {
+		durations = append(durations, time.Duration(i)*time.Millisecond)
+	}
+	client.SetClock((&steppingClock{durations: durations}).now)
+
+	// Tracking is off by default.
+	if _, err := client.FindTokenByID(tokenID1); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if p50, p95, p99 := client.LatencyStats(); p50 != 0 || p95 != 0 || p99 != 0 {
+		t.Errorf("got %v %v %v without tracking", p50, p95, p99)
+	}
+
+	client.SetLatencyTracking(100)
+	for i := 0; i < 100; i++ {
+		if _, err := client.FindTokenByID(tokenID1); err != nil {
+			t.Fatalf("#%d: err: %v", i, err)
+		}
+	}
+
+	p50, p95, p99 := client.LatencyStats()
+	if p50 != 50*time.Millisecond || p95 != 95*time.Millisecond || p99 != 99*time.Millisecond {
+		t.Errorf("got p50=%v p95=%v p99=%v", p50, p95, p99)
+	}
+
+	// Only the most recent requests are kept.
+	client.SetLatencyTracking(2)
+	for i := 0; i < 5; i++ {
+		if _, err := client.FindTokenByID(tokenID1); err != nil {
+			t.Fatalf("#%d: err: %v", i, err)
+		}
+	}
+	if p50, _, p99 := client.LatencyStats(); p50 != 4*time.Millisecond || p99 != 5*time.Millisecond {
+		t.Errorf("got p50=%v p99=%v want 4ms and 5ms", p50, p99)
+	}
+}
@@ -0,0 +1,182 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"regexp"
+	"time"
+)
+
+// RequestLog describes a single request made to SecurionPay.
+type RequestLog struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Duration   time.Duration
+
+	// CorrelationID is the ID attached to the request's
+	// context with ContextWithCorrelationID, if any.
+	CorrelationID string
+
+	// Err is set if the request couldn't be completed
+	// for example because of a network failure.
+	Err error
+
+	// RequestBody and ResponseBody are only set, redacted,
+	// if body logging was enabled with SetBodyLogging.
+	RequestBody  []byte
+	ResponseBody []byte
+}
+
+// SetLogger sets a hook that is invoked after every request
+// made to SecurionPay. A nil logger disables logging.
+func (c *Client) SetLogger(logFn func(*RequestLog)) {
+	c.Lock()
+	c.logger = logFn
+	c.Unlock()
+}
+
+// SetBodyLogging toggles whether the redacted request and response
+// bodies are included in the logs handed to the logger.
+func (c *Client) SetBodyLogging(enable bool) {
+	c.Lock()
+	c.logBodies = enable
+	c.Unlock()
+}
+
+func (c *Client) loggingSettings() (func(*RequestLog), bool) {
+	c.RLock()
+	defer c.RUnlock()
+
+	return c.logger, c.logBodies
+}
+
+const correlationIDHeader = "X-Correlation-ID"
+
+type correlationIDKey struct{}
+
+// ContextWithCorrelationID returns a copy of ctx carrying id. Requests
+// made with that context are sent with an "X-Correlation-ID" header
+// and the ID is echoed in their RequestLog, to tie them to the inbound
+// requests that triggered them.
+func ContextWithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationIDKey{}, id)
+}
+
+// CorrelationIDFromContext returns the correlation ID
+// attached to ctx or the empty string if none was set.
+func CorrelationIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(correlationIDKey{}).(string)
+	return id
+}
+
+// DoWithCorrelationID invokes fn with a client that shares c's settings
+// but sends all of its requests with ctx and the correlation ID id.
+func (c *Client) DoWithCorrelationID(ctx context.Context, id string, fn func(*Client) error) error {
+	derived := c.derive()
+	derived.reqCtx = ContextWithCorrelationID(ctx, id)
+	return fn(derived)
+}
+
+// panRegexp matches runs of 13 to 19 digits, the lengths of card
+// numbers, that may be grouped by single spaces or dashes.
+var panRegexp = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+
+// RedactPAN masks all but the last 4 digits of every substring of s that
+// looks like a card number, e.g "4242 4242 4242 4242" becomes
+// "**** **** **** 4242", so that s can be safely logged.
+func RedactPAN(s string) string {
+	return panRegexp.ReplaceAllStringFunc(s, maskPAN)
+}
+
+// maskPAN masks all but the last 4 digits of a card
+// number, keeping any separators between the digits.
+func maskPAN(pan string) string {
+	const visibleSuffixLen = 4
+	masked := []byte(pan)
+	visible := 0
+	for i := len(masked) - 1; i >= 0; i-- {
+		if masked[i] < '0' || masked[i] > '9' {
+			continue
+		}
+		if visible < visibleSuffixLen {
+			visible++
+			continue
+		}
+		masked[i] = '*'
+	}
+	return string(masked)
+}
+
+var redactedKeys = map[string]bool{
+	"cvc": true,
+}
+
+// redactBody returns a copy of body with card numbers masked,
+// security codes dropped and any occurrence of apiKey masked.
+func redactBody(body []byte, apiKey string) []byte {
+	if len(body) == 0 {
+		return nil
+	}
+
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err == nil {
+		if blob, err := json.Marshal(redactJSONValue(v)); err == nil {
+			body = blob
+		}
+	}
+
+	body = []byte(RedactPAN(string(body)))
+	if apiKey != "" {
+		body = bytes.Replace(body, []byte(apiKey), []byte(redactAPIKey(apiKey)), -1)
+	}
+	return body
+}
+
+func redactJSONValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for key, value := range t {
+			if redactedKeys[key] {
+				delete(t, key)
+				continue
+			}
+			t[key] = redactJSONValue(value)
+		}
+		return t
+
+	case []interface{}:
+		for i, value := range t {
+			t[i] = redactJSONValue(value)
+		}
+		return t
+
+	case json.Number:
+		// Card numbers sent as bare JSON numbers can't be masked in
+		// place without producing invalid JSON, so turn them to strings.
+		if str := t.String(); panRegexp.FindString(str) == str {
+			return maskPAN(str)
+		}
+		return t
+
+	default:
+		return v
+	}
+}
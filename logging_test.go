@@ -0,0 +1,188 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/orijtech/securionpay"
+	"github.com/orijtech/securionpay/securionpaytest"
+)
+
+func TestBodyLoggingIsRedacted(t *testing.T) {
+	const apiKey = "sk_test_3tHrz5aW9vkHxwQqgTTdWSjmabcd"
+	client, err := securionpay.NewClient(apiKey)
+	if err != nil {
+		t.Fatalf("initializing client: %v", err)
+	}
+
+	cRTripper := &customRoundTripper{route: createTokenRoute}
+	client.SetHTTPRoundTripper(cRTripper)
+
+	var mu sync.Mutex
+	var logs []*securionpay.RequestLog
+	client.SetLogger(func(rl *securionpay.RequestLog) {
+		mu.Lock()
+		logs = append(logs, rl)
+		mu.Unlock()
+	})
+
+	// Firstly without body logging.
+	if _, err := client.NewToken(tokenReqByIDFromFile(tokenReqID1)); err != nil {
+		t.Fatalf("creating token: %v", err)
+	}
+	client.SetBodyLogging(true)
+	if _, err := client.NewToken(tokenReqByIDFromFile(tokenReqID1)); err != nil {
+		t.Fatalf("creating token: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(logs) != 2 {
+		t.Fatalf("got %d logs, want 2", len(logs))
+	}
+	for i, rl := range logs {
+		if rl.Method != "POST" || rl.StatusCode != http.StatusOK {
+			t.Errorf("#%d: got method=%q statusCode=%d", i, rl.Method, rl.StatusCode)
+		}
+	}
+
+	if plain := logs[0]; len(plain.RequestBody) != 0 || len(plain.ResponseBody) != 0 {
+		t.Errorf("unexpectedly logged bodies without body logging enabled")
+	}
+
+	redacted := logs[1]
+	if len(redacted.RequestBody) == 0 || len(redacted.ResponseBody) == 0 {
+		t.Fatalf("expected the request and response bodies to be logged")
+	}
+	mustNotContain := [][]byte{
+		[]byte("4242424242424242"),
+		[]byte(`"cvc"`),
+		[]byte(`"123"`),
+		[]byte(apiKey),
+	}
+	for _, body := range [][]byte{redacted.RequestBody, redacted.ResponseBody} {
+		for _, secret := range mustNotContain {
+			if bytes.Contains(body, secret) {
+				t.Errorf("logged body %s contains %s", body, secret)
+			}
+		}
+	}
+	if want := []byte("************4242"); !bytes.Contains(redacted.RequestBody, want) {
+		t.Errorf("request body %s doesn't contain the masked card number %s", redacted.RequestBody, want)
+	}
+}
+
+type headerRecordingRoundTripper struct {
+	mu      sync.Mutex
+	headers []http.Header
+
+	rt http.RoundTripper
+}
+
+func (hrt *headerRecordingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	hrt.mu.Lock()
+	hrt.headers = append(hrt.headers, req.Header)
+	hrt.mu.Unlock()
+
+	return hrt.rt.RoundTrip(req)
+}
+
+func TestDoWithCorrelationID(t *testing.T) {
+	hrt := &headerRecordingRoundTripper{rt: &customRoundTripper{route: retrieveTokenRoute}}
+	client := newClientWithRoundTripper(t, hrt)
+
+	var loggedIDs []string
+	client.SetLogger(func(rl *securionpay.RequestLog) {
+		loggedIDs = append(loggedIDs, rl.CorrelationID)
+	})
+
+	const correlationID = "req-8f1c2a"
+	err := client.DoWithCorrelationID(context.Background(), correlationID, func(cc *securionpay.Client) error {
+		_, err := cc.FindTokenByID(tokenID1)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Requests made directly with the parent client have no correlation ID.
+	if _, err := client.FindTokenByID(tokenID1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(hrt.headers) != 2 {
+		t.Fatalf("got %d requests, want 2", len(hrt.headers))
+	}
+	if got := hrt.headers[0].Get("X-Correlation-ID"); got != correlationID {
+		t.Errorf("got header=%q want=%q", got, correlationID)
+	}
+	if got := hrt.headers[1].Get("X-Correlation-ID"); got != "" {
+		t.Errorf("got header=%q want none", got)
+	}
+	if want := []string{correlationID, ""}; !reflect.DeepEqual(loggedIDs, want) {
+		t.Errorf("got logged IDs=%q want=%q", loggedIDs, want)
+	}
+}
+
+func TestRedactPAN(t *testing.T) {
+	tests := [...]struct {
+		in, want string
+	}{
+		0: {in: "", want: ""},
+		1: {in: "4242424242424242", want: "************4242"},
+		2: {in: "card 4242 4242 4242 4242 was declined", want: "card **** **** **** 4242 was declined"},
+		3: {in: "378282246310005,5555-5555-5555-4444", want: "***********0005,****-****-****-4444"},
+		4: {in: `{"number":"4000000000000002"}`, want: `{"number":"************0002"}`},
+		// Shorter numbers such as amounts and timestamps are left as is.
+		5: {in: "amount 1999 at 1415810511", want: "amount 1999 at 1415810511"},
+		6: {in: "order 4242 4242", want: "order 4242 4242"},
+	}
+
+	for i, tt := range tests {
+		if got := securionpay.RedactPAN(tt.in); got != tt.want {
+			t.Errorf("#%d: RedactPAN(%q)\ngot:  %q\nwant: %q", i, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestErrorsAreRedacted(t *testing.T) {
+	client := newTestClient(t)
+
+	tests := [...]string{
+		0: `{"error":{"type":"card_error","message":"Invalid card number 4242424242424241"}}`,
+		1: `invalid request for card 4242424242424241`,
+	}
+
+	for i, body := range tests {
+		client.SetHTTPRoundTripper(&cannedRoundTripper{statusCode: http.StatusBadRequest, body: body})
+
+		_, err := client.NewToken(securionpaytest.VisaApproved())
+		if err == nil {
+			t.Errorf("#%d: expected an error", i)
+			continue
+		}
+		if msg := err.Error(); strings.Contains(msg, "4242424242424241") || !strings.Contains(msg, "************4241") {
+			t.Errorf("#%d: got unredacted error %q", i, msg)
+		}
+	}
+}
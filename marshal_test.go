@@ -0,0 +1,82 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/orijtech/securionpay"
+)
+
+func TestRequestsJSONMarshal(t *testing.T) {
+	authorization, err := securionpay.NewAuthorization(499, securionpay.Euros, tokenID1)
+	if err != nil {
+		t.Fatalf("creating the authorization: %v", err)
+	}
+
+	tests := [...]struct {
+		v    interface{}
+		want string
+	}{
+		// Charges.
+		0: {v: &securionpay.Charge{}, want: `{"amount":"0","currency":"","description":""}`},
+		1: {v: securionpay.Charge{Card: tokenID1, Currency: securionpay.USD, AmountMinorCurrencyUnits: 1500}, want: `{"amount":"1500","currency":"USD","description":"","card":"tokenID1"}`},
+		2: {v: &securionpay.Charge{CustomerID: "", Card: "  "}, want: `{"amount":"0","currency":"","description":""}`},
+		3: {v: &securionpay.Charge{CustomerID: customerID1, CardID: "card_1", Captured: false}, want: `{"amount":"0","currency":"","description":"","card":"card_1","customerId":"customerID1"}`},
+		4: {v: authorization, want: `{"amount":"499","currency":"EUR","description":"","card":"tokenID1","captured":false}`},
+		5: {v: &securionpay.Charge{Card: &securionpay.Card{ID: "card_1"}, Metadata: map[string]interface{}{"order": 7}}, want: `{"amount":"0","currency":"","description":"","card":{"id":"card_1","objectType":"card","created":0,"first6":"","last4":"","fingerprint":"","expMonth":"0","expYear":"0","cardholderName":"","customerId":"","brand":"","type":""},"metadata":{"order":7}}`},
+
+		// Token requests.
+		6: {v: &securionpay.TokenRequest{}, want: `{"number":"","expMonth":"0","expYear":"0","cvc":"","cardholderName":""}`},
+		7: {
+			v: &securionpay.TokenRequest{
+				CardNumber: "4242424242424242", ExpiryMonth: 1, ExpiryYear: 2030, SecurityCode: "123",
+				FraudCheckData: &securionpay.FraudCheckData{IPAddress: "10.0.0.1"},
+			},
+			want: `{"number":"4242424242424242","expMonth":"1","expYear":"2030","cvc":"123","cardholderName":"","fraudCheckData":{"ipAddress":"10.0.0.1"}}`,
+		},
+
+		// Credit requests.
+		8: {v: &securionpay.CreditRequest{}, want: `{}`},
+		9: {v: &securionpay.CreditRequest{Limit: 10, CustomerID: customerID1, IncludeTotalCount: true, DescriptionContains: "x"}, want: `{"limit":10,"customerId":"customerID1","includeTotalCount":true}`},
+
+		// Add card requests.
+		10: {v: &securionpay.AddCardRequest{CustomerID: customerID1, Card: &securionpay.Card{ID: tokenID1}, SetAsDefault: true}, want: `{"customerId":"customerID1","card":{"id":"tokenID1","objectType":"card","created":0,"first6":"","last4":"","fingerprint":"","expMonth":"0","expYear":"0","cardholderName":"","customerId":"","brand":"","type":""}}`},
+		11: {v: &securionpay.AddCardRequest{}, want: `{"customerId":"","card":null}`},
+
+		// Object types.
+		12: {v: securionpay.ObjectType(""), want: `""`},
+		13: {v: securionpay.ObjectType("  charge "), want: `"charge"`},
+		14: {v: securionpay.Card{}, want: `{"objectType":"card","created":0,"first6":"","last4":"","fingerprint":"","expMonth":"0","expYear":"0","cardholderName":"","customerId":"","brand":"","type":""}`},
+		15: {v: &securionpay.Card{ObjectType: "token"}, want: `{"objectType":"token","created":0,"first6":"","last4":"","fingerprint":"","expMonth":"0","expYear":"0","cardholderName":"","customerId":"","brand":"","type":""}`},
+		// Only cards default to the "card" object type, whether or not
+		// the value marshalled is addressable.
+		16: {v: &securionpay.Customer{ID: customerID1}, want: `{"id":"customerID1"}`},
+		17: {v: securionpay.Token{ID: tokenID1}, want: `{"id":"tokenID1","created":0,"objectType":"","first6":"","last4":"","fingerprint":"","expMonth":"0","expYear":"0","brand":"","type":"","cardholderName":"","card":null}`},
+		18: {v: &securionpay.Token{ID: tokenID1}, want: `{"id":"tokenID1","created":0,"objectType":"","first6":"","last4":"","fingerprint":"","expMonth":"0","expYear":"0","brand":"","type":"","cardholderName":"","card":null}`},
+	}
+
+	for i, tt := range tests {
+		blob, err := json.Marshal(tt.v)
+		if err != nil {
+			t.Errorf("#%d: err: %v", i, err)
+			continue
+		}
+		if got := string(blob); got != tt.want {
+			t.Errorf("#%d:\ngot:  %s\nwant: %s", i, got, tt.want)
+		}
+	}
+}
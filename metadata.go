@@ -0,0 +1,97 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+var errEmptyMetadataUpdate = errors.New("expecting at least one metadata key to update")
+
+// metadataUpdate is sent as is, in particular keys with nil values
+// are encoded as JSON null which tells SecurionPay to delete them.
+type metadataUpdate struct {
+	Metadata map[string]interface{} `json:"metadata"`
+}
+
+// UpdateChargeMetadata sets the given keys of the charge's
+// metadata, leaving the others as they are. A nil value deletes its key.
+//
+// POST https://api.securionpay.com/charges/{CHARGE_ID}
+func (c *Client) UpdateChargeMetadata(chargeID string, metadata map[string]interface{}) (*ChargeResponse, error) {
+	chargeID = strings.TrimSpace(chargeID)
+	if chargeID == "" {
+		return nil, errBlankChargeID
+	}
+
+	fullURL := fmt.Sprintf("%s/%s", chargeEndpointURL, chargeID)
+	blob, err := c.updateMetadata(fullURL, metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	cResp := new(ChargeResponse)
+	if err := c.decodeResponse(blob, cResp); err != nil {
+		return nil, err
+	}
+	return cResp, nil
+}
+
+// UpdateCustomerMetadata adds, replaces or deletes the given keys of the
+// customer's metadata like UpdateChargeMetadata does for charges.
+//
+// POST https://api.securionpay.com/customers/{CUSTOMER_ID}
+func (c *Client) UpdateCustomerMetadata(customerID string, metadata map[string]interface{}) (*Customer, error) {
+	customerID = strings.TrimSpace(customerID)
+	if customerID == "" {
+		return nil, errInvalidCustomerID
+	}
+
+	fullURL := fmt.Sprintf("%s/%s", customersEndpointURL, customerID)
+	blob, err := c.updateMetadata(fullURL, metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	customer := new(Customer)
+	if err := c.decodeResponse(blob, customer); err != nil {
+		return nil, err
+	}
+	return customer, nil
+}
+
+func (c *Client) updateMetadata(fullURL string, metadata map[string]interface{}) ([]byte, error) {
+	if len(metadata) == 0 {
+		return nil, errEmptyMetadataUpdate
+	}
+	if err := validateMetadata(metadata); err != nil {
+		return nil, err
+	}
+
+	blob, err := json.Marshal(&metadataUpdate{Metadata: metadata})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("POST", fullURL, bytes.NewReader(blob))
+	if err != nil {
+		return nil, err
+	}
+	return c.doAuthThenReqAndSlurpResponse(req)
+}
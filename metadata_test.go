@@ -0,0 +1,96 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay_test
+
+import (
+	"io/ioutil"
+	"testing"
+)
+
+func TestUpdateMetadata(t *testing.T) {
+	client := newTestClient(t)
+
+	chargeBody, err := ioutil.ReadFile("testdata/chargeResp1.json")
+	if err != nil {
+		t.Fatalf("reading charge fixture: %v", err)
+	}
+
+	tests := [...]struct {
+		customer bool
+		id       string
+		metadata map[string]interface{}
+
+		wantErr  bool
+		wantURL  string
+		wantBody string
+	}{
+		0: {id: chargeID1, wantErr: true},
+		1: {id: "  ", metadata: map[string]interface{}{"note": nil}, wantErr: true},
+		2: {customer: true, id: "", metadata: map[string]interface{}{"note": nil}, wantErr: true},
+		3: {
+			id:       chargeID1,
+			metadata: map[string]interface{}{"note": nil, "orderId": "1042"},
+			wantURL:  "https://api.securionpay.com/charges/" + chargeID1,
+			wantBody: `{"metadata":{"note":null,"orderId":"1042"}}`,
+		},
+		4: {
+			customer: true,
+			id:       customerID1,
+			metadata: map[string]interface{}{"tier": nil},
+			wantURL:  "https://api.securionpay.com/customers/" + customerID1,
+			wantBody: `{"metadata":{"tier":null}}`,
+		},
+	}
+
+	for i, tt := range tests {
+		body := string(chargeBody)
+		if tt.customer {
+			body = `{"id":"` + customerID1 + `","objectType":"customer"}`
+		}
+		crt := &cannedRoundTripper{body: body}
+		client.SetHTTPRoundTripper(crt)
+
+		if tt.customer {
+			_, err = client.UpdateCustomerMetadata(tt.id, tt.metadata)
+		} else {
+			_, err = client.UpdateChargeMetadata(tt.id, tt.metadata)
+		}
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("#%d: expected an error", i)
+			}
+			if n := len(crt.requests()); n != 0 {
+				t.Errorf("#%d: got %d requests, want none", i, n)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: err: %v", i, err)
+			continue
+		}
+
+		reqs, bodies := crt.requests(), crt.requestBodies()
+		if len(reqs) != 1 {
+			t.Errorf("#%d: got %d requests, want 1", i, len(reqs))
+			continue
+		}
+		if got := reqs[0].Method + " " + reqs[0].URL.String(); got != "POST "+tt.wantURL {
+			t.Errorf("#%d: got %q want %q", i, got, "POST "+tt.wantURL)
+		}
+		if got := string(bodies[0]); got != tt.wantBody {
+			t.Errorf("#%d\ngot:  %s\nwant: %s", i, got, tt.wantBody)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// MetricsSink receives an observation for each API call made by the client.
+type MetricsSink interface {
+	// ObserveRequest is invoked once an API call, e.g
+	// "POST /charges/{id}/capture", is done including its retries.
+	// statusCode is 0 if no response was received.
+	ObserveRequest(op string, duration time.Duration, statusCode int)
+}
+
+// SetMetricsSink sets the sink that API calls are reported to.
+// A nil sink, the default, disables reporting.
+func (c *Client) SetMetricsSink(sink MetricsSink) {
+	c.Lock()
+	c.metrics = sink
+	c.Unlock()
+}
+
+func (c *Client) metricsSink() MetricsSink {
+	c.RLock()
+	defer c.RUnlock()
+
+	return c.metrics
+}
+
+// operationName describes the request by its method and path, with
+// IDs replaced by "{id}" so that calls to the same endpoint share
+// a name. Paths alternate between resources and their IDs, as in
+// "/customers/{id}/cards/{id}".
+func operationName(req *http.Request) string {
+	segments := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	for i := 1; i < len(segments); i += 2 {
+		segments[i] = "{id}"
+	}
+	return req.Method + " /" + strings.Join(segments, "/")
+}
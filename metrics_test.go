@@ -0,0 +1,156 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay_test
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/orijtech/securionpay"
+)
+
+type observation struct {
+	op         string
+	duration   time.Duration
+	statusCode int
+}
+
+type recordingSink struct {
+	mu           sync.Mutex
+	observations []observation
+}
+
+func (rs *recordingSink) ObserveRequest(op string, duration time.Duration, statusCode int) {
+	rs.mu.Lock()
+	rs.observations = append(rs.observations, observation{op, duration, statusCode})
+	rs.mu.Unlock()
+}
+
+func TestMetricsSink(t *testing.T) {
+	tests := [...]struct {
+		do          func(*securionpay.Client) error
+		statusCodes []int
+		retries     int
+		baseURL     string
+
+		want    observation
+		wantURL string
+	}{
+		0: {
+			do: func(c *securionpay.Client) error {
+				_, err := c.FindTokenByID(tokenID1)
+				return err
+			},
+			want: observation{op: "GET /tokens/{id}", duration: 5 * time.Millisecond, statusCode: http.StatusOK},
+		},
+		1: {
+			do: func(c *securionpay.Client) error {
+				_, err := c.CaptureCharge(chargeID1)
+				return err
+			},
+			statusCodes: []int{http.StatusPaymentRequired},
+			want:        observation{op: "POST /charges/{id}/capture", duration: 5 * time.Millisecond, statusCode: http.StatusPaymentRequired},
+		},
+		2: {
+			do: func(c *securionpay.Client) error {
+				_, err := c.FindCard(customerID1, "card_8P7OWXA5xiTS1ISnyZcum1KV")
+				return err
+			},
+			want: observation{op: "GET /customers/{id}/cards/{id}", duration: 5 * time.Millisecond, statusCode: http.StatusOK},
+		},
+		3: {
+			// Retries are part of the same observation.
+			do: func(c *securionpay.Client) error {
+				_, err := c.FindTokenByID(tokenID1)
+				return err
+			},
+			statusCodes: []int{http.StatusServiceUnavailable, http.StatusOK},
+			retries:     1,
+			want:        observation{op: "GET /tokens/{id}", statusCode: http.StatusOK},
+		},
+		4: {
+			// The base URL's path isn't part of the operation.
+			do: func(c *securionpay.Client) error {
+				_, err := c.ListCharges(&securionpay.ChargeListRequest{Limit: 2})
+				return err
+			},
+			baseURL: "https://proxy.example/v1",
+			want:    observation{op: "GET /charges", duration: 5 * time.Millisecond, statusCode: http.StatusOK},
+			wantURL: "https://proxy.example/v1/charges?limit=2",
+		},
+		5: {
+			do: func(c *securionpay.Client) error {
+				_, err := c.ListCards(customerID1)
+				return err
+			},
+			baseURL: "https://proxy.example/v1/",
+			want:    observation{op: "GET /customers/{id}/cards", duration: 5 * time.Millisecond, statusCode: http.StatusOK},
+			wantURL: "https://proxy.example/v1/customers/customerID1/cards?limit=100",
+		},
+	}
+
+	for i, tt := range tests {
+		crt := &cannedRoundTripper{statusCodes: tt.statusCodes, body: `{"id":"x"}`}
+		client := newClientWithRoundTripper(t, crt)
+		if tt.baseURL != "" {
+			if err := client.SetBaseURL(tt.baseURL); err != nil {
+				t.Fatalf("#%d: setting the base URL: %v", i, err)
+			}
+		}
+		if tt.retries > 0 {
+			client.SetRetryPolicy(&securionpay.RetryPolicy{MaxRetries: tt.retries})
+		} else {
+			client.SetClock((&steppingClock{durations: []time.Duration{5 * time.Millisecond}}).now)
+		}
+
+		sink := new(recordingSink)
+		client.SetMetricsSink(sink)
+		tt.do(client)
+
+		if len(sink.observations) != 1 {
+			t.Errorf("#%d: got %d observations, want 1: %v", i, len(sink.observations), sink.observations)
+			continue
+		}
+		got := sink.observations[0]
+		if tt.retries > 0 {
+			// The duration depends on the backoff.
+			got.duration = 0
+		}
+		if got != tt.want {
+			t.Errorf("#%d:\ngot:  %+v\nwant: %+v", i, got, tt.want)
+		}
+		if got, want := len(crt.requests()), tt.retries+1; got != want {
+			t.Errorf("#%d: got %d requests, want %d", i, got, want)
+		}
+		if tt.wantURL != "" {
+			if got := crt.requests()[0].URL.String(); got != tt.wantURL {
+				t.Errorf("#%d: gotURL=%q want=%q", i, got, tt.wantURL)
+			}
+		}
+	}
+
+	// Nothing is observed once the sink is unset.
+	client := newTestClient(t)
+	client.SetHTTPRoundTripper(&cannedRoundTripper{body: `{"id":"x"}`})
+	sink := new(recordingSink)
+	client.SetMetricsSink(sink)
+	client.SetMetricsSink(nil)
+	client.FindTokenByID(tokenID1)
+	if len(sink.observations) != 0 {
+		t.Errorf("got %d observations after unsetting the sink", len(sink.observations))
+	}
+}
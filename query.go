@@ -0,0 +1,161 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/orijtech/otils"
+)
+
+// listQuery encodes the list request v, a pointer to a struct, as query
+// parameters named after its JSON tags. otils.ToURLValues encodes the
+// scalar fields but not maps, slices or pointers, which are encoded here
+// instead, and should it fail, every field is encoded here. Fields that
+// are structs, such as time.Time, have no query encoding and are skipped.
+func listQuery(v interface{}) (url.Values, error) {
+	qv, err := otils.ToURLValues(v)
+	if err != nil {
+		qv = make(url.Values)
+		return qv, encodeQueryFields(qv, v, true)
+	}
+	return qv, encodeQueryFields(qv, v, false)
+}
+
+// encodeQueryFields sets the query parameters of the fields of v that
+// aren't scalars, as well as those of the scalars if withScalars is set.
+// Maps are encoded as "name[key]=value" and slices as repeated parameters.
+func encodeQueryFields(qv url.Values, v interface{}, withScalars bool) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("expecting a struct to encode as a query, got %T", v)
+	}
+
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		name, omitEmpty := queryFieldName(field)
+		if name == "" {
+			continue
+		}
+
+		fv := rv.Field(i)
+		if isScalarKind(fv.Kind()) && !withScalars {
+			continue
+		}
+		// Whatever otils produced for the field is replaced.
+		delete(qv, name)
+		for fv.Kind() == reflect.Ptr && !fv.IsNil() {
+			fv = fv.Elem()
+		}
+		if fv.Kind() == reflect.Ptr || fv.Kind() == reflect.Struct || (omitEmpty && fv.IsZero()) {
+			continue
+		}
+
+		switch fv.Kind() {
+		case reflect.Map:
+			keys := fv.MapKeys()
+			sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j]) })
+			for _, key := range keys {
+				str, err := queryScalar(fv.MapIndex(key))
+				if err != nil {
+					return fmt.Errorf("%s[%v]: %v", name, key, err)
+				}
+				qv.Set(fmt.Sprintf("%s[%v]", name, key), str)
+			}
+		case reflect.Slice, reflect.Array:
+			for j := 0; j < fv.Len(); j++ {
+				str, err := queryScalar(fv.Index(j))
+				if err != nil {
+					return fmt.Errorf("%s: %v", name, err)
+				}
+				qv.Add(name, str)
+			}
+		default:
+			str, err := queryScalar(fv)
+			if err != nil {
+				return fmt.Errorf("%s: %v", name, err)
+			}
+			qv.Set(name, str)
+		}
+	}
+	return nil
+}
+
+// queryFieldName returns the name that the field is encoded with,
+// or the empty string if it isn't encoded, and whether it has the
+// "omitempty" option.
+func queryFieldName(field reflect.StructField) (string, bool) {
+	if field.PkgPath != "" {
+		return "", false
+	}
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	parts := strings.Split(tag, ",")
+	name := parts[0]
+	if name == "" {
+		name = field.Name
+	}
+	omitEmpty := false
+	for _, opt := range parts[1:] {
+		if opt == "omitempty" {
+			omitEmpty = true
+		}
+	}
+	return name, omitEmpty
+}
+
+func isScalarKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Map, reflect.Slice, reflect.Array, reflect.Ptr, reflect.Struct, reflect.Interface:
+		return false
+	}
+	return true
+}
+
+func queryScalar(v reflect.Value) (string, error) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return "", nil
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64), nil
+	}
+	return "", fmt.Errorf("can't encode a %s as a query parameter", v.Type())
+}
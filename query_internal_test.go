@@ -0,0 +1,85 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// unmarshalable is encoded as a string in queries
+// but makes encoding it as JSON, as otils does, fail.
+type unmarshalable string
+
+func (u unmarshalable) MarshalJSON() ([]byte, error) {
+	return nil, errors.New("unmarshalable")
+}
+
+func TestListQuery(t *testing.T) {
+	limit := 10
+	type composite struct {
+		Limit    *int              `json:"limit,omitempty"`
+		Cursor   *string           `json:"startingAfterId,omitempty"`
+		IDs      []string          `json:"ids,omitempty"`
+		Metadata map[string]string `json:"metadata,omitempty"`
+		Created  time.Time         `json:"created"`
+		Internal string            `json:"-"`
+	}
+	type failing struct {
+		Limit   int               `json:"limit,omitempty"`
+		Status  unmarshalable     `json:"status"`
+		Tags    []string          `json:"tags,omitempty"`
+		Filters map[string]string `json:"filters,omitempty"`
+	}
+
+	tests := [...]struct {
+		v         interface{}
+		wantQuery string
+	}{
+		0: {
+			v: &composite{
+				Limit:    &limit,
+				IDs:      []string{"char_1", "char_2"},
+				Metadata: map[string]string{"orderId": "42", "channel": "web"},
+				Created:  time.Date(2017, time.March, 14, 0, 0, 0, 0, time.UTC),
+				Internal: "secret",
+			},
+			wantQuery: "ids=char_1&ids=char_2&limit=10&metadata%5Bchannel%5D=web&metadata%5BorderId%5D=42",
+		},
+		1: {v: &composite{}, wantQuery: ""},
+		2: {
+			// otils fails on the field, so every field is encoded here.
+			v: &failing{
+				Limit:   5,
+				Status:  "paid",
+				Tags:    []string{"a"},
+				Filters: map[string]string{"currency": "EUR"},
+			},
+			wantQuery: "filters%5Bcurrency%5D=EUR&limit=5&status=paid&tags=a",
+		},
+	}
+
+	for i, tt := range tests {
+		qv, err := listQuery(tt.v)
+		if err != nil {
+			t.Errorf("#%d: err: %v", i, err)
+			continue
+		}
+		if got := qv.Encode(); got != tt.wantQuery {
+			t.Errorf("#%d:\ngotQuery:  %q\nwantQuery: %q", i, got, tt.wantQuery)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay_test
+
+import (
+	"testing"
+
+	"github.com/orijtech/securionpay"
+)
+
+func TestListRequestsQuery(t *testing.T) {
+	client := newTestClient(t)
+
+	tests := [...]struct {
+		list      func() error
+		wantQuery string
+	}{
+		0: {
+			list: func() error {
+				_, err := client.ListCharges(&securionpay.ChargeListRequest{
+					Limit: 25, CustomerID: customerID1,
+					CreatedAfter: 1415810511, CreatedOnOrBefore: 1515810511,
+					StartingAfterId: "char_a", EndingBeforeId: "char_b", IncludeTotalCount: true,
+				})
+				return err
+			},
+			wantQuery: "customerId=customerID1&endingBeforeId=char_b&gt=1415810511&includeTotalCount=true&limit=25&lte=1515810511&startingAfterId=char_a",
+		},
+		1: {
+			list: func() error {
+				_, err := client.ListCredits(&securionpay.CreditRequest{
+					Limit: 5, CustomerID: customerID1,
+					CreatedOnOrAfter: 1415810511, CreatedBefore: 1515810511,
+					StartingAfterId: "cred_a", EndingBeforeId: "cred_b", IncludeTotalCount: true,
+					DescriptionContains: "refund",
+				})
+				return err
+			},
+			wantQuery: "customerId=customerID1&endingBeforeId=cred_b&gte=1415810511&includeTotalCount=true&limit=5&lt=1515810511&startingAfterId=cred_a",
+		},
+		2: {
+			list: func() error {
+				_, err := client.ListCoupons(&securionpay.CouponListRequest{
+					Limit: 7, CreatedAfter: 1415810511, CreatedBefore: 1515810511,
+					StartingAfterId: "coup_a", EndingBeforeId: "coup_b", IncludeTotalCount: true,
+				})
+				return err
+			},
+			wantQuery: "endingBeforeId=coup_b&gt=1415810511&includeTotalCount=true&limit=7&lt=1515810511&startingAfterId=coup_a",
+		},
+		3: {
+			list: func() error {
+				_, err := client.ListAllSubscriptions(&securionpay.SubscriptionListRequest{
+					Limit: 3, CustomerID: customerID1, Status: securionpay.SubscriptionPastDue,
+					CreatedOnOrAfter: 1415810511, CreatedOnOrBefore: 1515810511,
+					StartingAfterId: "sub_a", EndingBeforeId: "sub_b", IncludeTotalCount: true,
+				})
+				return err
+			},
+			wantQuery: "customerId=customerID1&endingBeforeId=sub_b&gte=1415810511&includeTotalCount=true&limit=3&lte=1515810511&startingAfterId=sub_a&status=past_due",
+		},
+		4: {
+			list: func() error {
+				_, err := client.ListBalanceTransactions(&securionpay.BalanceTxListRequest{
+					Limit: 50, Type: securionpay.BalanceTxRefund,
+					CreatedAfter: 1415810511, CreatedBefore: 1515810511,
+					StartingAfterId: "txn_a", EndingBeforeId: "txn_b", IncludeTotalCount: true,
+				})
+				return err
+			},
+			wantQuery: "endingBeforeId=txn_b&gt=1415810511&includeTotalCount=true&limit=50&lt=1515810511&startingAfterId=txn_a&type=refund",
+		},
+		5: {
+			list: func() error {
+				_, err := client.ListEvents((&securionpay.EventListRequest{
+					Limit: 100, CreatedAfter: 1415810511, CreatedOnOrBefore: 1515810511,
+					StartingAfterId: "evt_a", EndingBeforeId: "evt_b", IncludeTotalCount: true,
+				}).TypeCharges())
+				return err
+			},
+			wantQuery: "endingBeforeId=evt_b&gt=1415810511&includeTotalCount=true&limit=100&lte=1515810511&startingAfterId=evt_a",
+		},
+		6: {
+			list: func() error {
+				_, err := client.ListInvoices(customerID1, &securionpay.InvoiceListRequest{
+					Limit: 12, Status: securionpay.InvoiceOpen,
+					CreatedOnOrAfter: 1415810511, CreatedBefore: 1515810511,
+					StartingAfterId: "inv_a", EndingBeforeId: "inv_b", IncludeTotalCount: true,
+				})
+				return err
+			},
+			wantQuery: "customerId=customerID1&endingBeforeId=inv_b&gte=1415810511&includeTotalCount=true&limit=12&lt=1515810511&startingAfterId=inv_a&status=open",
+		},
+	}
+
+	for i, tt := range tests {
+		crt := &cannedRoundTripper{body: `{"list":[],"hasMore":false}`}
+		client.SetHTTPRoundTripper(crt)
+
+		if err := tt.list(); err != nil {
+			t.Errorf("#%d: unexpected err: %v", i, err)
+			continue
+		}
+		reqs := crt.requests()
+		if len(reqs) != 1 {
+			t.Errorf("#%d: got %d requests, want 1", i, len(reqs))
+			continue
+		}
+		if got := reqs[0].URL.RawQuery; got != tt.wantQuery {
+			t.Errorf("#%d:\ngot:  %s\nwant: %s", i, got, tt.wantQuery)
+		}
+	}
+}
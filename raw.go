@@ -0,0 +1,104 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+var (
+	errInvalidRawPath = errors.New("expecting a path such as \"/charges\" relative to the base URL")
+	errNonJSONRaw     = errors.New("the response isn't valid JSON")
+)
+
+// GetRaw gets path, relative to the base URL, with query and returns
+// the response's JSON as is, for endpoints that aren't wrapped yet.
+//
+// GET https://api.securionpay.com/{PATH}
+func (c *Client) GetRaw(path string, query url.Values) (json.RawMessage, error) {
+	fullURL, err := rawURL(path, query)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("GET", fullURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.doRaw(req)
+}
+
+// PostRaw is like GetRaw but posts body, encoded as JSON, to path.
+//
+// POST https://api.securionpay.com/{PATH}
+func (c *Client) PostRaw(path string, body interface{}) (json.RawMessage, error) {
+	return c.PostRawIdempotent(path, body, "")
+}
+
+// PostRawIdempotent is like PostRaw but sends idempotencyKey, if set,
+// as the "Idempotency-Key" header so that SecurionPay acts on the post
+// only once even if it is retried, e.g by the client's RetryPolicy.
+func (c *Client) PostRawIdempotent(path string, body interface{}, idempotencyKey string) (json.RawMessage, error) {
+	fullURL, err := rawURL(path, nil)
+	if err != nil {
+		return nil, err
+	}
+	blob, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("POST", fullURL, bytes.NewReader(blob))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if key := strings.TrimSpace(idempotencyKey); key != "" {
+		req.Header.Set(idempotencyKeyHeader, key)
+	}
+	return c.doRaw(req)
+}
+
+// rawURL returns the URL of path, which must be relative to the
+// base URL and mustn't carry its own query or escape the base URL.
+func rawURL(path string, query url.Values) (string, error) {
+	path = strings.TrimSpace(path)
+	if !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") || strings.Contains(path, "..") {
+		return "", errInvalidRawPath
+	}
+	if strings.ContainsAny(path, "?#") {
+		return "", errInvalidRawPath
+	}
+
+	fullURL := "https://" + defaultHost + path
+	if len(query) > 0 {
+		fullURL += "?" + query.Encode()
+	}
+	return fullURL, nil
+}
+
+func (c *Client) doRaw(req *http.Request) (json.RawMessage, error) {
+	blob, err := c.doAuthThenReqAndSlurpResponse(req)
+	if err != nil {
+		return nil, err
+	}
+	if !json.Valid(blob) {
+		return nil, errNonJSONRaw
+	}
+	return json.RawMessage(blob), nil
+}
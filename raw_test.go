@@ -0,0 +1,177 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay_test
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/orijtech/securionpay"
+)
+
+func TestGetRaw(t *testing.T) {
+	client := newTestClient(t)
+
+	tests := [...]struct {
+		path  string
+		query url.Values
+		body  string
+
+		wantURL string
+		wantErr bool
+	}{
+		0: {path: "/payment-methods", body: `{"list":[],"hasMore":false}`, wantURL: "https://api.securionpay.com/payment-methods"},
+		1: {
+			path:    "/payment-methods",
+			query:   url.Values{"limit": {"10"}, "type": {"alipay"}},
+			body:    `{"list":[]}`,
+			wantURL: "https://api.securionpay.com/payment-methods?limit=10&type=alipay",
+		},
+		2: {path: "payment-methods", wantErr: true},
+		3: {path: "//evil.example.com/steal", wantErr: true},
+		4: {path: "/charges/../tokens", wantErr: true},
+		5: {path: "/charges?limit=1", wantErr: true},
+		6: {path: "/charges", body: "not json", wantURL: "https://api.securionpay.com/charges", wantErr: true},
+	}
+
+	for i, tt := range tests {
+		crt := &cannedRoundTripper{body: tt.body}
+		client.SetHTTPRoundTripper(crt)
+
+		raw, err := client.GetRaw(tt.path, tt.query)
+		reqs := crt.requests()
+		if tt.wantURL == "" && len(reqs) != 0 {
+			t.Errorf("#%d: got %d requests, want none", i, len(reqs))
+		}
+		if tt.wantURL != "" {
+			if len(reqs) != 1 {
+				t.Errorf("#%d: got %d requests, want 1", i, len(reqs))
+				continue
+			}
+			if got := reqs[0].URL.String(); got != tt.wantURL {
+				t.Errorf("#%d: got URL=%q want=%q", i, got, tt.wantURL)
+			}
+			if reqs[0].Method != "GET" {
+				t.Errorf("#%d: got method=%q", i, reqs[0].Method)
+			}
+		}
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("#%d: expected a non-nil error", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: err: %v", i, err)
+			continue
+		}
+		if got := string(raw); got != tt.body {
+			t.Errorf("#%d: got=%s want=%s", i, got, tt.body)
+		}
+	}
+}
+
+func TestPostRaw(t *testing.T) {
+	client := newTestClient(t)
+
+	tests := [...]struct {
+		path           string
+		body           interface{}
+		idempotencyKey string
+		statusCodes    []int
+
+		wantReqs int
+		wantBody string
+		wantErr  bool
+	}{
+		0: {
+			path:     "/subscriptions/sub_1/pause",
+			body:     map[string]interface{}{"resumeAt": 1500000000},
+			wantReqs: 1,
+			wantBody: `{"resumeAt":1500000000}`,
+		},
+		1: {
+			// Retries resend the body and the idempotency key.
+			path:           "/subscriptions/sub_1/pause",
+			body:           map[string]string{"reason": "vacation"},
+			idempotencyKey: "pause-sub_1",
+			statusCodes:    []int{http.StatusServiceUnavailable, http.StatusOK},
+			wantReqs:       2,
+			wantBody:       `{"reason":"vacation"}`,
+		},
+		2: {
+			path:        "/subscriptions/sub_1/pause",
+			body:        nil,
+			statusCodes: []int{http.StatusBadRequest},
+			wantReqs:    1,
+			wantBody:    "null",
+			wantErr:     true,
+		},
+		3: {path: "subscriptions", wantErr: true},
+		4: {path: "/subscriptions", body: func() {}, wantErr: true},
+	}
+
+	for i, tt := range tests {
+		crt := &cannedRoundTripper{
+			statusCodes: tt.statusCodes,
+			header:      http.Header{"Retry-After": {"0"}},
+			body:        `{"id":"sub_1","status":"paused"}`,
+		}
+		if len(tt.statusCodes) > 0 && tt.statusCodes[0] == http.StatusBadRequest {
+			crt.body = `{"error":{"type":"invalid_request","message":"Missing resumeAt","param":"resumeAt"}}`
+		}
+		client.SetHTTPRoundTripper(crt)
+		client.SetRetryPolicy(&securionpay.RetryPolicy{MaxRetries: 1})
+
+		raw, err := client.PostRawIdempotent(tt.path, tt.body, tt.idempotencyKey)
+		reqs, bodies := crt.requests(), crt.requestBodies()
+		if len(reqs) != tt.wantReqs {
+			t.Errorf("#%d: got %d requests, want %d", i, len(reqs), tt.wantReqs)
+			continue
+		}
+		for j, req := range reqs {
+			if req.Method != "POST" || req.URL.Path != tt.path {
+				t.Errorf("#%d: req #%d: got %s %s", i, j, req.Method, req.URL.Path)
+			}
+			if got := req.Header.Get("Content-Type"); got != "application/json" {
+				t.Errorf("#%d: req #%d: got Content-Type=%q", i, j, got)
+			}
+			if got := req.Header.Get("Idempotency-Key"); got != tt.idempotencyKey {
+				t.Errorf("#%d: req #%d: got Idempotency-Key=%q want=%q", i, j, got, tt.idempotencyKey)
+			}
+			if got := string(bodies[j]); got != tt.wantBody {
+				t.Errorf("#%d: req #%d: got body=%s want=%s", i, j, got, tt.wantBody)
+			}
+		}
+
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("#%d: expected a non-nil error", i)
+			}
+			if apiErr, ok := err.(*securionpay.APIError); ok && !apiErr.IsParam("resumeAt") {
+				t.Errorf("#%d: got param=%q", i, apiErr.ParamName)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: err: %v", i, err)
+			continue
+		}
+		if got, want := string(raw), `{"id":"sub_1","status":"paused"}`; got != want {
+			t.Errorf("#%d: got=%s want=%s", i, got, want)
+		}
+	}
+}
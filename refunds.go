@@ -0,0 +1,251 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const refundsEndpointURL = "https://api.securionpay.com/refunds"
+
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// SecurionPay's limits on the metadata attached to objects.
+const (
+	maxMetadataKeys        = 50
+	maxMetadataKeyLength   = 40
+	maxMetadataValueLength = 500
+)
+
+var (
+	errBlankRefundRequest = errors.New("expecting a non-blank refund request")
+	errNegativeRefund     = errors.New("expecting a non-negative refund amount")
+
+	errTooManyMetadataKeys = fmt.Errorf("metadata can have at most %d keys", maxMetadataKeys)
+	errBlankMetadataKey    = errors.New("metadata keys must be non-blank")
+)
+
+type RefundRequest struct {
+	ChargeID string `json:"chargeId"`
+
+	// AmountMinorCurrencyUnits is the amount to refund in minor
+	// amounts of currency. If 0, the charge is fully refunded.
+	AmountMinorCurrencyUnits int `json:"amount,omitempty"`
+
+	Reason RefundReason `json:"reason,omitempty"`
+
+	// Metadata is attached to the created refund, for example
+	// to record who requested the refund and why, for audits.
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+
+	// IdempotencyKey, if set, is sent as the "Idempotency-Key" header
+	// so that SecurionPay makes the refund only once however many
+	// times the request is sent, e.g when retrying after a timeout.
+	IdempotencyKey string `json:"-"`
+}
+
+func (rr *RefundRequest) Validate() error {
+	if rr == nil {
+		return errBlankRefundRequest
+	}
+	if strings.TrimSpace(rr.ChargeID) == "" {
+		return errBlankChargeID
+	}
+	if rr.AmountMinorCurrencyUnits < 0 {
+		return errNegativeRefund
+	}
+	return validateMetadata(rr.Metadata)
+}
+
+// validateMetadata checks metadata against SecurionPay's limits on
+// the number of keys and the lengths of keys and values. Values that
+// aren't strings are checked by the length of their JSON encoding.
+func validateMetadata(metadata map[string]interface{}) error {
+	if len(metadata) > maxMetadataKeys {
+		return errTooManyMetadataKeys
+	}
+	for key, value := range metadata {
+		if strings.TrimSpace(key) == "" {
+			return errBlankMetadataKey
+		}
+		if utf8.RuneCountInString(key) > maxMetadataKeyLength {
+			return fmt.Errorf("metadata key %q is longer than %d characters", key, maxMetadataKeyLength)
+		}
+
+		str, ok := value.(string)
+		if !ok {
+			blob, err := json.Marshal(value)
+			if err != nil {
+				return fmt.Errorf("metadata key %q: %v", key, err)
+			}
+			str = string(blob)
+		}
+		if utf8.RuneCountInString(str) > maxMetadataValueLength {
+			return fmt.Errorf("metadata value for key %q is longer than %d characters", key, maxMetadataValueLength)
+		}
+	}
+	return nil
+}
+
+// RefundCharge refunds a charge either fully or partially. The refund's
+// Metadata is persisted by SecurionPay and is returned in the result.
+//
+// POST https://api.securionpay.com/refunds
+func (c *Client) RefundCharge(rr *RefundRequest) (*Refund, error) {
+	return c.refundCharge(context.Background(), rr)
+}
+
+func (c *Client) refundCharge(ctx context.Context, rr *RefundRequest) (*Refund, error) {
+	if err := rr.Validate(); err != nil {
+		return nil, err
+	}
+
+	rreq := *rr
+	rreq.ChargeID = strings.TrimSpace(rr.ChargeID)
+	blob, err := json.Marshal(&rreq)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", refundsEndpointURL, bytes.NewReader(blob))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if key := strings.TrimSpace(rr.IdempotencyKey); key != "" {
+		req.Header.Set(idempotencyKeyHeader, key)
+	}
+
+	blob, err = c.doAuthThenReqAndSlurpResponse(req)
+	if err != nil {
+		return nil, err
+	}
+
+	refund := new(Refund)
+	if err := c.decodeResponse(blob, refund); err != nil {
+		return nil, err
+	}
+	return refund, nil
+}
+
+// RefundCharges makes the refunds of reqs with at most concurrency
+// of them in flight, returning a refund or an error per request in
+// the order of reqs. Requests without an IdempotencyKey get one unique
+// to this call and their position in reqs. Once a refund is rate
+// limited, no refunds are started until its Retry-After elapses.
+func (c *Client) RefundCharges(ctx context.Context, reqs []*RefundRequest, concurrency int) ([]*Refund, []error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	refunds := make([]*Refund, len(reqs))
+	errs := make([]error, len(reqs))
+	gate := &rateLimitGate{now: c.now}
+
+	nonce, err := newBatchNonce()
+	if err != nil {
+		for i := range errs {
+			errs[i] = err
+		}
+		return refunds, errs
+	}
+
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency && i < len(reqs); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				key := fmt.Sprintf("refund-%s-%d", nonce, i)
+				refunds[i], errs[i] = c.refundInBatch(ctx, gate, reqs[i], key)
+			}
+		}()
+	}
+	for i := range reqs {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+
+	return refunds, errs
+}
+
+// refundInBatch makes the refund with defaultKey as its
+// idempotency key unless the request has its own.
+func (c *Client) refundInBatch(ctx context.Context, gate *rateLimitGate, rr *RefundRequest, defaultKey string) (*Refund, error) {
+	if err := rr.Validate(); err != nil {
+		return nil, err
+	}
+	if err := gate.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	rreq := *rr
+	if strings.TrimSpace(rreq.IdempotencyKey) == "" {
+		rreq.IdempotencyKey = defaultKey
+	}
+	refund, err := c.refundCharge(ctx, &rreq)
+	if rateLimited, ok := err.(*ErrRateLimited); ok {
+		gate.pause(rateLimited.RetryAfter)
+	}
+	return refund, err
+}
+
+// newBatchNonce returns a random value that makes the
+// idempotency keys derived for a batch unique to it.
+func newBatchNonce() (string, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(nonce), nil
+}
+
+// rateLimitGate holds back the work of concurrent workers
+// until a rate limit that one of them ran into has elapsed.
+type rateLimitGate struct {
+	now func() time.Time
+
+	mu    sync.Mutex
+	until time.Time
+}
+
+func (g *rateLimitGate) pause(d time.Duration) {
+	until := g.now().Add(d)
+	g.mu.Lock()
+	if until.After(g.until) {
+		g.until = until
+	}
+	g.mu.Unlock()
+}
+
+func (g *rateLimitGate) wait(ctx context.Context) error {
+	g.mu.Lock()
+	until := g.until
+	g.mu.Unlock()
+	return sleepContext(ctx, until.Sub(g.now()))
+}
@@ -0,0 +1,290 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/orijtech/securionpay"
+)
+
+func TestRefundCharge(t *testing.T) {
+	client := newTestClient(t)
+
+	refundBody, err := ioutil.ReadFile("testdata/refund1.json")
+	if err != nil {
+		t.Fatalf("reading refund fixture: %v", err)
+	}
+
+	tooManyKeys := make(map[string]interface{})
+	for i := 0; i <= 50; i++ {
+		tooManyKeys[strings.Repeat("k", i+1)] = i
+	}
+
+	tests := [...]struct {
+		rr       *securionpay.RefundRequest
+		wantErr  bool
+		wantBody string
+	}{
+		0: {rr: nil, wantErr: true},
+		1: {rr: &securionpay.RefundRequest{ChargeID: "  "}, wantErr: true},
+		2: {rr: &securionpay.RefundRequest{ChargeID: chargeID1, AmountMinorCurrencyUnits: -1}, wantErr: true},
+		3: {
+			rr:      &securionpay.RefundRequest{ChargeID: chargeID1, Metadata: tooManyKeys},
+			wantErr: true,
+		},
+		4: {
+			rr: &securionpay.RefundRequest{
+				ChargeID: chargeID1,
+				Metadata: map[string]interface{}{strings.Repeat("k", 41): "v"},
+			},
+			wantErr: true,
+		},
+		5: {
+			rr: &securionpay.RefundRequest{
+				ChargeID: chargeID1,
+				Metadata: map[string]interface{}{"note": strings.Repeat("v", 501)},
+			},
+			wantErr: true,
+		},
+		6: {
+			rr: &securionpay.RefundRequest{
+				ChargeID: chargeID1,
+				Metadata: map[string]interface{}{" ": "v"},
+			},
+			wantErr: true,
+		},
+		7: {
+			rr: &securionpay.RefundRequest{
+				ChargeID: " " + chargeID1 + " ",
+				Reason:   securionpay.RefundReasonRequestedByCustomer,
+
+				AmountMinorCurrencyUnits: 299,
+
+				Metadata: map[string]interface{}{
+					"approvedBy": "jane@example.com",
+					"ticket":     "SUP-1021",
+				},
+			},
+			wantBody: `{"chargeId":"char_ORVCrwOrTkGsDwM3H50OIW7Q","amount":299,"reason":"requested_by_customer","metadata":{"approvedBy":"jane@example.com","ticket":"SUP-1021"}}`,
+		},
+	}
+
+	for i, tt := range tests {
+		crt := &cannedRoundTripper{body: string(refundBody)}
+		client.SetHTTPRoundTripper(crt)
+
+		refund, err := client.RefundCharge(tt.rr)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("#%d: expected an error", i)
+			}
+			if n := len(crt.requests()); n != 0 {
+				t.Errorf("#%d: got %d requests despite invalid input", i, n)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("#%d: err: %v", i, err)
+			continue
+		}
+
+		reqs := crt.requests()
+		if len(reqs) != 1 {
+			t.Errorf("#%d: got %d requests, want 1", i, len(reqs))
+			continue
+		}
+		if got, want := reqs[0].URL.String(), "https://api.securionpay.com/refunds"; got != want {
+			t.Errorf("#%d: gotURL=%q want=%q", i, got, want)
+		}
+		if got := string(crt.requestBodies()[0]); got != tt.wantBody {
+			t.Errorf("#%d:\ngotBody:  %s\nwantBody: %s", i, got, tt.wantBody)
+		}
+
+		if refund.ChargeID != chargeID1 || refund.AmountMinorCurrencyUnits != 299 {
+			t.Errorf("#%d: got refund %#v", i, refund)
+		}
+		if got := refund.Metadata["approvedBy"]; got != "jane@example.com" {
+			t.Errorf("#%d: metadata approvedBy=%v", i, got)
+		}
+	}
+}
+
+// refundEchoRoundTripper responds to refund requests with a refund of
+// the requested charge, failing those of charges prefixed "char_fail".
+type refundEchoRoundTripper struct {
+	mu              sync.Mutex
+	inFlight        int
+	maxInFlight     int
+	idempotencyKeys map[string]string
+}
+
+func (rt *refundEchoRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	rr := new(securionpay.RefundRequest)
+	if err := json.NewDecoder(req.Body).Decode(rr); err != nil {
+		return makeResp(err.Error(), http.StatusBadRequest), nil
+	}
+
+	rt.mu.Lock()
+	rt.inFlight++
+	if rt.inFlight > rt.maxInFlight {
+		rt.maxInFlight = rt.inFlight
+	}
+	if rt.idempotencyKeys == nil {
+		rt.idempotencyKeys = make(map[string]string)
+	}
+	rt.idempotencyKeys[rr.ChargeID] = req.Header.Get("Idempotency-Key")
+	rt.mu.Unlock()
+
+	time.Sleep(5 * time.Millisecond)
+
+	rt.mu.Lock()
+	rt.inFlight--
+	rt.mu.Unlock()
+
+	if strings.HasPrefix(rr.ChargeID, "char_fail") {
+		return makeResp(`{"error":{"type":"invalid_request","message":"Charge already refunded"}}`, http.StatusBadRequest), nil
+	}
+	resp := makeResp("200 OK", http.StatusOK)
+	resp.Body = ioutil.NopCloser(strings.NewReader(fmt.Sprintf(`{"id":"re_%s","charge":%q,"amount":%d}`, rr.ChargeID, rr.ChargeID, rr.AmountMinorCurrencyUnits)))
+	return resp, nil
+}
+
+func TestRefundCharges(t *testing.T) {
+	var reqs []*securionpay.RefundRequest
+	for i := 0; i < 20; i++ {
+		reqs = append(reqs, &securionpay.RefundRequest{ChargeID: fmt.Sprintf("char_%02d", i), AmountMinorCurrencyUnits: i})
+	}
+	reqs[3] = &securionpay.RefundRequest{ChargeID: "char_fail"}
+	reqs[7] = &securionpay.RefundRequest{ChargeID: "  "}
+	reqs[9].IdempotencyKey = "caller-key"
+
+	rt := new(refundEchoRoundTripper)
+	client := newClientWithRoundTripper(t, rt)
+
+	refunds, errs := client.RefundCharges(context.Background(), reqs, 4)
+	if len(refunds) != len(reqs) || len(errs) != len(reqs) {
+		t.Fatalf("got %d refunds and %d errors, want %d of each", len(refunds), len(errs), len(reqs))
+	}
+	for i, rr := range reqs {
+		if i == 3 || i == 7 {
+			if errs[i] == nil || refunds[i] != nil {
+				t.Errorf("#%d: got refund=%#v err=%v, want just an error", i, refunds[i], errs[i])
+			}
+			continue
+		}
+		if errs[i] != nil {
+			t.Errorf("#%d: err: %v", i, errs[i])
+			continue
+		}
+		if refunds[i].ChargeID != rr.ChargeID {
+			t.Errorf("#%d: got refund of %q want %q", i, refunds[i].ChargeID, rr.ChargeID)
+		}
+	}
+
+	if rt.maxInFlight > 4 {
+		t.Errorf("got %d refunds in flight, want at most 4", rt.maxInFlight)
+	}
+	if got := rt.idempotencyKeys["char_09"]; got != "caller-key" {
+		t.Errorf("got idempotency key %q, want the caller's", got)
+	}
+	if rt.idempotencyKeys["char_00"] == "" || rt.idempotencyKeys["char_00"] == rt.idempotencyKeys["char_01"] {
+		t.Errorf("expecting distinct idempotency keys, got %v", rt.idempotencyKeys)
+	}
+
+	// Running the batch again only sends the same idempotency
+	// keys for the requests that set their own.
+	firstKeys := rt.idempotencyKeys
+	rt.idempotencyKeys = nil
+	client.RefundCharges(context.Background(), reqs, 2)
+	for chargeID, key := range firstKeys {
+		if same := rt.idempotencyKeys[chargeID] == key; same != (chargeID == "char_09") {
+			t.Errorf("%s: got idempotency key %q then %q", chargeID, key, rt.idempotencyKeys[chargeID])
+		}
+	}
+
+	// Refunds aren't started once the context is done.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	refunds, errs = client.RefundCharges(ctx, reqs[:2], 2)
+	for i := range refunds {
+		if refunds[i] != nil || errs[i] != context.Canceled {
+			t.Errorf("#%d: got refund=%#v err=%v, want context.Canceled", i, refunds[i], errs[i])
+		}
+	}
+}
+
+func TestRefundChargesPausesWhenRateLimited(t *testing.T) {
+	crt := &cannedRoundTripper{
+		statusCodes: []int{http.StatusTooManyRequests, http.StatusOK},
+		header:      http.Header{"Retry-After": {"1"}},
+		body:        `{"id":"re_1"}`,
+	}
+	client := newClientWithRoundTripper(t, crt)
+
+	reqs := []*securionpay.RefundRequest{{ChargeID: "char_1"}, {ChargeID: "char_2"}}
+	start := time.Now()
+	_, errs := client.RefundCharges(context.Background(), reqs, 1)
+	if _, ok := errs[0].(*securionpay.ErrRateLimited); !ok {
+		t.Errorf("got err=%#v, want *ErrRateLimited", errs[0])
+	}
+	if errs[1] != nil {
+		t.Errorf("err: %v", errs[1])
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("the second refund started after %v, want at least the 1s Retry-After", elapsed)
+	}
+}
+
+func TestRefundChargesIdenticalPartialRefunds(t *testing.T) {
+	crt := &cannedRoundTripper{body: `{"id":"re_1","charge":"char_1","amount":500}`}
+	client := newClientWithRoundTripper(t, crt)
+
+	partial := func() *securionpay.RefundRequest {
+		return &securionpay.RefundRequest{ChargeID: "char_1", AmountMinorCurrencyUnits: 500, Reason: securionpay.RefundReasonRequestedByCustomer}
+	}
+	seen := make(map[string]bool)
+	for batch := 0; batch < 2; batch++ {
+		_, errs := client.RefundCharges(context.Background(), []*securionpay.RefundRequest{partial(), partial()}, 2)
+		for i, err := range errs {
+			if err != nil {
+				t.Errorf("batch #%d: #%d: err: %v", batch, i, err)
+			}
+		}
+	}
+
+	reqs := crt.requests()
+	if len(reqs) != 4 {
+		t.Fatalf("got %d requests, want 4", len(reqs))
+	}
+	// Each refund, within a batch and across batches, is made rather
+	// than replayed as an identical one made before it.
+	for i, req := range reqs {
+		key := req.Header.Get("Idempotency-Key")
+		if key == "" || seen[key] {
+			t.Errorf("#%d: got idempotency key %q, want a distinct one", i, key)
+		}
+		seen[key] = true
+	}
+}
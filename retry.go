@@ -0,0 +1,305 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryPolicy controls how requests that failed because SecurionPay
+// was temporarily unavailable (503) or rate limited them (429) are retried.
+// Requests that would create objects, such as charges, are only retried
+// after a 503 if they have an idempotency key.
+type RetryPolicy struct {
+	// MaxRetries is the number of retries after the first attempt.
+	MaxRetries int
+
+	// BaseBackoff is the wait before the first retry, which is then
+	// doubled for every subsequent retry up to MaxBackoff. A response's
+	// Retry-After header takes precedence over the computed backoff.
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+
+	// MaxPageResumes is the number of times a walk over many pages, such
+	// as ListCards or StreamCredits, pauses and fetches a page again once
+	// its retries are exhausted, so that a rate limit midway doesn't throw
+	// away the pages already walked. A Retry-After is always honored here.
+	MaxPageResumes int
+}
+
+const (
+	defaultBaseBackoff = 500 * time.Millisecond
+	defaultMaxBackoff  = 30 * time.Second
+)
+
+// SetRetryPolicy sets the policy for retrying requests. By default, and
+// if rp is nil, requests are not retried. When retries are exhausted or a
+// Retry-After is longer than the policy's MaxBackoff, a 503 response is
+// returned as *ErrServiceUnavailable so that callers can reschedule.
+func (c *Client) SetRetryPolicy(rp *RetryPolicy) {
+	var policy *RetryPolicy
+	if rp != nil {
+		policy = new(RetryPolicy)
+		*policy = *rp
+	}
+
+	c.Lock()
+	c.retry = policy
+	c.Unlock()
+}
+
+func (c *Client) retryPolicy() *RetryPolicy {
+	c.RLock()
+	defer c.RUnlock()
+
+	return c.retry
+}
+
+// retryable reports whether req can be retried after getting a response
+// with the status code. A 429 means that the request wasn't processed but
+// a 503, e.g from a proxy, doesn't rule out that it was hence only those
+// requests that can't take effect twice are retried after it.
+func retryable(req *http.Request, code int) bool {
+	switch code {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusServiceUnavailable:
+		switch req.Method {
+		case "GET", "HEAD", "DELETE":
+			return true
+		}
+		return req.Header.Get(idempotencyKeyHeader) != ""
+	}
+	return false
+}
+
+// nextBackoff returns how long to wait before retrying req that got res
+// at now, or false if it mustn't be retried. attempt is 0 for the first.
+func (rp *RetryPolicy) nextBackoff(attempt int, req *http.Request, res *http.Response, now time.Time) (time.Duration, bool) {
+	if rp == nil || attempt >= rp.MaxRetries || !retryable(req, res.StatusCode) {
+		return 0, false
+	}
+
+	maxBackoff := rp.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = defaultMaxBackoff
+	}
+
+	if retryAfter := res.Header.Get("Retry-After"); retryAfter != "" {
+		wait := parseRetryAfter(retryAfter, now)
+		return wait, wait <= maxBackoff
+	}
+
+	wait := rp.BaseBackoff
+	if wait <= 0 {
+		wait = defaultBaseBackoff
+	}
+	for i := 0; i < attempt && wait < maxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > maxBackoff {
+		wait = maxBackoff
+	}
+	return wait, true
+}
+
+// parseRetryAfter parses a Retry-After header value which is either a
+// number of seconds or an HTTP date. It returns 0 if value is invalid.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs < 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
+
+// ErrPageTimeout is returned by walks over many pages, such as ListCards,
+// StreamCredits and ExportCharges, when fetching a page took longer than
+// the page timeout. See SetPageTimeout.
+var ErrPageTimeout = errors.New("securionpay: fetching a page took longer than the page timeout")
+
+// SetPageTimeout limits how long fetching each page of a paginated walk
+// may take. A page that times out is fetched again as MaxPageResumes
+// allows, otherwise the walk fails with ErrPageTimeout. A non-positive
+// d, the default, disables the limit.
+func (c *Client) SetPageTimeout(d time.Duration) {
+	c.Lock()
+	c.pageTimeout = d
+	c.Unlock()
+}
+
+func (c *Client) _pageTimeout() time.Duration {
+	c.RLock()
+	defer c.RUnlock()
+
+	return c.pageTimeout
+}
+
+// fetchPage invokes fetch with a context that, if a page timeout
+// is set, is cancelled once the page timeout has elapsed.
+func (c *Client) fetchPage(ctx context.Context, fetch func(context.Context) error) error {
+	timeout := c._pageTimeout()
+	if timeout <= 0 {
+		return fetch(ctx)
+	}
+
+	pageCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	err := fetch(pageCtx)
+	if err != nil && ctx.Err() == nil && pageCtx.Err() == context.DeadlineExceeded {
+		return ErrPageTimeout
+	}
+	return err
+}
+
+// resumePage fetches a page of a multi-page walk and, if SecurionPay
+// rate limited the request or was unavailable, waits then fetches the
+// same page again so that the walk continues from its last cursor.
+// fetch must send its request with the context that it is passed.
+func (c *Client) resumePage(ctx context.Context, fetch func(context.Context) error) error {
+	policy := c.retryPolicy()
+
+	for resumes := 0; ; resumes++ {
+		err := c.fetchPage(ctx, fetch)
+		if err == nil {
+			return nil
+		}
+
+		wait, resume := policy.resumeBackoff(resumes, err)
+		if !resume {
+			return err
+		}
+		if err := sleepContext(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+// resumeBackoff returns how long to pause before fetching a page again
+// after it failed with err, or false if the page mustn't be fetched again.
+func (rp *RetryPolicy) resumeBackoff(resumes int, err error) (time.Duration, bool) {
+	if rp == nil || resumes >= rp.MaxPageResumes {
+		return 0, false
+	}
+
+	if err == ErrPageTimeout {
+		// The page is fetched again right away since
+		// SecurionPay didn't ask for requests to slow down.
+		return 0, true
+	}
+
+	var retryAfter time.Duration
+	switch e := err.(type) {
+	case *ErrServiceUnavailable:
+		retryAfter = e.RetryAfter
+	case *ErrRateLimited:
+		retryAfter = e.RetryAfter
+	default:
+		return 0, false
+	}
+	if retryAfter > 0 {
+		return retryAfter, true
+	}
+
+	if rp.MaxBackoff > 0 {
+		return rp.MaxBackoff, true
+	}
+	return defaultMaxBackoff, true
+}
+
+var errUnrewindableBody = errors.New("cannot retry the request since its body can't be re-read")
+
+// rewindRequest returns a copy of req whose body
+// can be read again from the start, for a retry.
+func rewindRequest(req *http.Request) (*http.Request, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req, nil
+	}
+	if req.GetBody == nil {
+		return nil, errUnrewindableBody
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	rewound := req.WithContext(req.Context())
+	rewound.Body = body
+	return rewound, nil
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// ErrServiceUnavailable is returned when SecurionPay responded with a
+// 503 e.g during scheduled maintenance and retries, if any, are exhausted.
+type ErrServiceUnavailable struct {
+	Message string
+
+	// RetryAfter is how long SecurionPay asked to wait before
+	// trying again, or 0 if it didn't say.
+	RetryAfter time.Duration
+}
+
+func (e *ErrServiceUnavailable) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("securionpay: service unavailable, retry after %s: %s", e.RetryAfter, e.Message)
+	}
+	return fmt.Sprintf("securionpay: service unavailable: %s", e.Message)
+}
+
+// ErrRateLimited is returned when SecurionPay responded with a 429
+// because too many requests were made and retries, if any, are exhausted.
+type ErrRateLimited struct {
+	Message string
+
+	// RetryAfter is how long SecurionPay asked to wait before
+	// trying again, or 0 if it didn't say.
+	RetryAfter time.Duration
+}
+
+func (e *ErrRateLimited) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("securionpay: rate limited, retry after %s: %s", e.RetryAfter, e.Message)
+	}
+	return fmt.Sprintf("securionpay: rate limited: %s", e.Message)
+}
@@ -0,0 +1,366 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/orijtech/securionpay"
+)
+
+func TestRetryOnServiceUnavailable(t *testing.T) {
+	client := newTestClient(t)
+
+	chargeBody, err := ioutil.ReadFile("testdata/chargeResp1.json")
+	if err != nil {
+		t.Fatalf("reading charge fixture: %v", err)
+	}
+
+	findCharge := func(c *securionpay.Client) (string, error) {
+		cResp, err := c.FindChargeByID(chargeID1)
+		if err != nil {
+			return "", err
+		}
+		return cResp.ID, nil
+	}
+	charge := func(c *securionpay.Client) (string, error) {
+		cResp, err := c.Charge(&securionpay.Charge{Card: "tok_NGsyDoJQXop5Pqqi6HizbJTe", AmountMinorCurrencyUnits: 499})
+		if err != nil {
+			return "", err
+		}
+		return cResp.ID, nil
+	}
+	refund := func(c *securionpay.Client) (string, error) {
+		_, err := c.RefundCharge(&securionpay.RefundRequest{ChargeID: chargeID1, IdempotencyKey: "refund-1"})
+		return chargeID1, err
+	}
+
+	tests := [...]struct {
+		do          func(*securionpay.Client) (string, error)
+		policy      *securionpay.RetryPolicy
+		statusCodes []int
+		retryAfter  string
+
+		wantReqs       int
+		wantErr        bool
+		wantRetryAfter time.Duration
+	}{
+		0: {
+			do:          findCharge,
+			policy:      &securionpay.RetryPolicy{MaxRetries: 2},
+			statusCodes: []int{http.StatusServiceUnavailable, http.StatusOK},
+			retryAfter:  "0",
+			wantReqs:    2,
+		},
+		1: {
+			do:          findCharge,
+			policy:      &securionpay.RetryPolicy{MaxRetries: 2, BaseBackoff: time.Millisecond},
+			statusCodes: []int{http.StatusTooManyRequests, http.StatusServiceUnavailable, http.StatusOK},
+			wantReqs:    3,
+		},
+		2: {
+			do: findCharge,
+			// No retries by default.
+			statusCodes:    []int{http.StatusServiceUnavailable, http.StatusOK},
+			retryAfter:     "120",
+			wantReqs:       1,
+			wantErr:        true,
+			wantRetryAfter: 120 * time.Second,
+		},
+		3: {
+			do: findCharge,
+			// Retry-After exceeds the maximum backoff so the caller
+			// gets to reschedule rather than having to wait.
+			policy:         &securionpay.RetryPolicy{MaxRetries: 2, MaxBackoff: time.Second},
+			statusCodes:    []int{http.StatusServiceUnavailable, http.StatusOK},
+			retryAfter:     "120",
+			wantReqs:       1,
+			wantErr:        true,
+			wantRetryAfter: 120 * time.Second,
+		},
+		4: {
+			do:             findCharge,
+			policy:         &securionpay.RetryPolicy{MaxRetries: 1},
+			statusCodes:    []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusOK},
+			retryAfter:     "0",
+			wantReqs:       2,
+			wantErr:        true,
+			wantRetryAfter: 0,
+		},
+		5: {
+			// The charge may have been made despite the 503.
+			do:          charge,
+			policy:      &securionpay.RetryPolicy{MaxRetries: 2},
+			statusCodes: []int{http.StatusServiceUnavailable, http.StatusOK},
+			retryAfter:  "0",
+			wantReqs:    1,
+			wantErr:     true,
+		},
+		6: {
+			// Rate limited requests weren't processed.
+			do:          charge,
+			policy:      &securionpay.RetryPolicy{MaxRetries: 2},
+			statusCodes: []int{http.StatusTooManyRequests, http.StatusOK},
+			retryAfter:  "0",
+			wantReqs:    2,
+		},
+		7: {
+			do:          refund,
+			policy:      &securionpay.RetryPolicy{MaxRetries: 2},
+			statusCodes: []int{http.StatusServiceUnavailable, http.StatusOK},
+			retryAfter:  "0",
+			wantReqs:    2,
+		},
+	}
+
+	for i, tt := range tests {
+		crt := &cannedRoundTripper{
+			statusCodes: tt.statusCodes,
+			body:        string(chargeBody),
+			header:      http.Header{},
+		}
+		if tt.retryAfter != "" {
+			crt.header.Set("Retry-After", tt.retryAfter)
+		}
+		client.SetHTTPRoundTripper(crt)
+		client.SetRetryPolicy(tt.policy)
+
+		id, err := tt.do(client)
+		if got := len(crt.requests()); got != tt.wantReqs {
+			t.Errorf("#%d: got %d requests, want %d", i, got, tt.wantReqs)
+		}
+		if tt.wantErr {
+			unavailable, ok := err.(*securionpay.ErrServiceUnavailable)
+			if !ok {
+				t.Errorf("#%d: got err=%#v, want *ErrServiceUnavailable", i, err)
+				continue
+			}
+			if unavailable.RetryAfter != tt.wantRetryAfter {
+				t.Errorf("#%d: gotRetryAfter=%v want=%v", i, unavailable.RetryAfter, tt.wantRetryAfter)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("#%d: err: %v", i, err)
+			continue
+		}
+		if id != chargeID1 {
+			t.Errorf("#%d: gotID=%q", i, id)
+		}
+	}
+}
+
+func TestListCardsResumesAfterRateLimit(t *testing.T) {
+	client := newTestClient(t)
+
+	page1 := `{"list":[{"id":"card_1"},{"id":"card_2"}],"hasMore":true}`
+	page2 := `{"list":[{"id":"card_3"}],"hasMore":false}`
+
+	tests := [...]struct {
+		policy      *securionpay.RetryPolicy
+		statusCodes []int
+		bodies      []string
+
+		wantReqs        int
+		wantRateLimited bool
+		wantCardIDs     []string
+	}{
+		0: {
+			// The rate limited page is fetched again from the
+			// same cursor instead of restarting the walk.
+			policy:      &securionpay.RetryPolicy{MaxBackoff: time.Millisecond, MaxPageResumes: 2},
+			statusCodes: []int{http.StatusOK, http.StatusTooManyRequests, http.StatusTooManyRequests, http.StatusOK},
+			bodies:      []string{page1, "slow down", "slow down", page2},
+			wantReqs:    4,
+			wantCardIDs: []string{"card_1", "card_2", "card_3"},
+		},
+		1: {
+			policy:          &securionpay.RetryPolicy{MaxBackoff: time.Millisecond, MaxPageResumes: 1},
+			statusCodes:     []int{http.StatusOK, http.StatusTooManyRequests, http.StatusTooManyRequests, http.StatusOK},
+			bodies:          []string{page1, "slow down", "slow down", page2},
+			wantReqs:        3,
+			wantRateLimited: true,
+		},
+		2: {
+			// Without a policy the walk stops at the first failure.
+			statusCodes:     []int{http.StatusOK, http.StatusTooManyRequests, http.StatusOK},
+			bodies:          []string{page1, "slow down", page2},
+			wantReqs:        2,
+			wantRateLimited: true,
+		},
+	}
+
+	for i, tt := range tests {
+		crt := &cannedRoundTripper{statusCodes: tt.statusCodes, bodies: tt.bodies}
+		client.SetHTTPRoundTripper(crt)
+		client.SetRetryPolicy(tt.policy)
+
+		cards, err := client.ListCards(customerID1)
+		reqs := crt.requests()
+		if got := len(reqs); got != tt.wantReqs {
+			t.Errorf("#%d: got %d requests, want %d", i, got, tt.wantReqs)
+		}
+		for j, req := range reqs[1:] {
+			if got := req.URL.Query().Get("startingAfterId"); got != "card_2" {
+				t.Errorf("#%d: req #%d: startingAfterId=%q want %q", i, j+1, got, "card_2")
+			}
+		}
+
+		if tt.wantRateLimited {
+			if _, ok := err.(*securionpay.ErrRateLimited); !ok {
+				t.Errorf("#%d: got err=%#v, want *ErrRateLimited", i, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: err: %v", i, err)
+			continue
+		}
+
+		var gotIDs []string
+		for _, card := range cards {
+			gotIDs = append(gotIDs, card.ID)
+		}
+		if fmt.Sprintf("%v", gotIDs) != fmt.Sprintf("%v", tt.wantCardIDs) {
+			t.Errorf("#%d: gotCardIDs=%v want=%v", i, gotIDs, tt.wantCardIDs)
+		}
+	}
+}
+
+func TestRetryAfterDateUsesClock(t *testing.T) {
+	client := newTestClient(t)
+
+	frozen := time.Date(2017, time.March, 14, 15, 9, 26, 0, time.UTC)
+	client.SetClock(func() time.Time { return frozen })
+
+	crt := &cannedRoundTripper{
+		statusCode: http.StatusServiceUnavailable,
+		header:     http.Header{"Retry-After": {frozen.Add(90 * time.Second).Format(http.TimeFormat)}},
+	}
+	client.SetHTTPRoundTripper(crt)
+
+	var logs []*securionpay.RequestLog
+	client.SetLogger(func(rl *securionpay.RequestLog) { logs = append(logs, rl) })
+
+	_, err := client.FindChargeByID(chargeID1)
+	unavailable, ok := err.(*securionpay.ErrServiceUnavailable)
+	if !ok {
+		t.Fatalf("got err=%#v, want *ErrServiceUnavailable", err)
+	}
+	if got, want := unavailable.RetryAfter, 90*time.Second; got != want {
+		t.Errorf("gotRetryAfter=%v want=%v", got, want)
+	}
+	if len(logs) != 1 || logs[0].Duration != 0 {
+		t.Errorf("got logs=%#v, want a single log with a 0 duration", logs)
+	}
+}
+
+// slowPageRoundTripper stalls the requests numbered in stall until they
+// are cancelled and otherwise responds with the next of bodies.
+type slowPageRoundTripper struct {
+	mu     sync.Mutex
+	n      int
+	stall  map[int]bool
+	bodies []string
+}
+
+func (rt *slowPageRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt.mu.Lock()
+	rt.n++
+	n := rt.n
+	rt.mu.Unlock()
+
+	if rt.stall[n] {
+		<-req.Context().Done()
+		return nil, req.Context().Err()
+	}
+	resp := makeResp("200 OK", http.StatusOK)
+	resp.Body = ioutil.NopCloser(strings.NewReader(rt.bodies[0]))
+	rt.mu.Lock()
+	rt.bodies = rt.bodies[1:]
+	rt.mu.Unlock()
+	return resp, nil
+}
+
+func TestPageTimeout(t *testing.T) {
+	page1 := `{"list":[{"id":"card_1"}],"hasMore":true}`
+	page2 := `{"list":[{"id":"card_2"}],"hasMore":false}`
+
+	tests := [...]struct {
+		policy *securionpay.RetryPolicy
+		stall  map[int]bool
+
+		wantReqs    int
+		wantErr     error
+		wantCardIDs []string
+	}{
+		0: {wantReqs: 2, wantCardIDs: []string{"card_1", "card_2"}},
+		1: {
+			// The slow second page fails the walk.
+			stall:    map[int]bool{2: true},
+			wantReqs: 2,
+			wantErr:  securionpay.ErrPageTimeout,
+		},
+		2: {
+			// The slow second page is fetched again.
+			policy:      &securionpay.RetryPolicy{MaxPageResumes: 1},
+			stall:       map[int]bool{2: true},
+			wantReqs:    3,
+			wantCardIDs: []string{"card_1", "card_2"},
+		},
+		3: {
+			policy:   &securionpay.RetryPolicy{MaxPageResumes: 1},
+			stall:    map[int]bool{2: true, 3: true},
+			wantReqs: 3,
+			wantErr:  securionpay.ErrPageTimeout,
+		},
+	}
+
+	for i, tt := range tests {
+		rt := &slowPageRoundTripper{stall: tt.stall, bodies: []string{page1, page2}}
+		client := newClientWithRoundTripper(t, rt)
+		client.SetRetryPolicy(tt.policy)
+		client.SetPageTimeout(50 * time.Millisecond)
+
+		cards, err := client.ListCards(customerID1)
+		if rt.n != tt.wantReqs {
+			t.Errorf("#%d: got %d requests, want %d", i, rt.n, tt.wantReqs)
+		}
+		if tt.wantErr != nil {
+			if err != tt.wantErr {
+				t.Errorf("#%d: got err=%v want=%v", i, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: err: %v", i, err)
+			continue
+		}
+		var gotIDs []string
+		for _, card := range cards {
+			gotIDs = append(gotIDs, card.ID)
+		}
+		if fmt.Sprint(gotIDs) != fmt.Sprint(tt.wantCardIDs) {
+			t.Errorf("#%d: got cards %v want %v", i, gotIDs, tt.wantCardIDs)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay
+
+import "strings"
+
+// scaCountries are the countries where Strong Customer Authentication
+// applies to cards issued there: the EEA, that is the EU as well as
+// Iceland, Liechtenstein and Norway, plus the United Kingdom.
+var scaCountries = map[string]bool{
+	"AT": true, "BE": true, "BG": true, "CY": true, "CZ": true, "DE": true,
+	"DK": true, "EE": true, "ES": true, "FI": true, "FR": true, "GR": true,
+	"HR": true, "HU": true, "IE": true, "IT": true, "LT": true, "LU": true,
+	"LV": true, "MT": true, "NL": true, "PL": true, "PT": true, "RO": true,
+	"SE": true, "SI": true, "SK": true,
+
+	"IS": true, "LI": true, "NO": true,
+
+	"GB": true,
+}
+
+// scaLowValueExemptions are the amounts, in minor currency units, up to
+// which remote payments are exempt from Strong Customer Authentication.
+var scaLowValueExemptions = map[Currency]int{
+	Euros: 3000,
+	"GBP": 2500,
+}
+
+// ChargeRequiresSCA guesses, without a request, whether charging amount
+// of cur to a card from cardCountry requires Strong Customer Authentication.
+// Cards from the EEA or the UK require it unless amount is within the low
+// value exemption of €30 or £25. Issuers may still require it regardless.
+func (c *Client) ChargeRequiresSCA(cardCountry string, amount int, cur Currency) bool {
+	country, err := NormalizeCountry(cardCountry)
+	if err != nil || !scaCountries[country] {
+		return false
+	}
+	exemption, known := scaLowValueExemptions[Currency(strings.ToUpper(string(cur)))]
+	return !known || amount > exemption
+}
@@ -0,0 +1,53 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay_test
+
+import (
+	"testing"
+
+	"github.com/orijtech/securionpay"
+)
+
+func TestChargeRequiresSCA(t *testing.T) {
+	client := newTestClient(t)
+
+	tests := [...]struct {
+		country string
+		amount  int
+		cur     securionpay.Currency
+		want    bool
+	}{
+		0: {country: "PL", amount: 5000, cur: securionpay.Euros, want: true},
+		1: {country: "PL", amount: 3000, cur: securionpay.Euros},
+		2: {country: "de", amount: 3001, cur: "eur", want: true},
+		3: {country: "Norway", amount: 10000, cur: securionpay.Euros, want: true},
+		4: {country: "GB", amount: 2500, cur: "GBP"},
+		5: {country: "GB", amount: 2600, cur: "GBP", want: true},
+
+		// Amounts in other currencies aren't converted.
+		6: {country: "SE", amount: 100, cur: "SEK", want: true},
+
+		// Cards from outside the EEA and the UK.
+		7: {country: "US", amount: 100000, cur: securionpay.USD},
+		8: {country: "CH", amount: 100000, cur: securionpay.Euros},
+		9: {country: "", amount: 100000, cur: securionpay.Euros},
+	}
+
+	for i, tt := range tests {
+		if got := client.ChargeRequiresSCA(tt.country, tt.amount, tt.cur); got != tt.want {
+			t.Errorf("#%d: ChargeRequiresSCA(%q, %d, %q)=%v want %v", i, tt.country, tt.amount, tt.cur, got, tt.want)
+		}
+	}
+}
@@ -16,16 +16,19 @@ package securionpay
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"os"
-	"strconv"
+	"reflect"
 	"strings"
 	"sync"
 	"text/template"
+	"time"
 
 	"github.com/orijtech/otils"
 )
@@ -33,9 +36,75 @@ import (
 type Client struct {
 	sync.RWMutex
 
-	apiKey string
+	apiKey     string
+	authScheme AuthScheme
 
 	rt http.RoundTripper
+
+	dialTimeout time.Duration
+	httpTimeout time.Duration
+
+	disableKeepAlives   bool
+	maxIdleConnsPerHost int
+
+	// pageTimeout bounds each page fetch of walks over many pages.
+	pageTimeout time.Duration
+
+	// tunedTransport is a clone of http.DefaultTransport
+	// adjusted by the transport settings e.g the dial timeout.
+	// It is only used when no custom round tripper was set.
+	tunedTransport *http.Transport
+
+	logger    func(*RequestLog)
+	logBodies bool
+
+	retry *RetryPolicy
+
+	// reqCtx is only set on clients derived by DoWithCorrelationID
+	// and is the context that their requests are sent with.
+	reqCtx context.Context
+
+	// clock, if set, tells the current time instead of time.Now.
+	clock func() time.Time
+
+	// tokens remembers the tokens created by NewTokenDeduped.
+	tokens *dedupedTokens
+
+	// createdTokens, if set, remembers the tokens created by NewToken.
+	createdTokens *createdTokens
+
+	// chargedTokens, if set, remembers the tokens charged by Charge.
+	chargedTokens *chargedTokens
+
+	// recentCharges, if set, remembers the charges
+	// recently submitted by Charge.
+	recentCharges *recentCharges
+
+	// descriptionTmpl, if set, generates the
+	// descriptions of charges that lack one.
+	descriptionTmpl *template.Template
+
+	// fallbackKeys are tried in order, after apiKey, when keyFailover
+	// is enabled and SecurionPay rejects a key. keyState tracks the
+	// key that SecurionPay last accepted.
+	fallbackKeys []string
+	keyFailover  bool
+	keyState     *keyFailoverState
+
+	// latencies, if set, records the durations of recent requests.
+	latencies *latencyTracker
+	metrics   MetricsSink
+
+	deprecationHandler func(msg string)
+
+	strictDecoding   bool
+	compressRequests bool
+
+	// baseURL, if set, replaces the default base URL of requests
+	// and so does sandboxBaseURL for test keys if autoEnvironment is set.
+	baseURL         *url.URL
+	sandboxBaseURL  *url.URL
+	autoEnvironment bool
 }
 
 const (
@@ -56,10 +125,14 @@ func NewClientFromEnv() (*Client, error) {
 // NewClient first tries all the apiKeys provided as arguments,
 // if it finds a non blank one, uses that.
 // Otherwise it falls back to finding the API key from the environment.
+// The non blank keys after the first one are only used once failover
+// is enabled with SetKeyFailover.
 func NewClient(apiKeysToTry ...string) (*Client, error) {
 	nonBlankAPIKey := otils.FirstNonEmptyString(apiKeysToTry...)
 	if nonBlankAPIKey != "" {
-		return &Client{apiKey: nonBlankAPIKey}, nil
+		client := &Client{apiKey: nonBlankAPIKey}
+		client.fallbackKeys = nonBlankKeysAfter(nonBlankAPIKey, apiKeysToTry)
+		return client, nil
 	}
 	return NewClientFromEnv()
 }
@@ -76,6 +149,156 @@ func (c *Client) SetAPIKey(key string) {
 	c.Unlock()
 }
 
+// derive returns a new client with the same settings as c.
+// Any newly added settings must also be copied over here,
+// which TestDeriveCopiesEveryField checks.
+func (c *Client) derive() *Client {
+	keyState := c.ensureKeyFailoverState()
+
+	c.RLock()
+	defer c.RUnlock()
+
+	return &Client{
+		apiKey:     c.apiKey,
+		authScheme: c.authScheme,
+		rt:         c.rt,
+
+		dialTimeout:         c.dialTimeout,
+		httpTimeout:         c.httpTimeout,
+		disableKeepAlives:   c.disableKeepAlives,
+		maxIdleConnsPerHost: c.maxIdleConnsPerHost,
+		pageTimeout:         c.pageTimeout,
+		tunedTransport:      c.tunedTransport,
+
+		logger:    c.logger,
+		logBodies: c.logBodies,
+
+		retry: c.retry,
+
+		reqCtx: c.reqCtx,
+
+		clock:         c.clock,
+		tokens:        c.tokens,
+		createdTokens: c.createdTokens,
+		chargedTokens: c.chargedTokens,
+		recentCharges: c.recentCharges,
+
+		descriptionTmpl: c.descriptionTmpl,
+
+		fallbackKeys: c.fallbackKeys,
+		keyFailover:  c.keyFailover,
+		keyState:     keyState,
+
+		latencies: c.latencies,
+		metrics:   c.metrics,
+
+		deprecationHandler: c.deprecationHandler,
+
+		strictDecoding:   c.strictDecoding,
+		compressRequests: c.compressRequests,
+
+		baseURL:         c.baseURL,
+		sandboxBaseURL:  c.sandboxBaseURL,
+		autoEnvironment: c.autoEnvironment,
+	}
+}
+
+// SetClock sets the function that the client uses to tell the current
+// time e.g to freeze time in tests. If fn is nil, time.Now is used.
+func (c *Client) SetClock(fn func() time.Time) {
+	c.Lock()
+	c.clock = fn
+	c.Unlock()
+}
+
+func (c *Client) now() time.Time {
+	c.RLock()
+	clock := c.clock
+	c.RUnlock()
+
+	if clock == nil {
+		return time.Now()
+	}
+	return clock()
+}
+
+var (
+	_ fmt.Stringer   = (*Client)(nil)
+	_ fmt.GoStringer = (*Client)(nil)
+)
+
+// String returns a description of the client that is safe to log
+// since the API key is redacted. Note that "%#v" would otherwise
+// print every field including the unexported API key, hence the
+// client also implements fmt.GoStringer with the same redaction.
+func (c *Client) String() string {
+	return fmt.Sprintf("securionpay.Client{apiKey: %q}", redactAPIKey(c._apiKey()))
+}
+
+func (c *Client) GoString() string {
+	return c.String()
+}
+
+// redactAPIKey masks all but the last 4 characters of an API
+// key while keeping its descriptive prefix e.g "sk_live_".
+func redactAPIKey(key string) string {
+	const visibleSuffixLen = 4
+	if key == "" {
+		return ""
+	}
+
+	prefix := ""
+	if i := strings.LastIndex(key, "_"); i >= 0 {
+		prefix, key = key[:i+1], key[i+1:]
+	}
+	if len(key) <= 2*visibleSuffixLen {
+		return prefix + "****"
+	}
+	return prefix + "****" + key[len(key)-visibleSuffixLen:]
+}
+
+const (
+	testKeyMarker = "_test_"
+	liveKeyMarker = "_live_"
+)
+
+// IsTestKey reports whether key is a SecurionPay test mode key
+// such as "sk_test_..." or "pk_test_...".
+func IsTestKey(key string) bool {
+	return strings.Contains(strings.TrimSpace(key), testKeyMarker)
+}
+
+// LiveMode reports whether the client is configured with a
+// live mode API key i.e one prefixed by "sk_live_".
+func (c *Client) LiveMode() bool {
+	return strings.Contains(c._apiKey(), liveKeyMarker)
+}
+
+// testIDMarkers are the segments assumed to follow the object type
+// prefix of test mode resource IDs e.g "char_test_..." as opposed to
+// live ones e.g "char_...". These are assumptions about SecurionPay's
+// ID format and need to be updated if the provider changes it.
+var testIDMarkers = []string{"test_"}
+
+// IsTestID reports whether id looks like the ID of an object created
+// in test mode e.g "char_test_...", "cust_test_..." or "tok_test_...".
+// It only inspects the ID's format so a false result doesn't guarantee
+// that the object is live; see testIDMarkers for the assumptions made.
+func IsTestID(id string) bool {
+	id = strings.TrimSpace(id)
+	i := strings.Index(id, "_")
+	if i < 1 {
+		return false
+	}
+	rest := id[i+1:]
+	for _, marker := range testIDMarkers {
+		if strings.HasPrefix(rest, marker) {
+			return true
+		}
+	}
+	return false
+}
+
 type CardType string
 
 const (
@@ -96,23 +319,40 @@ const (
 
 type ObjectType string
 
-var _ json.Marshaler = (*ObjectType)(nil)
+var _ json.Marshaler = ObjectType("")
 
-func (ot *ObjectType) MarshalJSON() ([]byte, error) {
-	str := "card"
-	if ot != nil {
-		stripped := strings.TrimSpace(string(*ot))
-		if stripped != "" {
-			str = stripped
-		}
-	}
+// MarshalJSON trims surrounding whitespace from the object type. It has
+// a value receiver so that object types are encoded the same whether or
+// not they are addressable.
+func (ot ObjectType) MarshalJSON() ([]byte, error) {
+	// json.Marshal rather than strconv.Quote since the latter
+	// produces Go escape sequences such as "\x00" that aren't
+	// valid JSON.
+	return json.Marshal(strings.TrimSpace(string(ot)))
+}
+
+const cardObjectType ObjectType = "card"
 
-	quoted := strconv.Quote(str)
-	return []byte(quoted), nil
+var _ json.Marshaler = Card{}
+
+// MarshalJSON encodes the card with its object type defaulting to "card".
+func (c Card) MarshalJSON() ([]byte, error) {
+	type plainCard Card
+	pc := plainCard(c)
+	if strings.TrimSpace(string(pc.ObjectType)) == "" {
+		pc.ObjectType = cardObjectType
+	}
+	return json.Marshal(pc)
 }
 
 type Card struct {
-	ID             string     `json:"id"`
+	ID string `json:"id,omitempty"`
+
+	// Number and SecurityCode are only set, and sent,
+	// when creating a card from its details.
+	Number       string `json:"number,omitempty"`
+	SecurityCode string `json:"cvc,omitempty"`
+
 	ObjectType     ObjectType `json:"objectType"`
 	CreatedAt      int64      `json:"created"`
 	First6Digits   string     `json:"first6"`
@@ -124,14 +364,19 @@ type Card struct {
 	CustomerID     string     `json:"customerId"`
 	Brand          string     `json:"brand"`
 	Type           CardType   `json:"type"`
-	Country        string     `json:"addressCountry,omitempty"`
+	Country        Country    `json:"addressCountry,omitempty"`
 	City           string     `json:"addressCity,omitempty"`
 	State          string     `json:"addressState,omitempty"`
 	ZIP            string     `json:"addressZip,omitempty"`
 	AddressLine1   string     `json:"addressLine1,omitempty"`
 	AddressLine2   string     `json:"addressLine2,omitempty"`
 
-	FraudCheckData *FraudCheckData `json:"fraudCheckData"`
+	FraudCheckData *FraudCheckData `json:"fraudCheckData,omitempty"`
+
+	// IsDefault is not sent by SecurionPay but is set by
+	// ListCardsWithDefault when this card is the customer's
+	// default card.
+	IsDefault bool `json:"-"`
 }
 
 type FraudCheckData struct {
@@ -139,16 +384,44 @@ type FraudCheckData struct {
 	IPCountry      string `json:"ipCountry,omitempty"`
 	Email          string `json:"email,omitempty"`
 	UserAgent      string `json:"userAgent,omitempty"`
-	AcceptLanguage string `json:"acceptLanguage"`
+	AcceptLanguage string `json:"acceptLanguage,omitempty"`
 }
 
 type Customer struct {
-	ID string `json:"id"`
+	ID         string     `json:"id"`
+	CreatedAt  int64      `json:"created,omitempty"`
+	ObjectType ObjectType `json:"objectType,omitempty"`
+
+	Email       string `json:"email,omitempty"`
+	Description string `json:"description,omitempty"`
+
+	DefaultCardID string  `json:"defaultCardId,omitempty"`
+	Cards         []*Card `json:"cards,omitempty"`
+
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+	Deleted  bool                   `json:"deleted,omitempty"`
 }
 
 type AddCardRequest struct {
 	CustomerID string `json:"customerId"`
 	Card       *Card  `json:"card"`
+
+	// SetAsDefault if set makes the added card
+	// the customer's default card.
+	SetAsDefault bool `json:"-"`
+}
+
+// ErrDefaultCardNotSet is returned by AddCard when the card
+// was added but couldn't then be made the customer's default card.
+type ErrDefaultCardNotSet struct {
+	// Card is the card that was added.
+	Card *Card
+
+	Err error
+}
+
+func (e *ErrDefaultCardNotSet) Error() string {
+	return fmt.Sprintf("card %q was added but not set as the default card: %v", e.Card.ID, e.Err)
 }
 
 var (
@@ -159,9 +432,21 @@ var (
 	errBlankTokenID = errors.New("expecting a non-blank token ID")
 
 	errBlankAddCardRequest = errors.New("expecting a non-blank card request")
+
+	errCardIDOnCreation   = errors.New("a card being created can't have an ID yet")
+	errBlankCardNumber    = errors.New("expecting a non-blank card number")
+	errInvalidExpiryMonth = errors.New("expecting the expiry month to be from 1 to 12")
+	errInvalidExpiryYear  = errors.New("expecting a positive expiry year")
 )
 
+// Validate is the same as ValidateForReference.
 func (c *Card) Validate() error {
+	return c.ValidateForReference()
+}
+
+// ValidateForReference checks that the card can be used to
+// refer to an existing card, which requires its ID.
+func (c *Card) ValidateForReference() error {
 	if c == nil {
 		return errBlankCard
 	}
@@ -172,6 +457,69 @@ func (c *Card) Validate() error {
 	return nil
 }
 
+// ValidateForCreation checks that the card's details are enough to
+// create it i.e its number, expiry and security code. A card that is
+// yet to be created can't have an ID.
+func (c *Card) ValidateForCreation() error {
+	if c == nil {
+		return errBlankCard
+	}
+	if strings.TrimSpace(c.ID) != "" {
+		return errCardIDOnCreation
+	}
+	if strings.TrimSpace(c.Number) == "" {
+		return errBlankCardNumber
+	}
+	if c.ExpiryMonth < 1 || c.ExpiryMonth > 12 {
+		return errInvalidExpiryMonth
+	}
+	if c.ExpiryYear < 1 {
+		return errInvalidExpiryYear
+	}
+	if strings.TrimSpace(c.SecurityCode) == "" {
+		return errEmptySecurityCode
+	}
+	return nil
+}
+
+// validateCardSource validates a card, either referenced by its ID
+// or with the details to create it, otherwise it returns nil for
+// sources that aren't cards such as token IDs.
+func validateCardSource(source interface{}) error {
+	var card *Card
+	switch c := source.(type) {
+	case *Card:
+		if c == nil {
+			return errBlankCard
+		}
+		card = c
+	case Card:
+		card = &c
+	default:
+		return nil
+	}
+
+	if strings.TrimSpace(card.ID) != "" {
+		return card.ValidateForReference()
+	}
+	return card.ValidateForCreation()
+}
+
+// HasFullBillingAddress reports whether the card carries a complete
+// billing address. Issuers usually only perform full address
+// verification (AVS) when every one of these fields is present.
+func (c *Card) HasFullBillingAddress() bool {
+	if c == nil {
+		return false
+	}
+	for _, field := range []string{c.AddressLine1, c.City, c.State, c.ZIP, string(c.Country)} {
+		if strings.TrimSpace(field) == "" {
+			return false
+		}
+	}
+	return true
+}
+
 const addCardEndpointURL = "https://api.securionpay.com/customers/{{.CustomerID}}/cards"
 
 var addCardEndpointTmpl = template.Must(template.New("addCard").Parse(addCardEndpointURL))
@@ -184,13 +532,16 @@ func (acr *AddCardRequest) generateURL() (string, error) {
 	return string(buf.Bytes()), nil
 }
 
+// AddCard saves a card for a customer. If acr.SetAsDefault is set, the
+// card is then made the customer's default card; should that fail, the
+// added card is returned along with an *ErrDefaultCardNotSet.
 func (c *Client) AddCard(acr *AddCardRequest) (*Card, error) {
 	if acr == nil {
 		return nil, errBlankAddCardRequest
 	}
 
 	card := acr.Card
-	if err := card.Validate(); err != nil {
+	if err := validateCardSource(card); err != nil {
 		return nil, err
 	}
 
@@ -219,27 +570,43 @@ func (c *Client) AddCard(acr *AddCardRequest) (*Card, error) {
 	}
 
 	registeredCard := new(Card)
-	if err := json.Unmarshal(blob, registeredCard); err != nil {
+	if err := c.decodeResponse(blob, registeredCard); err != nil {
 		return nil, err
 	}
 
+	if acr.SetAsDefault {
+		if err := c.setDefaultCard(customerID, registeredCard.ID); err != nil {
+			return registeredCard, &ErrDefaultCardNotSet{Card: registeredCard, Err: err}
+		}
+		registeredCard.IsDefault = true
+	}
+
 	return registeredCard, nil
 }
 
-func (c *Client) httpRoundTripper() http.RoundTripper {
+func (c *Client) httpClient() *http.Client {
+	// Both settings are read under the same lock so that a request
+	// never combines a timeout and a transport set at different times.
 	c.RLock()
-	rt := c.rt
+	rt, timeout := c.rt, c.httpTimeout
 	c.RUnlock()
 
 	if rt == nil {
-		rt = http.DefaultTransport
+		rt = c.defaultTransport()
+	}
+	return &http.Client{
+		Transport:     rt,
+		Timeout:       timeout,
+		CheckRedirect: refuseRedirect,
 	}
-
-	return rt
 }
 
-func (c *Client) httpClient() *http.Client {
-	return &http.Client{Transport: c.httpRoundTripper()}
+var errRedirectRefused = errors.New("securionpay: refusing to follow a redirect")
+
+// refuseRedirect stops redirects from being followed,
+// since they could leak the API key to another host.
+func refuseRedirect(req *http.Request, via []*http.Request) error {
+	return fmt.Errorf("%v to %q", errRedirectRefused, req.URL.Host)
 }
 
 func (c *Client) _apiKey() string {
@@ -256,6 +623,14 @@ const (
 	USD   Currency = "USD"
 	Euros Currency = "EUR"
 	CAD   Currency = "CAD"
+	GBP   Currency = "GBP"
+	AUD   Currency = "AUD"
+	CHF   Currency = "CHF"
+	JPY   Currency = "JPY"
+	PLN   Currency = "PLN"
+	SEK   Currency = "SEK"
+	NOK   Currency = "NOK"
+	DKK   Currency = "DKK"
 )
 
 type Charge struct {
@@ -269,6 +644,10 @@ type Charge struct {
 	Currency    Currency `json:"currency"`
 	Description string   `json:"description"`
 
+	// StatementDescriptor, if set, is the text shown for the charge on
+	// the customer's card statement instead of the merchant's default.
+	StatementDescriptor string `json:"statementDescriptor,omitempty"`
+
 	// Card can either be:
 	// a) card token
 	// b) card details
@@ -278,19 +657,67 @@ type Charge struct {
 	// Either CustomerID or Card can be set
 	CustomerID CustomerID `json:"customerId,omitempty"`
 
+	// CardID selects one of the customer's saved cards to
+	// charge instead of their default card. It requires
+	// CustomerID to be set and is sent as the "card" field
+	// hence it can't be used together with Card.
+	CardID string `json:"-"`
+
 	Shipping *Shipping `json:"shipping,omitempty"`
 	Billing  *Billing  `json:"billing,omitempty"`
 
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+
+	// Captured is only sent when true unless the charge was made by
+	// NewCapturedCharge or NewAuthorization, since SecurionPay captures
+	// charges by default. Use NewAuthorization to authorize a charge
+	// that will be captured later.
 	Captured bool `json:"captured,omitempty"`
+
+	// explicitCapture is set by the constructors so that
+	// Captured is sent even when false.
+	explicitCapture bool
+}
+
+// NewCapturedCharge returns a validated charge that is captured
+// immediately. source is either a CustomerID, to charge that customer's
+// default card, or anything accepted by Charge.Card.
+func NewCapturedCharge(amountMinorCurrencyUnits int, currency Currency, source interface{}) (*Charge, error) {
+	return newCharge(amountMinorCurrencyUnits, currency, source, true)
+}
+
+// NewAuthorization returns a validated charge that is only authorized
+// and must be captured later. source is either a CustomerID, to charge
+// that customer's default card, or anything accepted by Charge.Card.
+func NewAuthorization(amountMinorCurrencyUnits int, currency Currency, source interface{}) (*Charge, error) {
+	return newCharge(amountMinorCurrencyUnits, currency, source, false)
+}
+
+func newCharge(amountMinorCurrencyUnits int, currency Currency, source interface{}, captured bool) (*Charge, error) {
+	creq := &Charge{
+		AmountMinorCurrencyUnits: amountMinorCurrencyUnits,
+		Currency:                 currency,
+		Captured:                 captured,
+		explicitCapture:          true,
+	}
+	if customerID, ok := source.(CustomerID); ok {
+		creq.CustomerID = customerID
+	} else {
+		creq.Card = source
+	}
+	if err := creq.Validate(); err != nil {
+		return nil, err
+	}
+	return creq, nil
 }
 
 type Address struct {
-	Zip     string `json:"zip"`
-	Line1   string `json:"line1"`
-	Line2   string `json:"line2"`
-	City    string `json:"city"`
-	State   string `json:"state"`
-	Country string `json:"country"`
+	Zip     string  `json:"zip"`
+	Line1   string  `json:"line1"`
+	Line2   string  `json:"line2"`
+	City    string  `json:"city"`
+	State   string  `json:"state"`
+	Country Country `json:"country"`
 }
 
 type Shipping struct {
@@ -305,6 +732,54 @@ type Billing struct {
 	VAT string `json:"vat"`
 }
 
+var (
+	errShippingWithoutName   = errors.New("shipping: expecting a non-blank name")
+	errBillingWithoutAddr    = errors.New("billing: expecting an address")
+	errBillingWithoutLine1   = errors.New("billing: expecting a non-blank address line1")
+	errBillingWithoutCountry = errors.New("billing: expecting a non-blank address country")
+)
+
+// Validate checks that shipping, which is optional, has at least the
+// recipient's name if it is set and that its country is recognized.
+func (s *Shipping) Validate() error {
+	if s == nil {
+		return nil
+	}
+	if strings.TrimSpace(s.Name) == "" {
+		return errShippingWithoutName
+	}
+	if err := s.Address.ValidateCountry(); err != nil {
+		return fmt.Errorf("shipping: %v", err)
+	}
+	return nil
+}
+
+// Validate checks that billing, which is optional, has at least
+// the address' country, which must be recognized, and first line if it
+// is set since partial addresses degrade fraud scoring and can get
+// charges declined.
+func (b *Billing) Validate() error {
+	if b == nil {
+		return nil
+	}
+	if b.Address == nil {
+		return errBillingWithoutAddr
+	}
+	if strings.TrimSpace(string(b.Address.Country)) == "" {
+		return errBillingWithoutCountry
+	}
+	if strings.TrimSpace(b.Address.Line1) == "" {
+		return errBillingWithoutLine1
+	}
+	// The VAT number is checked against the country's code even
+	// if it was given by name e.g "Poland" rather than "PL".
+	country, err := NormalizeCountry(string(b.Address.Country))
+	if err != nil {
+		return fmt.Errorf("billing: %v", err)
+	}
+	return b.ValidateVAT(country)
+}
+
 type ChargeResponse struct {
 	ID          string     `json:"id"`
 	Amount      float32    `json:"amount"`
@@ -313,19 +788,182 @@ type ChargeResponse struct {
 	ObjectType  ObjectType `json:"objectType"`
 	Description string     `json:"description"`
 
-	Card *Card `json:"card"`
+	Card       *Card      `json:"card"`
+	CustomerID CustomerID `json:"customerId,omitempty"`
+
+	// Customer is set when the response embeds the customer that
+	// was charged, in which case CustomerID is also set from it.
+	Customer *Customer `json:"customer,omitempty"`
 
 	Captured bool `json:"captured"`
 	Refunded bool `json:"refunded"`
 	Disputed bool `json:"disputed"`
 
-	Refunds  []*Refund  `json:"refunds,omitempty"`
-	Disputes []*Dispute `json:"dispute,omitempty"`
+	// AlreadyCaptured is not sent by SecurionPay but is set by
+	// CaptureCharge when the charge had been captured before.
+	AlreadyCaptured bool `json:"-"`
+
+	Refunds  []*Refund   `json:"refunds,omitempty"`
+	Disputes DisputeList `json:"dispute,omitempty"`
+
+	// Fee is the processing fee deducted by SecurionPay
+	// and Net is the amount left to be settled after that
+	// fee, both in minor currency units.
+	Fee int `json:"fee,omitempty"`
+	Net int `json:"net,omitempty"`
+
+	// RequiresAction is set when the customer must
+	// authenticate with 3D Secure at RedirectURL.
+	RequiresAction bool   `json:"requiresAction,omitempty"`
+	RedirectURL    string `json:"redirectUrl,omitempty"`
+
+	// FailureCode and FailureMessage describe why the charge failed
+	// e.g "card_declined", even if it was returned successfully.
+	FailureCode    string `json:"failureCode,omitempty"`
+	FailureMessage string `json:"failureMessage,omitempty"`
+
+	// ThreeDSecureInfo is set for charges that went through
+	// 3D Secure authentication, see Complete3DSCharge.
+	ThreeDSecureInfo *ThreeDSecureInfo `json:"threeDSecureInfo,omitempty"`
+
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
+var _ json.Unmarshaler = (*ChargeResponse)(nil)
+
+func (cr *ChargeResponse) UnmarshalJSON(b []byte) error {
+	type plainChargeResponse ChargeResponse
+	if err := json.Unmarshal(b, (*plainChargeResponse)(cr)); err != nil {
+		return err
+	}
+	if cr.CustomerID == "" && cr.Customer != nil {
+		cr.CustomerID = CustomerID(cr.Customer.ID)
+	}
+	return nil
+}
+
+// ActiveDispute returns the most recently created dispute
+// of the charge that isn't closed yet, or nil if there is none.
+func (cr *ChargeResponse) ActiveDispute() *Dispute {
+	if cr == nil {
+		return nil
+	}
+
+	var active *Dispute
+	for _, dispute := range cr.Disputes {
+		if dispute == nil || dispute.Status.IsTerminal() {
+			continue
+		}
+		if active == nil || dispute.CreatedAt > active.CreatedAt {
+			active = dispute
+		}
+	}
+	return active
+}
+
+// HasOpenDispute reports whether the charge is currently contested.
+func (cr *ChargeResponse) HasOpenDispute() bool {
+	return cr.ActiveDispute() != nil
+}
+
+// Succeeded reports whether the charge went through, that is it neither
+// failed, as its FailureCode or FailureMessage tell, nor is still waiting
+// for the customer to authenticate with 3D Secure.
+func (cr *ChargeResponse) Succeeded() bool {
+	return cr != nil && cr.FailureCode == "" && cr.FailureMessage == "" && !cr.RequiresAction
+}
+
+var errChargeWithoutCustomer = errors.New("the charge isn't associated with a customer")
+
+// FetchCustomer returns the customer that the charge belongs to, e.g
+// to sync charges to a CRM. The customer embedded in the response is
+// returned if present, otherwise it is retrieved by its CustomerID.
+// It isn't named Customer since that is the embedded customer's field.
+func (cr *ChargeResponse) FetchCustomer(c *Client) (*Customer, error) {
+	if cr == nil || strings.TrimSpace(string(cr.CustomerID)) == "" {
+		return nil, errChargeWithoutCustomer
+	}
+	if cr.Customer != nil {
+		return cr.Customer, nil
+	}
+	return c.FindCustomerByID(string(cr.CustomerID))
 }
 
-type Refund *Charge
+// SumNet totals the settled amounts of charges per currency,
+// skipping any charges that were refunded or disputed since
+// those won't be part of the expected payout.
+func SumNet(charges []*ChargeResponse) map[Currency]int {
+	totals := make(map[Currency]int)
+	for _, cr := range charges {
+		if cr == nil || cr.Refunded || cr.Disputed {
+			continue
+		}
+		totals[cr.Currency] += cr.Net
+	}
+	return totals
+}
+
+type Refund struct {
+	ID         string     `json:"id"`
+	CreatedAt  int64      `json:"created"`
+	ObjectType ObjectType `json:"objectType"`
+
+	// AmountMinorCurrencyUnits is the refunded amount in
+	// minor amounts of currency.
+	AmountMinorCurrencyUnits int `json:"amount"`
+
+	// Currency is the 3 digit ISO currency code
+	// for example: EUR, USD, CAD
+	Currency Currency `json:"currency"`
+
+	ChargeID string       `json:"charge"`
+	Reason   RefundReason `json:"reason,omitempty"`
+
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
+type RefundReason string
+
+const (
+	RefundReasonFraudulent          RefundReason = "fraudulent"
+	RefundReasonDuplicate           RefundReason = "duplicate"
+	RefundReasonRequestedByCustomer RefundReason = "requested_by_customer"
+)
+
+// DisputeList holds the disputes of a charge. SecurionPay sends
+// a charge's dispute as a single object rather than as a list,
+// but both shapes are accepted when decoding.
+type DisputeList []*Dispute
+
+var _ json.Unmarshaler = (*DisputeList)(nil)
+
+func (dl *DisputeList) UnmarshalJSON(b []byte) error {
+	trimmed := bytes.TrimSpace(b)
+	switch {
+	case bytes.Equal(trimmed, []byte("null")):
+		*dl = nil
+		return nil
+
+	case bytes.HasPrefix(trimmed, []byte("[")):
+		var disputes []*Dispute
+		if err := json.Unmarshal(trimmed, &disputes); err != nil {
+			return err
+		}
+		*dl = disputes
+		return nil
+
+	default:
+		dispute := new(Dispute)
+		if err := json.Unmarshal(trimmed, dispute); err != nil {
+			return err
+		}
+		*dl = DisputeList{dispute}
+		return nil
+	}
+}
 
 type Dispute struct {
+	ID         string `json:"id"`
 	ObjectType string `json:"objectType"`
 	CreatedAt  int64  `json:"created"`
 	UpdatedAt  int64  `json:"updated"`
@@ -338,6 +976,9 @@ type Dispute struct {
 	// Currency is the 3 digit ISO currency code
 	// for example: EUR, USD, CAD
 	Currency Currency `json:"currency"`
+
+	// Evidence is the evidence submitted so far, if any.
+	Evidence *DisputeEvidence `json:"evidence,omitempty"`
 }
 
 type DisputeStatus string
@@ -352,6 +993,29 @@ const (
 	DisputeChargebackDisputeLost    DisputeStatus = "CHARGEBACK_REPRESENTED_UNSUCCESSFULLY"
 )
 
+// NeedsResponse reports whether the merchant has to act on a dispute
+// with status s, by responding to either a retrieval request or a
+// chargeback.
+func (s DisputeStatus) NeedsResponse() bool {
+	switch s {
+	case DisputeResponseNeeded, DisputeChargebackResponseNeeded:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsTerminal reports whether a dispute with status s is closed
+// i.e the chargeback was either won or lost.
+func (s DisputeStatus) IsTerminal() bool {
+	switch s {
+	case DisputeChargebackDisputeWon, DisputeChargebackDisputeLost:
+		return true
+	default:
+		return false
+	}
+}
+
 type Reason string
 
 const (
@@ -365,23 +1029,157 @@ const (
 	ReasonGeneral               Reason = "GENERAL"
 )
 
+var reasonLabels = map[Reason]string{
+	ReasonFraudulent:            "Fraudulent",
+	ReasonUnrecognized:          "Unrecognized",
+	ReasonDuplicate:             "Duplicate",
+	ReasonSubscriptionCancelled: "Subscription canceled",
+	ReasonProductNotReceived:    "Product not received",
+	ReasonProductUnacceptable:   "Product unacceptable",
+	ReasonCreditNotProcessed:    "Credit not processed",
+	ReasonGeneral:               "General",
+}
+
+// Valid reports whether r is one of the dispute reasons known to SecurionPay.
+func (r Reason) Valid() bool {
+	_, known := reasonLabels[r]
+	return known
+}
+
+// Human returns a friendly label for r e.g "Product not received",
+// falling back to the raw reason if it isn't a known one.
+func (r Reason) Human() string {
+	if label, known := reasonLabels[r]; known {
+		return label
+	}
+	return string(r)
+}
+
 var (
 	errBlankCharge = errors.New("expecting a non-blank charge")
 
 	errEitherBlankCardOrCustomerIDMustBeSet = errors.New("either `customerId` or `card` must be set")
+
+	errCardIDWithoutCustomerID = errors.New("`customerId` must be set when charging a saved card by its ID")
+	errBothCardAndCardIDSet    = errors.New("only one of `Card` or `CardID` can be set")
 )
 
+var _ json.Marshaler = (*Charge)(nil)
+
+func (creq Charge) MarshalJSON() ([]byte, error) {
+	type plainCharge Charge
+	pc := plainCharge(creq)
+	if cardID := strings.TrimSpace(creq.CardID); cardID != "" {
+		pc.Card = cardID
+	} else if blankCardSource(pc.Card) {
+		// Charging just the customer's default card.
+		pc.Card = nil
+	}
+	if creq.explicitCapture {
+		// The shallower Captured field takes precedence
+		// over the one with "omitempty" in plainCharge.
+		return json.Marshal(struct {
+			plainCharge
+			Captured bool `json:"captured"`
+		}{plainCharge: pc, Captured: creq.Captured})
+	}
+	return json.Marshal(pc)
+}
+
+// Validate checks that the charge has a positive amount of at most
+// 2^31-1 minor currency units, a funding source and valid addresses.
 func (creq *Charge) Validate() error {
 	if creq == nil {
 		return errBlankCharge
 	}
+	if creq.AmountMinorCurrencyUnits <= 0 || creq.AmountMinorCurrencyUnits > maxAmountMinorCurrencyUnits {
+		return errAmountOutOfRange
+	}
 	// The rule is that either customerId or card have to be set
-	blankCard := creq.Card == nil || creq.Card == ""
+	blankCard := blankCardSource(creq.Card)
 	blankCustomerID := creq.CustomerID == ""
-	if blankCard && blankCustomerID {
+	if strings.TrimSpace(creq.CardID) != "" {
+		if !blankCard {
+			return errBothCardAndCardIDSet
+		}
+		if blankCustomerID {
+			return errCardIDWithoutCustomerID
+		}
+	} else if blankCard && blankCustomerID {
 		return errEitherBlankCardOrCustomerIDMustBeSet
 	}
-	return nil
+	if !blankCard {
+		if err := validateCardSource(creq.Card); err != nil {
+			return err
+		}
+	}
+
+	if err := creq.Shipping.Validate(); err != nil {
+		return err
+	}
+	if err := creq.Billing.Validate(); err != nil {
+		return err
+	}
+	return validateMetadata(creq.Metadata)
+}
+
+// CloneWithCard returns a copy of the charge, with its captured flag
+// cleared, funded by card: a token, card ID or customer ID instead.
+func (creq *Charge) CloneWithCard(card interface{}) *Charge {
+	if creq == nil {
+		return nil
+	}
+	clone := &Charge{
+		Currency:            creq.Currency,
+		Description:         creq.Description,
+		StatementDescriptor: creq.StatementDescriptor,
+		CustomerID:          creq.CustomerID,
+
+		AmountMinorCurrencyUnits: creq.AmountMinorCurrencyUnits,
+	}
+	if customerID, ok := card.(CustomerID); ok {
+		clone.CustomerID = customerID
+	} else {
+		clone.Card = card
+	}
+
+	if creq.Shipping != nil {
+		shipping := *creq.Shipping
+		shipping.Address = creq.Shipping.Address.clone()
+		clone.Shipping = &shipping
+	}
+	if creq.Billing != nil {
+		billing := *creq.Billing
+		billing.Address = creq.Billing.Address.clone()
+		clone.Billing = &billing
+	}
+	if creq.Metadata != nil {
+		clone.Metadata = make(map[string]interface{}, len(creq.Metadata))
+		for key, value := range creq.Metadata {
+			clone.Metadata[key] = value
+		}
+	}
+	return clone
+}
+
+func (a *Address) clone() *Address {
+	if a == nil {
+		return nil
+	}
+	copied := *a
+	return &copied
+}
+
+// blankCardSource reports whether card is unset or is a string, of any
+// string kind such as CustomerID, that has nothing but whitespace.
+func blankCardSource(card interface{}) bool {
+	if card == nil {
+		return true
+	}
+	if rv := reflect.ValueOf(card); rv.Kind() == reflect.String {
+		return strings.TrimSpace(rv.String()) == ""
+	}
+	return false
 }
 
 const chargeEndpointURL = "https://api.securionpay.com/charges"
@@ -390,6 +1188,10 @@ func (c *Client) Charge(creq *Charge) (*ChargeResponse, error) {
 	if err := creq.Validate(); err != nil {
 		return nil, err
 	}
+	creq, err := c.withTemplatedDescription(creq)
+	if err != nil {
+		return nil, err
+	}
 
 	blob, err := json.Marshal(creq)
 	if err != nil {
@@ -401,16 +1203,63 @@ func (c *Client) Charge(creq *Charge) (*ChargeResponse, error) {
 		return nil, err
 	}
 
+	releaseCharge, err := c.claimRecentCharge(creq)
+	if err != nil {
+		return nil, err
+	}
+	releaseToken, err := c.claimChargedToken(creq)
+	if err != nil {
+		releaseCharge()
+		return nil, err
+	}
+
 	blob, err = c.doAuthThenReqAndSlurpResponse(req)
+	if declined, ok := err.(*ErrCardDeclined); ok {
+		// The token was used up by the declined charge but
+		// the charge may be retried e.g with another card.
+		releaseCharge()
+		return declined.Charge, err
+	}
 	if err != nil {
+		if definitelyRejected(err) {
+			releaseToken()
+			releaseCharge()
+		}
 		return nil, err
 	}
 
 	cResp := new(ChargeResponse)
-	if err := json.Unmarshal(blob, cResp); err != nil {
+	if err := c.decodeResponse(blob, cResp); err != nil {
+		return nil, err
+	}
+
+	return cResp, nil
+}
+
+var errBlankChargeID = errors.New("expecting a non-blank charge ID")
+
+// GET https://api.securionpay.com/charges/{CHARGE_ID}
+func (c *Client) FindChargeByID(chargeID string) (*ChargeResponse, error) {
+	chargeID = strings.TrimSpace(chargeID)
+	if chargeID == "" {
+		return nil, errBlankChargeID
+	}
+
+	fullURL := fmt.Sprintf("%s/%s", chargeEndpointURL, chargeID)
+	req, err := http.NewRequest("GET", fullURL, nil)
+	if err != nil {
 		return nil, err
 	}
 
+	blob, err := c.doAuthThenReqAndSlurpResponse(req)
+	if err != nil {
+		return nil, err
+	}
+
+	cResp := new(ChargeResponse)
+	if err := c.decodeResponse(blob, cResp); err != nil {
+		return nil, err
+	}
 	return cResp, nil
 }
 
@@ -428,12 +1277,12 @@ type Token struct {
 	Type           CardType   `json:"type"`
 	CardHolderName string     `json:"cardholderName"`
 
-	AddressLine1 string `json:"addressLine1,omitempty"`
-	AddressLine2 string `json:"addressLine2,omitempty"`
-	City         string `json:"addressCity,omitempty"`
-	State        string `json:"addressState,omitempty"`
-	ZIP          string `json:"addressZip,omitempty"`
-	Country      string `json:"addressCountry,omitempty"`
+	AddressLine1 string  `json:"addressLine1,omitempty"`
+	AddressLine2 string  `json:"addressLine2,omitempty"`
+	City         string  `json:"addressCity,omitempty"`
+	State        string  `json:"addressState,omitempty"`
+	ZIP          string  `json:"addressZip,omitempty"`
+	Country      Country `json:"addressCountry,omitempty"`
 
 	Used bool  `json:"used,omitempty"`
 	Card *Card `json:"card"`
@@ -442,6 +1291,21 @@ type Token struct {
 	ThreeDSecureInfo *ThreeDSecureInfo `json:"threeDSecureInfo,omitempty"`
 }
 
+var errTokenAlreadyUsed = errors.New("the token has already been used, tokens can only be charged once")
+
+// AsChargeSource returns the token in the form that Charge.Card expects,
+// that is its ID. It fails if the token was already used since tokens
+// are single use; save the card to a customer to charge it repeatedly.
+func (t *Token) AsChargeSource() (interface{}, error) {
+	if t == nil || strings.TrimSpace(t.ID) == "" {
+		return nil, errBlankTokenID
+	}
+	if t.Used {
+		return nil, errTokenAlreadyUsed
+	}
+	return t.ID, nil
+}
+
 type ThreeDSecureInfo struct {
 	// AmountMinorCurrencyUnits is the charge in minor
 	// amounts of currency. For example 10€ is represented
@@ -469,16 +1333,16 @@ type TokenRequest struct {
 	ExpiryMonth int    `json:"expMonth,string"`
 	ExpiryYear  int    `json:"expYear,string"`
 
-	SecurityCode   string `json:"cvc"`
-	CardHolderName string `json:"cardholderName"`
-	City           string `json:"addressCity,omitempty"`
-	State          string `json:"addressState,omitempty"`
-	ZIP            string `json:"addressZip,omitempty"`
-	AddressLine1   string `json:"addressLine1,omitempty"`
-	AddressLine2   string `json:"addressLine2,omitempty"`
-	Country        string `json:"addressCountry,omitempty"`
+	SecurityCode   string  `json:"cvc"`
+	CardHolderName string  `json:"cardholderName"`
+	City           string  `json:"addressCity,omitempty"`
+	State          string  `json:"addressState,omitempty"`
+	ZIP            string  `json:"addressZip,omitempty"`
+	AddressLine1   string  `json:"addressLine1,omitempty"`
+	AddressLine2   string  `json:"addressLine2,omitempty"`
+	Country        Country `json:"addressCountry,omitempty"`
 
-	FraudCheckData *FraudCheckData `json:"fraudCheckData"`
+	FraudCheckData *FraudCheckData `json:"fraudCheckData,omitempty"`
 }
 
 var (
@@ -517,10 +1381,11 @@ func (c *Client) NewToken(treq *TokenRequest) (*Token, error) {
 	}
 
 	tok := new(Token)
-	if err := json.Unmarshal(blob, tok); err != nil {
+	if err := c.decodeResponse(blob, tok); err != nil {
 		return nil, err
 	}
 
+	c.cacheCreatedToken(tok)
 	return tok, nil
 }
 
@@ -545,12 +1410,32 @@ func (c *Client) FindTokenByID(tokenID string) (*Token, error) {
 	}
 
 	tok := new(Token)
-	if err := json.Unmarshal(blob, tok); err != nil {
+	if err := c.decodeResponse(blob, tok); err != nil {
 		return nil, err
 	}
 	return tok, nil
 }
 
+// ErrTokenImmutable is returned by UpdateTokenFraudData since SecurionPay
+// doesn't permit updating tokens once created. Enriched fraud data can
+// instead be sent with the charge made from the token.
+var ErrTokenImmutable = errors.New("securionpay: tokens can't be updated once created")
+
+var errNilFraudCheckData = errors.New("expecting non-nil fraud check data")
+
+// UpdateTokenFraudData would attach fraud check data to an existing token.
+// SecurionPay doesn't allow tokens to be modified so after validating its
+// arguments, it returns ErrTokenImmutable without making any request.
+func (c *Client) UpdateTokenFraudData(tokenID string, f *FraudCheckData) (*Token, error) {
+	if strings.TrimSpace(tokenID) == "" {
+		return nil, errBlankTokenID
+	}
+	if f == nil {
+		return nil, errNilFraudCheckData
+	}
+	return nil, ErrTokenImmutable
+}
+
 type Credit struct {
 	ID         string     `json:"id"`
 	CreatedAt  int64      `json:"created"`
@@ -561,6 +1446,10 @@ type Credit struct {
 	// as "1000" and 10¥ is represented as "10"
 	AmountMinorCurrencyUnits int `json:"amount,string"`
 
+	// Currency is the 3 digit ISO currency code
+	// for example: EUR, USD, CAD
+	Currency Currency `json:"currency,omitempty"`
+
 	Description string `json:"description"`
 
 	Card *Card `json:"card"`
@@ -572,25 +1461,49 @@ type Credit struct {
 
 type Credits struct {
 	Credits []*Credit `json:"list"`
+
+	HasMore    bool `json:"hasMore"`
+	TotalCount int  `json:"totalCount,omitempty"`
+
+	// ClientFiltered is set when Credits were filtered by this package
+	// after being fetched, for example by DescriptionContains. HasMore
+	// and TotalCount then describe the unfiltered results.
+	ClientFiltered bool `json:"-"`
 }
 
 type CustomerID string
 
 var _ json.Unmarshaler = (*CustomerID)(nil)
 
+var errInvalidCustomerIDJSON = errors.New("expecting a customerId encoded as a JSON string, number or null")
+
 func (cid *CustomerID) UnmarshalJSON(b []byte) error {
-	str := string(b)
-	// Special case when we encounter `null`, modify it to the empty string
-	if str == "null" {
-		str = ""
-	}
-	unquoted, err := strconv.Unquote(string(b))
-	if err != nil {
-		return err
+	str := strings.TrimSpace(string(b))
+	switch {
+	case str == "null":
+		// Special case when we encounter `null`, modify it to the empty string
+		*cid = ""
+		return nil
+
+	case strings.HasPrefix(str, `"`):
+		var unquoted string
+		if err := json.Unmarshal(b, &unquoted); err != nil {
+			return err
+		}
+		*cid = CustomerID(unquoted)
+		return nil
+
+	default:
+		// Tolerate numeric IDs in case the API ever sends them unquoted.
+		var num json.Number
+		dec := json.NewDecoder(strings.NewReader(str))
+		dec.UseNumber()
+		if err := dec.Decode(&num); err != nil || dec.More() {
+			return errInvalidCustomerIDJSON
+		}
+		*cid = CustomerID(num.String())
+		return nil
 	}
-	*cid = CustomerID(unquoted)
-
-	return nil
 }
 
 type CreditRequest struct {
@@ -605,11 +1518,164 @@ type CreditRequest struct {
 	StartingAfterId   string `json:"startingAfterId,omitempty"`
 	EndingBeforeId    string `json:"endingBeforeId,omitempty"`
 	IncludeTotalCount bool   `json:"includeTotalCount,omitempty"`
+
+	// DescriptionContains keeps only the credits whose description
+	// contains it. SecurionPay doesn't support filtering by description
+	// so this filter is applied by this package to each fetched page,
+	// which can thus hold fewer credits than Limit.
+	DescriptionContains string `json:"-"`
+}
+
+// matchesClientFilters reports whether credit passes the
+// filters that are applied after fetching from SecurionPay.
+func (cr *CreditRequest) matchesClientFilters(credit *Credit) bool {
+	if cr == nil || cr.DescriptionContains == "" {
+		return true
+	}
+	return credit != nil && strings.Contains(credit.Description, cr.DescriptionContains)
+}
+
+var (
+	errConflictingLowerBounds = errors.New("only one of `CreatedAfter` (gt) or `CreatedOnOrAfter` (gte) can be set")
+	errConflictingUpperBounds = errors.New("only one of `CreatedBefore` (lt) or `CreatedOnOrBefore` (lte) can be set")
+	errEmptyCreatedRange      = errors.New("the created time range is empty, its lower bound is past its upper bound")
+)
+
+// validateCreatedRange checks the creation time filters shared by the
+// list requests, where zero values mean that a bound is unset.
+func validateCreatedRange(gt, gte, lt, lte int64) error {
+	if gt != 0 && gte != 0 {
+		return errConflictingLowerBounds
+	}
+	if lt != 0 && lte != 0 {
+		return errConflictingUpperBounds
+	}
+
+	lower, lowerExclusive := gte, false
+	if gt != 0 {
+		lower, lowerExclusive = gt, true
+	}
+	upper, upperExclusive := lte, false
+	if lt != 0 {
+		upper, upperExclusive = lt, true
+	}
+	if lower == 0 || upper == 0 {
+		return nil
+	}
+
+	if lower > upper || (lower == upper && (lowerExclusive || upperExclusive)) {
+		return errEmptyCreatedRange
+	}
+	return nil
+}
+
+func (cr *CreditRequest) Validate() error {
+	if cr == nil {
+		return nil
+	}
+	return validateCreatedRange(cr.CreatedAfter, cr.CreatedOnOrAfter, cr.CreatedBefore, cr.CreatedOnOrBefore)
 }
 
 const defaultCreditLimit = 3
 
 func (c *Client) ListCredits(cr *CreditRequest) (*Credits, error) {
+	creds, err := c.listCredits(context.Background(), cr)
+	if err != nil {
+		return nil, err
+	}
+	if cr == nil || cr.DescriptionContains == "" {
+		return creds, nil
+	}
+
+	filtered := creds.Credits[:0]
+	for _, credit := range creds.Credits {
+		if cr.matchesClientFilters(credit) {
+			filtered = append(filtered, credit)
+		}
+	}
+	creds.Credits = filtered
+	creds.ClientFiltered = true
+	return creds, nil
+}
+
+var errConflictingCustomerID = errors.New("the request's `CustomerID` conflicts with the customer whose credits are listed")
+
+// ListCustomerCredits lists the credits issued to a customer, with cr
+// optionally narrowing them down further. cr isn't modified and its
+// CustomerID, if set, must be that of the customer.
+//
+// GET https://api.securionpay.com/credits?customerId={CUSTOMER_ID}
+func (c *Client) ListCustomerCredits(customerID string, cr *CreditRequest) (*Credits, error) {
+	customerID = strings.TrimSpace(customerID)
+	if customerID == "" {
+		return nil, errInvalidCustomerID
+	}
+
+	creq := new(CreditRequest)
+	if cr != nil {
+		*creq = *cr
+	}
+	if set := strings.TrimSpace(string(creq.CustomerID)); set != "" && set != customerID {
+		return nil, errConflictingCustomerID
+	}
+	creq.CustomerID = CustomerID(customerID)
+	return c.ListCredits(creq)
+}
+
+// StreamCredits sends all the credits matching cr on the returned
+// channel, fetching a page once the previous one was received. Both
+// channels are closed once done, after sending any error.
+func (c *Client) StreamCredits(ctx context.Context, cr *CreditRequest) (<-chan *Credit, <-chan error) {
+	creditsChan := make(chan *Credit)
+	errsChan := make(chan error, 1)
+
+	go func() {
+		defer close(errsChan)
+		defer close(creditsChan)
+
+		creq := new(CreditRequest)
+		if cr != nil {
+			*creq = *cr
+		}
+
+		for {
+			var creds *Credits
+			err := c.resumePage(ctx, func(ctx context.Context) (err error) {
+				creds, err = c.listCredits(ctx, creq)
+				return err
+			})
+			if err != nil {
+				errsChan <- err
+				return
+			}
+
+			for _, credit := range creds.Credits {
+				if !creq.matchesClientFilters(credit) {
+					continue
+				}
+				select {
+				case <-ctx.Done():
+					errsChan <- ctx.Err()
+					return
+				case creditsChan <- credit:
+				}
+			}
+
+			if !creds.HasMore || len(creds.Credits) == 0 {
+				return
+			}
+			creq.StartingAfterId = creds.Credits[len(creds.Credits)-1].ID
+		}
+	}()
+
+	return creditsChan, errsChan
+}
+
+func (c *Client) listCredits(ctx context.Context, cr *CreditRequest) (*Credits, error) {
+	if err := cr.Validate(); err != nil {
+		return nil, err
+	}
+
 	creq := new(CreditRequest)
 	if cr != nil {
 		*creq = *cr
@@ -619,47 +1685,225 @@ func (c *Client) ListCredits(cr *CreditRequest) (*Credits, error) {
 		creq.Limit = defaultCreditLimit
 	}
 
-	qv, err := otils.ToURLValues(creq)
+	qv, err := listQuery(creq)
 	if err != nil {
 		return nil, err
 	}
 
-	fullURL := fmt.Sprintf("https://api.securionpay.com/credits?%s", qv.Encode())
+	fullURL := buildURL("/credits", qv)
 	req, err := http.NewRequest("GET", fullURL, nil)
 	if err != nil {
 		return nil, err
 	}
+	req = req.WithContext(ctx)
 	slurp, err := c.doAuthThenReqAndSlurpResponse(req)
 	if err != nil {
 		return nil, err
 	}
 	creds := new(Credits)
-	if err := json.Unmarshal(slurp, creds); err != nil {
+	if err := c.decodeResponse(slurp, creds); err != nil {
 		return nil, err
 	}
 	return creds, nil
 }
 
 func (c *Client) doAuthThenReqAndSlurpResponse(req *http.Request) ([]byte, error) {
-	req.SetBasicAuth(c._apiKey(), "")
-	res, err := c.httpClient().Do(req)
+	// The operation is named before the request is
+	// rebased, while it still has SecurionPay's paths.
+	op := operationName(req)
+	sink := c.metricsSink()
+	if sink == nil {
+		res, slurp, err := c.doAuthThenReqAndSlurp(req)
+		c.warnIfDeprecated(op, res)
+		return slurp, err
+	}
+
+	startTime := c.now()
+	res, slurp, err := c.doAuthThenReqAndSlurp(req)
+	statusCode := 0
+	if res != nil {
+		statusCode = res.StatusCode
+	}
+	sink.ObserveRequest(op, c.now().Sub(startTime), statusCode)
+	c.warnIfDeprecated(op, res)
+	return slurp, err
+}
+
+// doAuthThenReqAndSlurp sends the request, failing over to the fallback
+// keys if any, and returns the last response received along with the body.
+func (c *Client) doAuthThenReqAndSlurp(req *http.Request) (*http.Response, []byte, error) {
+	if c.reqCtx != nil && req.Context() == context.Background() {
+		req = req.WithContext(c.reqCtx)
+	}
+	req, err := c.compressRequest(req)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	req = c.rebaseRequest(req)
+
+	keys := c.failoverKeys()
+	if len(keys) == 0 {
+		return c.doWithRetries(req)
 	}
-	if res.Body != nil {
-		defer res.Body.Close()
+
+	for i := 0; ; i++ {
+		key := keys[i]
+		if i > 0 {
+			var err error
+			if req, err = rewindRequest(req); err != nil {
+				return nil, nil, err
+			}
+		}
+		req = req.WithContext(context.WithValue(req.Context(), apiKeyKey{}, key))
+		// Copied so that the key set for this attempt doesn't
+		// overwrite the Authorization header of earlier attempts.
+		req.Header = req.Header.Clone()
+
+		res, slurp, err := c.doWithRetries(req)
+		if err == nil {
+			c.setKeyInUse(key)
+			return res, slurp, nil
+		}
+		if res == nil || !authFailure(res.StatusCode) || i == len(keys)-1 {
+			return res, nil, err
+		}
 	}
+}
+
+// doWithRetries sends the request, retrying it as the retry policy allows.
+// The last response, if any, is returned even if it was unsuccessful.
+func (c *Client) doWithRetries(req *http.Request) (*http.Response, []byte, error) {
+	policy := c.retryPolicy()
 
-	if !otils.StatusOK(res.StatusCode) {
-		errMsg := res.Status
-		if res.Body != nil {
-			slurp, _ := ioutil.ReadAll(res.Body)
-			if len(slurp) > 0 {
-				errMsg = string(slurp)
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 {
+			var err error
+			if req, err = rewindRequest(req); err != nil {
+				return nil, nil, err
 			}
 		}
-		return nil, errors.New(errMsg)
+
+		res, slurp, err := c.doAuthThenReqAndLog(req)
+		if err != nil {
+			return res, nil, err
+		}
+		if otils.StatusOK(res.StatusCode) {
+			return res, slurp, nil
+		}
+
+		wait, retry := policy.nextBackoff(attempt, req, res, c.now())
+		if !retry {
+			return res, nil, responseError(res, slurp, c.now())
+		}
+		if err := sleepContext(req.Context(), wait); err != nil {
+			return res, nil, err
+		}
+	}
+}
+
+// responseError converts an unsuccessful response to an error.
+// now is used to interpret a Retry-After that is an HTTP date.
+func responseError(res *http.Response, slurp []byte, now time.Time) error {
+	errMsg := res.Status
+	if len(slurp) > 0 {
+		// Responses may echo the card number that was sent.
+		errMsg = RedactPAN(string(slurp))
 	}
 
-	return ioutil.ReadAll(res.Body)
+	switch res.StatusCode {
+	case http.StatusServiceUnavailable:
+		return &ErrServiceUnavailable{
+			Message:    errMsg,
+			RetryAfter: parseRetryAfter(res.Header.Get("Retry-After"), now),
+		}
+	case http.StatusTooManyRequests:
+		return &ErrRateLimited{
+			Message:    errMsg,
+			RetryAfter: parseRetryAfter(res.Header.Get("Retry-After"), now),
+		}
+	}
+	if err := declinedChargeError(res, slurp); err != nil {
+		return err
+	}
+	if apiErr := parseAPIError(res, slurp); apiErr != nil {
+		if err := duplicateChargeError(apiErr); err != nil {
+			return err
+		}
+		return apiErr
+	}
+	return errors.New(errMsg)
+}
+
+// doAuthThenReqAndLog sends the request and hands
+// its outcome to the logger if one was set.
+func (c *Client) doAuthThenReqAndLog(req *http.Request) (*http.Response, []byte, error) {
+	logFn, logBodies := c.loggingSettings()
+	latencies := c.latencyTracker()
+	if logFn == nil && latencies == nil {
+		return c.doAuthThenReq(req)
+	}
+
+	var reqBody []byte
+	if logFn != nil && logBodies && req.Body != nil {
+		var err error
+		reqBody, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, nil, err
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	startTime := c.now()
+	res, slurp, err := c.doAuthThenReq(req)
+	duration := c.now().Sub(startTime)
+	if latencies != nil {
+		latencies.record(duration)
+	}
+	if logFn == nil {
+		return res, slurp, err
+	}
+
+	rl := &RequestLog{
+		Method:   req.Method,
+		URL:      req.URL.String(),
+		Duration: duration,
+		Err:      err,
+
+		CorrelationID: CorrelationIDFromContext(req.Context()),
+	}
+	if res != nil {
+		rl.StatusCode = res.StatusCode
+	}
+	if logBodies {
+		apiKey := c._apiKey()
+		rl.RequestBody = redactBody(loggableBody(req.Header, reqBody), apiKey)
+		rl.ResponseBody = redactBody(slurp, apiKey)
+	}
+	logFn(rl)
+
+	return res, slurp, err
+}
+
+// doAuthThenReq sends the authenticated request and returns the
+// response along with its fully read body.
+func (c *Client) doAuthThenReq(req *http.Request) (*http.Response, []byte, error) {
+	c.authorize(req)
+	if id := CorrelationIDFromContext(req.Context()); id != "" {
+		req.Header.Set(correlationIDHeader, id)
+	}
+	res, err := c.httpClient().Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	if res.Body == nil {
+		return res, nil, nil
+	}
+	defer res.Body.Close()
+
+	slurp, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return res, nil, err
+	}
+	return res, slurp, nil
 }
@@ -0,0 +1,70 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package securionpaytest provides the card numbers that SecurionPay
+// accepts with test mode API keys, and token requests for them, for
+// use in tests and sandboxes. None of them work with live keys.
+package securionpaytest
+
+import (
+	"time"
+
+	"github.com/orijtech/securionpay"
+)
+
+// Card numbers that are approved with test mode API keys, as
+// documented under "Test cards" at https://securionpay.com/docs/testing.
+const (
+	CardVisa            = "4242424242424242"
+	CardMastercard      = "5555555555554444"
+	CardAmericanExpress = "378282246310005"
+)
+
+// Card numbers that are declined with test mode API keys, with the
+// error code in their comments, as documented under "Card errors" at
+// https://securionpay.com/docs/testing.
+const (
+	CardDeclined        = "4000000000000002" // card_declined
+	CardExpired         = "4000000000000069" // expired_card
+	CardIncorrectCVC    = "4000000000000127" // incorrect_cvc
+	CardProcessingError = "4000000000000119" // processing_error
+)
+
+// TokenRequest returns a token request for the test card number
+// that expires in a few years, so that it can be passed to NewToken.
+func TokenRequest(number string) *securionpay.TokenRequest {
+	return &securionpay.TokenRequest{
+		CardNumber:     number,
+		ExpiryMonth:    12,
+		ExpiryYear:     time.Now().Year() + 3,
+		SecurityCode:   "123",
+		CardHolderName: "Test Cardholder",
+	}
+}
+
+// VisaApproved returns a token request for a Visa
+// test card whose charges are approved.
+func VisaApproved() *securionpay.TokenRequest { return TokenRequest(CardVisa) }
+
+// MastercardApproved returns a token request for a
+// Mastercard test card whose charges are approved.
+func MastercardApproved() *securionpay.TokenRequest { return TokenRequest(CardMastercard) }
+
+// VisaDeclined returns a token request for a test card
+// whose charges are declined with "card_declined".
+func VisaDeclined() *securionpay.TokenRequest { return TokenRequest(CardDeclined) }
+
+// IncorrectCVC returns a token request for a test card
+// whose charges are declined with "incorrect_cvc".
+func IncorrectCVC() *securionpay.TokenRequest { return TokenRequest(CardIncorrectCVC) }
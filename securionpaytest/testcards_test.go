@@ -0,0 +1,78 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpaytest_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/orijtech/securionpay"
+	"github.com/orijtech/securionpay/securionpaytest"
+)
+
+// luhnValid reports whether number has a valid Luhn check digit.
+func luhnValid(number string) bool {
+	sum := 0
+	for i := len(number) - 1; i >= 0; i-- {
+		d := int(number[i] - '0')
+		if (len(number)-i)%2 == 0 {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
+
+func TestTokenRequests(t *testing.T) {
+	tests := [...]struct {
+		treq       *securionpay.TokenRequest
+		wantNumber string
+	}{
+		0: {treq: securionpaytest.VisaApproved(), wantNumber: "4242424242424242"},
+		1: {treq: securionpaytest.MastercardApproved(), wantNumber: "5555555555554444"},
+		2: {treq: securionpaytest.VisaDeclined(), wantNumber: "4000000000000002"},
+		3: {treq: securionpaytest.IncorrectCVC(), wantNumber: securionpaytest.CardIncorrectCVC},
+		4: {treq: securionpaytest.TokenRequest(securionpaytest.CardAmericanExpress), wantNumber: "378282246310005"},
+	}
+
+	for i, tt := range tests {
+		if tt.treq.CardNumber != tt.wantNumber {
+			t.Errorf("#%d: got number %q want %q", i, tt.treq.CardNumber, tt.wantNumber)
+		}
+		if tt.treq.ExpiryYear <= time.Now().Year() {
+			t.Errorf("#%d: expected an expiry in the future, got %d", i, tt.treq.ExpiryYear)
+		}
+		// The requests are ready to be passed to NewToken.
+		if err := tt.treq.Validate(); err != nil {
+			t.Errorf("#%d: err: %v", i, err)
+		}
+	}
+
+	for _, number := range []string{
+		securionpaytest.CardVisa,
+		securionpaytest.CardMastercard,
+		securionpaytest.CardAmericanExpress,
+		securionpaytest.CardDeclined,
+		securionpaytest.CardExpired,
+		securionpaytest.CardIncorrectCVC,
+		securionpaytest.CardProcessingError,
+	} {
+		if !luhnValid(number) {
+			t.Errorf("%q fails the Luhn check", number)
+		}
+	}
+}
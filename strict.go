@@ -0,0 +1,152 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// SetStrictDecoding makes responses with fields that the package's types
+// don't model fail to decode, instead of those fields being dropped, e.g
+// to detect in staging that SecurionPay added fields that should be mapped.
+// It is off by default so that new fields don't break production code.
+func (c *Client) SetStrictDecoding(strict bool) {
+	c.Lock()
+	c.strictDecoding = strict
+	c.Unlock()
+}
+
+// decodeResponse decodes the body of a response into v, which
+// must have no unknown fields if strict decoding is enabled.
+func (c *Client) decodeResponse(blob []byte, v interface{}) error {
+	if err := json.Unmarshal(blob, v); err != nil {
+		return err
+	}
+
+	c.RLock()
+	strict := c.strictDecoding
+	c.RUnlock()
+	if !strict {
+		return nil
+	}
+
+	// json.Decoder.DisallowUnknownFields isn't used since it doesn't
+	// apply to the types that implement json.Unmarshaler themselves,
+	// such as ChargeResponse, hence the fields are checked by walking
+	// the generic form of the JSON alongside the type of v.
+	var generic interface{}
+	if err := json.Unmarshal(blob, &generic); err != nil {
+		return err
+	}
+	var unknown []string
+	collectUnknownFields(generic, reflect.TypeOf(v), "", &unknown)
+	if len(unknown) == 0 {
+		return nil
+	}
+	sort.Strings(unknown)
+	return fmt.Errorf("securionpay: strict decoding of %T: unknown fields %s", v, strings.Join(unknown, ", "))
+}
+
+func collectUnknownFields(data interface{}, t reflect.Type, path string, unknown *[]string) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		obj, ok := data.(map[string]interface{})
+		if !ok {
+			return
+		}
+		fields := jsonFields(t)
+		for key, value := range obj {
+			field, ok := fields[key]
+			if !ok {
+				// encoding/json matches keys case insensitively as a fallback.
+				for name, f := range fields {
+					if strings.EqualFold(name, key) {
+						field, ok = f, true
+						break
+					}
+				}
+			}
+			if !ok {
+				*unknown = append(*unknown, path+key)
+				continue
+			}
+			collectUnknownFields(value, field.Type, path+key+".", unknown)
+		}
+
+	case reflect.Slice, reflect.Array:
+		switch data := data.(type) {
+		case []interface{}:
+			for i, elem := range data {
+				collectUnknownFields(elem, t.Elem(), fmt.Sprintf("%s%d.", path, i), unknown)
+			}
+		case map[string]interface{}:
+			// e.g DisputeList which also accepts a single object.
+			collectUnknownFields(data, t.Elem(), path, unknown)
+		}
+
+	case reflect.Map:
+		if obj, ok := data.(map[string]interface{}); ok {
+			for key, value := range obj {
+				collectUnknownFields(value, t.Elem(), path+key+".", unknown)
+			}
+		}
+	}
+}
+
+// jsonFields returns the fields of struct type t keyed by their JSON
+// names, including those promoted from embedded structs.
+func jsonFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+
+		if field.Anonymous && name == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				for embeddedName, embedded := range jsonFields(ft) {
+					if _, shadowed := fields[embeddedName]; !shadowed {
+						fields[embeddedName] = embedded
+					}
+				}
+				continue
+			}
+		}
+		if field.PkgPath != "" {
+			// Unexported fields aren't decoded.
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field
+	}
+	return fields
+}
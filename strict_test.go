@@ -0,0 +1,91 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay_test
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestStrictDecoding(t *testing.T) {
+	client := newTestClient(t)
+
+	readFixture := func(path string) string {
+		blob, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatalf("reading %s: %v", path, err)
+		}
+		return string(blob)
+	}
+
+	tests := [...]struct {
+		body   string
+		strict bool
+
+		wantErr     bool
+		wantUnknown []string
+	}{
+		0: {body: readFixture("testdata/chargeResp1.json"), strict: true},
+		1: {body: readFixture("testdata/chargeRespDisputed.json"), strict: true},
+		2: {body: readFixture("testdata/chargeRespCustomer.json"), strict: true},
+		// Lenient by default.
+		3: {body: `{"id":"char_1","newField":1,"card":{"id":"card_1","wallet":"apple"}}`},
+		4: {
+			body:        `{"id":"char_1","newField":1,"card":{"id":"card_1","wallet":"apple"}}`,
+			strict:      true,
+			wantErr:     true,
+			wantUnknown: []string{"card.wallet", "newField"},
+		},
+		5: {
+			// Unknown fields in a single dispute object are caught too.
+			body:        `{"id":"char_1","dispute":{"id":"dp_1","evidenceDueBy":1415810811}}`,
+			strict:      true,
+			wantErr:     true,
+			wantUnknown: []string{"dispute.evidenceDueBy"},
+		},
+		6: {
+			// Metadata holds arbitrary keys.
+			body:   `{"id":"char_1","metadata":{"anything":{"goes":true}}}`,
+			strict: true,
+		},
+	}
+
+	for i, tt := range tests {
+		client.SetStrictDecoding(tt.strict)
+		client.SetHTTPRoundTripper(&cannedRoundTripper{body: tt.body})
+
+		cResp, err := client.FindChargeByID("char_1")
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("#%d: expected an error", i)
+				continue
+			}
+			for _, field := range tt.wantUnknown {
+				if !strings.Contains(err.Error(), field) {
+					t.Errorf("#%d: err=%q doesn't mention %q", i, err, field)
+				}
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: err: %v", i, err)
+			continue
+		}
+		if cResp.ID == "" {
+			t.Errorf("#%d: expected a decoded charge", i)
+		}
+	}
+}
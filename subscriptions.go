@@ -0,0 +1,134 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay
+
+import (
+	"net/http"
+)
+
+type SubscriptionStatus string
+
+const (
+	SubscriptionTrialing SubscriptionStatus = "trialing"
+	SubscriptionActive   SubscriptionStatus = "active"
+	SubscriptionPastDue  SubscriptionStatus = "past_due"
+	SubscriptionCanceled SubscriptionStatus = "canceled"
+	SubscriptionUnpaid   SubscriptionStatus = "unpaid"
+)
+
+type Subscription struct {
+	ID         string     `json:"id"`
+	CreatedAt  int64      `json:"created"`
+	ObjectType ObjectType `json:"objectType"`
+
+	PlanID     string     `json:"planId"`
+	CustomerID CustomerID `json:"customerId"`
+	Quantity   int        `json:"quantity"`
+
+	Status SubscriptionStatus `json:"status"`
+
+	RemainingBillingCycles int `json:"remainingBillingCycles,omitempty"`
+
+	// All the timestamps below are in seconds since the Unix epoch.
+	Start              int64 `json:"start"`
+	CurrentPeriodStart int64 `json:"currentPeriodStart"`
+	CurrentPeriodEnd   int64 `json:"currentPeriodEnd"`
+	CanceledAt         int64 `json:"canceledAt,omitempty"`
+	EndedAt            int64 `json:"endedAt,omitempty"`
+	TrialStart         int64 `json:"trialStart,omitempty"`
+	TrialEnd           int64 `json:"trialEnd,omitempty"`
+
+	CancelAtPeriodEnd bool `json:"cancelAtPeriodEnd"`
+
+	// CouponID is the ID of the coupon discounting the subscription, if any.
+	CouponID string `json:"couponId,omitempty"`
+
+	Shipping *Shipping `json:"shipping,omitempty"`
+	Billing  *Billing  `json:"billing,omitempty"`
+
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+	Deleted  bool                   `json:"deleted,omitempty"`
+}
+
+type SubscriptionList struct {
+	Subscriptions []*Subscription `json:"list"`
+
+	HasMore    bool `json:"hasMore"`
+	TotalCount int  `json:"totalCount,omitempty"`
+}
+
+type SubscriptionListRequest struct {
+	Limit      int                `json:"limit,omitempty"`
+	CustomerID CustomerID         `json:"customerId,omitempty"`
+	Status     SubscriptionStatus `json:"status,omitempty"`
+
+	CreatedAfter      int64 `json:"gt,omitempty"`
+	CreatedOnOrAfter  int64 `json:"gte,omitempty"`
+	CreatedBefore     int64 `json:"lt,omitempty"`
+	CreatedOnOrBefore int64 `json:"lte,omitempty"`
+
+	StartingAfterId   string `json:"startingAfterId,omitempty"`
+	EndingBeforeId    string `json:"endingBeforeId,omitempty"`
+	IncludeTotalCount bool   `json:"includeTotalCount,omitempty"`
+}
+
+const (
+	subscriptionsEndpointURL = "https://api.securionpay.com/subscriptions"
+	defaultSubscriptionLimit = 10
+)
+
+func (sr *SubscriptionListRequest) Validate() error {
+	if sr == nil {
+		return nil
+	}
+	return validateCreatedRange(sr.CreatedAfter, sr.CreatedOnOrAfter, sr.CreatedBefore, sr.CreatedOnOrBefore)
+}
+
+// ListAllSubscriptions lists subscriptions across all customers,
+// optionally filtered by status e.g to find every past due one.
+func (c *Client) ListAllSubscriptions(sr *SubscriptionListRequest) (*SubscriptionList, error) {
+	if err := sr.Validate(); err != nil {
+		return nil, err
+	}
+
+	sreq := new(SubscriptionListRequest)
+	if sr != nil {
+		*sreq = *sr
+	}
+
+	if sreq.Limit < 1 {
+		sreq.Limit = defaultSubscriptionLimit
+	}
+
+	qv, err := listQuery(sreq)
+	if err != nil {
+		return nil, err
+	}
+
+	fullURL := buildURL("/subscriptions", qv)
+	req, err := http.NewRequest("GET", fullURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	slurp, err := c.doAuthThenReqAndSlurpResponse(req)
+	if err != nil {
+		return nil, err
+	}
+	subs := new(SubscriptionList)
+	if err := c.decodeResponse(slurp, subs); err != nil {
+		return nil, err
+	}
+	return subs, nil
+}
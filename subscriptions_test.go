@@ -0,0 +1,62 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay_test
+
+import (
+	"testing"
+
+	"github.com/orijtech/securionpay"
+)
+
+func TestListAllSubscriptions(t *testing.T) {
+	crt := &cannedRoundTripper{body: `{
+	  "list": [
+	    {"id": "sub_1", "planId": "plan_1", "customerId": "cust_1", "quantity": 1, "status": "past_due", "currentPeriodEnd": 1415810511},
+	    {"id": "sub_2", "planId": "plan_1", "customerId": null, "quantity": 2, "status": "past_due"}
+	  ],
+	  "hasMore": false
+	}`}
+	client := newClientWithRoundTripper(t, crt)
+
+	subs, err := client.ListAllSubscriptions(&securionpay.SubscriptionListRequest{
+		Status: securionpay.SubscriptionPastDue,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	reqs := crt.requests()
+	if len(reqs) != 1 {
+		t.Fatalf("got %d requests, want 1", len(reqs))
+	}
+	if got, want := reqs[0].URL.Path, "/subscriptions"; got != want {
+		t.Errorf("got path=%q want=%q", got, want)
+	}
+	if got, want := reqs[0].URL.Query().Get("status"), "past_due"; got != want {
+		t.Errorf("got status=%q want=%q", got, want)
+	}
+
+	if len(subs.Subscriptions) != 2 {
+		t.Fatalf("got %d subscriptions, want 2", len(subs.Subscriptions))
+	}
+	for i, sub := range subs.Subscriptions {
+		if sub.Status != securionpay.SubscriptionPastDue {
+			t.Errorf("#%d: got status=%q", i, sub.Status)
+		}
+	}
+	if got := subs.Subscriptions[0].CustomerID; got != "cust_1" {
+		t.Errorf("got customerID=%q", got)
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay
+
+import "time"
+
+// SecurionPay timestamps are in seconds since the Unix epoch. The
+// accessors below convert them to UTC times, or to the zero time if
+// unset, and format them as RFC 3339 e.g "2014-11-12T16:41:51Z", or
+// as "" if unset, for logs and JSON output.
+
+func unixUTC(secs int64) time.Time {
+	if secs == 0 {
+		return time.Time{}
+	}
+	return time.Unix(secs, 0).UTC()
+}
+
+func unixRFC3339(secs int64) string {
+	if secs == 0 {
+		return ""
+	}
+	return unixUTC(secs).Format(time.RFC3339)
+}
+
+func (c *Card) Created() time.Time     { return unixUTC(c.CreatedAt) }
+func (c *Card) CreatedRFC3339() string { return unixRFC3339(c.CreatedAt) }
+
+func (cust *Customer) Created() time.Time     { return unixUTC(cust.CreatedAt) }
+func (cust *Customer) CreatedRFC3339() string { return unixRFC3339(cust.CreatedAt) }
+
+func (cr *ChargeResponse) Created() time.Time     { return unixUTC(cr.CreatedAt) }
+func (cr *ChargeResponse) CreatedRFC3339() string { return unixRFC3339(cr.CreatedAt) }
+
+func (r *Refund) Created() time.Time     { return unixUTC(r.CreatedAt) }
+func (r *Refund) CreatedRFC3339() string { return unixRFC3339(r.CreatedAt) }
+
+func (d *Dispute) Created() time.Time     { return unixUTC(d.CreatedAt) }
+func (d *Dispute) CreatedRFC3339() string { return unixRFC3339(d.CreatedAt) }
+
+func (t *Token) Created() time.Time     { return unixUTC(t.CreatedAt) }
+func (t *Token) CreatedRFC3339() string { return unixRFC3339(t.CreatedAt) }
+
+func (cr *Credit) Created() time.Time     { return unixUTC(cr.CreatedAt) }
+func (cr *Credit) CreatedRFC3339() string { return unixRFC3339(cr.CreatedAt) }
+
+func (s *Subscription) Created() time.Time     { return unixUTC(s.CreatedAt) }
+func (s *Subscription) CreatedRFC3339() string { return unixRFC3339(s.CreatedAt) }
+
+func (inv *Invoice) Created() time.Time     { return unixUTC(inv.CreatedAt) }
+func (inv *Invoice) CreatedRFC3339() string { return unixRFC3339(inv.CreatedAt) }
+
+func (cp *Coupon) Created() time.Time     { return unixUTC(cp.CreatedAt) }
+func (cp *Coupon) CreatedRFC3339() string { return unixRFC3339(cp.CreatedAt) }
+
+func (btx *BalanceTransaction) Created() time.Time     { return unixUTC(btx.CreatedAt) }
+func (btx *BalanceTransaction) CreatedRFC3339() string { return unixRFC3339(btx.CreatedAt) }
+
+func (d *Dispute) Updated() time.Time     { return unixUTC(d.UpdatedAt) }
+func (d *Dispute) UpdatedRFC3339() string { return unixRFC3339(d.UpdatedAt) }
@@ -0,0 +1,72 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/orijtech/securionpay"
+)
+
+func TestTimestampAccessors(t *testing.T) {
+	// 1415810511 is the creation time used throughout the fixtures.
+	const createdAt, wantRFC3339 = 1415810511, "2014-11-12T16:41:51Z"
+
+	tests := [...]struct {
+		name    string
+		created func() (time.Time, string)
+	}{
+		0: {"Card", func() (time.Time, string) {
+			c := &securionpay.Card{CreatedAt: createdAt}
+			return c.Created(), c.CreatedRFC3339()
+		}},
+		1: {"ChargeResponse", func() (time.Time, string) {
+			cr := &securionpay.ChargeResponse{CreatedAt: createdAt}
+			return cr.Created(), cr.CreatedRFC3339()
+		}},
+		2: {"Credit", func() (time.Time, string) {
+			cr := &securionpay.Credit{CreatedAt: createdAt}
+			return cr.Created(), cr.CreatedRFC3339()
+		}},
+		3: {"Dispute", func() (time.Time, string) {
+			d := &securionpay.Dispute{UpdatedAt: createdAt}
+			return d.Updated(), d.UpdatedRFC3339()
+		}},
+		4: {"Invoice", func() (time.Time, string) {
+			inv := &securionpay.Invoice{CreatedAt: createdAt}
+			return inv.Created(), inv.CreatedRFC3339()
+		}},
+	}
+
+	for i, tt := range tests {
+		got, gotRFC3339 := tt.created()
+		if got.Location() != time.UTC {
+			t.Errorf("#%d: %s: got location %v, want UTC", i, tt.name, got.Location())
+		}
+		if got.Unix() != createdAt {
+			t.Errorf("#%d: %s: got %d, want %d", i, tt.name, got.Unix(), createdAt)
+		}
+		if gotRFC3339 != wantRFC3339 {
+			t.Errorf("#%d: %s: gotRFC3339=%q want=%q", i, tt.name, gotRFC3339, wantRFC3339)
+		}
+	}
+
+	// Unset timestamps aren't reported as the Unix epoch.
+	unset := new(securionpay.Token)
+	if !unset.Created().IsZero() || unset.CreatedRFC3339() != "" {
+		t.Errorf("got %v %q for an unset timestamp", unset.Created(), unset.CreatedRFC3339())
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay
+
+import "strings"
+
+// SecurionPay has no endpoint to list tokens, hence tokens created by
+// NewToken can optionally be remembered by the client instead, to look
+// up their details in short lived flows without another request.
+
+// createdTokens is a size bounded cache of tokens keyed by their IDs
+// which evicts the least recently used token once it is full.
+type createdTokens struct {
+	set *boundedSet
+}
+
+func newCreatedTokens(maxSize int) *createdTokens {
+	return &createdTokens{set: newBoundedSet(maxSize)}
+}
+
+func (ct *createdTokens) add(tok *Token) {
+	ct.set.put(tok.ID, tok, true)
+}
+
+func (ct *createdTokens) get(tokenID string) (*Token, bool) {
+	tok, ok := ct.set.get(tokenID)
+	if !ok {
+		return nil, false
+	}
+	return tok.(*Token), true
+}
+
+// SetTokenCacheSize makes the client remember up to size of the tokens
+// that it created with NewToken, for GetCachedToken to look up. A size
+// that isn't positive, the default, disables and clears the cache.
+func (c *Client) SetTokenCacheSize(size int) {
+	var cache *createdTokens
+	if size > 0 {
+		cache = newCreatedTokens(size)
+	}
+
+	c.Lock()
+	c.createdTokens = cache
+	c.Unlock()
+}
+
+// GetCachedToken returns the token with the given ID if it was created
+// by NewToken and is still cached. See SetTokenCacheSize.
+func (c *Client) GetCachedToken(tokenID string) (*Token, bool) {
+	c.RLock()
+	cache := c.createdTokens
+	c.RUnlock()
+
+	if cache == nil {
+		return nil, false
+	}
+	return cache.get(strings.TrimSpace(tokenID))
+}
+
+func (c *Client) cacheCreatedToken(tok *Token) {
+	c.RLock()
+	cache := c.createdTokens
+	c.RUnlock()
+
+	if cache != nil && tok != nil && tok.ID != "" {
+		cache.add(tok)
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxDedupedTokens bounds the number of tokens remembered by NewTokenDeduped.
+const maxDedupedTokens = 1024
+
+type dedupedToken struct {
+	token     *Token
+	expiresAt time.Time
+}
+
+// dedupedTokens remembers recently created tokens keyed by an HMAC of the
+// card's details, with a random key, so that card numbers and security
+// codes are neither kept in memory nor easily recoverable from the keys.
+type dedupedTokens struct {
+	sync.Mutex
+
+	hmacKey  []byte
+	entries  map[string]*dedupedToken
+	creating map[string]*tokenCreation
+}
+
+func newDedupedTokens() (*dedupedTokens, error) {
+	hmacKey := make([]byte, 32)
+	if _, err := rand.Read(hmacKey); err != nil {
+		return nil, err
+	}
+	return &dedupedTokens{
+		hmacKey:  hmacKey,
+		entries:  make(map[string]*dedupedToken),
+		creating: make(map[string]*tokenCreation),
+	}, nil
+}
+
+func (dt *dedupedTokens) key(treq *TokenRequest) string {
+	mac := hmac.New(sha256.New, dt.hmacKey)
+	fmt.Fprintf(mac, "%s\x00%d\x00%d\x00%s", strings.TrimSpace(treq.CardNumber), treq.ExpiryMonth, treq.ExpiryYear, treq.SecurityCode)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// tokenCreation is the creation of a token that callers
+// with the same key wait for rather than creating another.
+type tokenCreation struct {
+	done  chan struct{}
+	token *Token
+	err   error
+}
+
+// token returns the token remembered for key or, if there is none, the
+// one that create returns and which is remembered for window. Callers
+// that ask for the key while a token is being created wait for it.
+func (dt *dedupedTokens) token(key string, window time.Duration, now func() time.Time, create func() (*Token, error)) (*Token, error) {
+	dt.Lock()
+	if entry, ok := dt.entries[key]; ok {
+		if now().Before(entry.expiresAt) {
+			dt.Unlock()
+			return entry.token, nil
+		}
+		delete(dt.entries, key)
+	}
+	if creation, ok := dt.creating[key]; ok {
+		dt.Unlock()
+		<-creation.done
+		return creation.token, creation.err
+	}
+	creation := &tokenCreation{done: make(chan struct{})}
+	dt.creating[key] = creation
+	dt.Unlock()
+
+	creation.token, creation.err = create()
+
+	dt.Lock()
+	delete(dt.creating, key)
+	if creation.err == nil {
+		at := now()
+		if len(dt.entries) >= maxDedupedTokens {
+			dt.evictLocked(at)
+		}
+		dt.entries[key] = &dedupedToken{token: creation.token, expiresAt: at.Add(window)}
+	}
+	dt.Unlock()
+	close(creation.done)
+
+	return creation.token, creation.err
+}
+
+// evictLocked removes the expired entries or, if none
+// had expired, the entry that is closest to expiring.
+func (dt *dedupedTokens) evictLocked(now time.Time) {
+	oldestKey := ""
+	var oldest time.Time
+	for key, entry := range dt.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(dt.entries, key)
+			continue
+		}
+		if oldestKey == "" || entry.expiresAt.Before(oldest) {
+			oldestKey, oldest = key, entry.expiresAt
+		}
+	}
+	if len(dt.entries) >= maxDedupedTokens {
+		delete(dt.entries, oldestKey)
+	}
+}
+
+func (c *Client) ensureDedupedTokens() (*dedupedTokens, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.tokens == nil {
+		dt, err := newDedupedTokens()
+		if err != nil {
+			return nil, err
+		}
+		c.tokens = dt
+	}
+	return c.tokens, nil
+}
+
+// NewTokenDeduped is like NewToken but returns the token created, or being
+// created, by it for the same card within the last window instead of
+// creating another. Keep in mind that the token might already be charged.
+func (c *Client) NewTokenDeduped(treq *TokenRequest, window time.Duration) (*Token, error) {
+	if err := treq.Validate(); err != nil {
+		return nil, err
+	}
+	if window <= 0 {
+		return c.NewToken(treq)
+	}
+
+	dt, err := c.ensureDedupedTokens()
+	if err != nil {
+		return nil, err
+	}
+	return dt.token(dt.key(treq), window, c.now, func() (*Token, error) {
+		return c.NewToken(treq)
+	})
+}
@@ -0,0 +1,77 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrTokenAlreadyCharged is returned, before any request is made, when a
+// charge uses a token that the client already charged, e.g because a pay
+// button was clicked twice. See SetTokenChargeGuard.
+var ErrTokenAlreadyCharged = errors.New("securionpay: the token was already charged by this client, tokens can only be charged once")
+
+// chargedTokens is a size bounded set of the IDs of charged tokens
+// which forgets the earliest charged token once it is full.
+type chargedTokens struct {
+	set *boundedSet
+}
+
+func newChargedTokens(maxSize int) *chargedTokens {
+	return &chargedTokens{set: newBoundedSet(maxSize)}
+}
+
+// claim records tokenID as charged and reports
+// false if it already was recorded as such.
+func (ct *chargedTokens) claim(tokenID string) bool {
+	return ct.set.put(tokenID, nil, false)
+}
+
+func (ct *chargedTokens) release(tokenID string) {
+	ct.set.remove(tokenID)
+}
+
+// SetTokenChargeGuard makes charging any of the last size tokens charged
+// by the client again fail with ErrTokenAlreadyCharged, unless its charge
+// was rejected. A size that isn't positive, the default, disables it.
+func (c *Client) SetTokenChargeGuard(size int) {
+	var guard *chargedTokens
+	if size > 0 {
+		guard = newChargedTokens(size)
+	}
+
+	c.Lock()
+	c.chargedTokens = guard
+	c.Unlock()
+}
+
+// claimChargedToken claims the token that creq charges, if any, and
+// returns a function to release it should the charge be rejected.
+func (c *Client) claimChargedToken(creq *Charge) (release func(), err error) {
+	c.RLock()
+	guard := c.chargedTokens
+	c.RUnlock()
+
+	tokenID, _ := creq.Card.(string)
+	tokenID = strings.TrimSpace(tokenID)
+	if guard == nil || !strings.HasPrefix(tokenID, tokenIDPrefix) {
+		return func() {}, nil
+	}
+	if !guard.claim(tokenID) {
+		return nil, ErrTokenAlreadyCharged
+	}
+	return func() { guard.release(tokenID) }, nil
+}
@@ -0,0 +1,113 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/orijtech/securionpay"
+)
+
+func TestTokenChargeGuard(t *testing.T) {
+	chargeBody, err := ioutil.ReadFile("testdata/chargeResp1.json")
+	if err != nil {
+		t.Fatalf("reading charge fixture: %v", err)
+	}
+	crt := &cannedRoundTripper{body: string(chargeBody)}
+	client := newClientWithRoundTripper(t, crt)
+
+	charge := func(card interface{}) error {
+		_, err := client.Charge(&securionpay.Charge{Card: card, Currency: securionpay.USD, AmountMinorCurrencyUnits: 1500})
+		return err
+	}
+
+	// Off by default.
+	for i := 0; i < 2; i++ {
+		if err := charge("tok_default"); err != nil {
+			t.Fatalf("#%d: unguarded charge: %v", i, err)
+		}
+	}
+
+	client.SetTokenChargeGuard(2)
+	steps := [...]struct {
+		card        interface{}
+		statusCode  int
+		body        string
+		roundTrip   http.RoundTripper
+		wantErr     error
+		wantAnyErr  bool
+		wantRequest bool
+	}{
+		0: {card: "tok_1", wantRequest: true},
+		1: {card: "tok_1", wantErr: securionpay.ErrTokenAlreadyCharged},
+		2: {card: " tok_1 ", wantErr: securionpay.ErrTokenAlreadyCharged},
+
+		// Rejected charges release the token so they can be retried.
+		3: {
+			card:        "tok_2",
+			statusCode:  http.StatusBadRequest,
+			body:        `{"error":{"type":"invalid_request","message":"Invalid amount"}}`,
+			wantAnyErr:  true,
+			wantRequest: true,
+		},
+		4: {card: "tok_2", wantRequest: true},
+		5: {card: "tok_2", wantErr: securionpay.ErrTokenAlreadyCharged},
+
+		// The guard is bounded, so the earliest token is forgotten.
+		6: {card: "tok_3", wantRequest: true},
+		7: {card: "tok_1", wantRequest: true},
+
+		// Only tokens are guarded.
+		8: {card: "card_1", wantRequest: true},
+		9: {card: "card_1", wantRequest: true},
+
+		// Charges that may have been made keep the token.
+		10: {card: "tok_4", roundTrip: new(brokenConnRoundTripper), wantAnyErr: true},
+		11: {card: "tok_4", wantErr: securionpay.ErrTokenAlreadyCharged},
+		12: {card: "tok_5", statusCode: http.StatusInternalServerError, wantAnyErr: true, wantRequest: true},
+		13: {card: "tok_5", wantErr: securionpay.ErrTokenAlreadyCharged},
+	}
+
+	for i, step := range steps {
+		crt.statusCode = step.statusCode
+		crt.body = string(chargeBody)
+		if step.body != "" {
+			crt.body = step.body
+		}
+		client.SetHTTPRoundTripper(crt)
+		if step.roundTrip != nil {
+			client.SetHTTPRoundTripper(step.roundTrip)
+		}
+		before := len(crt.requests())
+		err := charge(step.card)
+		if made := len(crt.requests()) > before; made != step.wantRequest {
+			t.Errorf("#%d: request made=%v want=%v", i, made, step.wantRequest)
+		}
+		switch {
+		case step.wantErr != nil:
+			if err != step.wantErr {
+				t.Errorf("#%d: got err=%v want=%v", i, err, step.wantErr)
+			}
+		case step.wantAnyErr:
+			if err == nil {
+				t.Errorf("#%d: expected a non-nil error", i)
+			}
+		case err != nil:
+			t.Errorf("#%d: err: %v", i, err)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+// SetDialTimeout limits how long establishing a connection to
+// SecurionPay may take. It only applies when no custom round
+// tripper was set with SetHTTPRoundTripper. A non-positive
+// duration restores the default dial behavior.
+func (c *Client) SetDialTimeout(d time.Duration) {
+	c.Lock()
+	c.dialTimeout = d
+	c.retuneTransportLocked()
+	c.Unlock()
+}
+
+// SetHTTPTimeout limits the total duration of each request, from
+// dialing up to reading the whole response body. A non-positive
+// duration means that there is no timeout.
+func (c *Client) SetHTTPTimeout(d time.Duration) {
+	c.Lock()
+	c.httpTimeout = d
+	c.Unlock()
+}
+
+// SetTimeouts sets both the connect and the overall request timeouts,
+// see SetDialTimeout and SetHTTPTimeout. This lets connecting fail fast
+// while still leaving large list downloads a long enough read window.
+func (c *Client) SetTimeouts(connect, overall time.Duration) {
+	c.Lock()
+	c.dialTimeout = connect
+	c.httpTimeout = overall
+	c.retuneTransportLocked()
+	c.Unlock()
+}
+
+// SetDisableKeepAlives if set closes each connection once its request is
+// done, e.g for short-lived CLIs. It doesn't apply to custom round trippers.
+func (c *Client) SetDisableKeepAlives(disable bool) {
+	c.Lock()
+	c.disableKeepAlives = disable
+	c.retuneTransportLocked()
+	c.Unlock()
+}
+
+// SetMaxIdleConnsPerHost sets how many idle connections are kept open
+// for reuse. It doesn't apply to custom round trippers. A non-positive
+// n restores the default.
+func (c *Client) SetMaxIdleConnsPerHost(n int) {
+	c.Lock()
+	c.maxIdleConnsPerHost = n
+	c.retuneTransportLocked()
+	c.Unlock()
+}
+
+const defaultDialKeepAlive = 30 * time.Second
+
+// retuneTransportLocked replaces the tuned transport after a transport
+// setting changed, closing the idle connections of the replaced one so
+// that they don't linger until they time out. It must be invoked with
+// the client's lock held.
+func (c *Client) retuneTransportLocked() {
+	if replaced := c.tunedTransport; replaced != nil {
+		replaced.CloseIdleConnections()
+	}
+	c.tunedTransport = c.tuneDefaultTransportLocked()
+}
+
+// tuneDefaultTransportLocked returns a clone of http.DefaultTransport
+// with the client's transport settings applied, or nil if none of
+// them are set. It must be invoked with the client's lock held.
+func (c *Client) tuneDefaultTransportLocked() *http.Transport {
+	if c.dialTimeout <= 0 && !c.disableKeepAlives && c.maxIdleConnsPerHost <= 0 {
+		return nil
+	}
+
+	dt, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		return nil
+	}
+
+	tuned := dt.Clone()
+	if c.dialTimeout > 0 {
+		dialer := &net.Dialer{Timeout: c.dialTimeout, KeepAlive: defaultDialKeepAlive}
+		tuned.DialContext = dialer.DialContext
+	}
+	if c.maxIdleConnsPerHost > 0 {
+		tuned.MaxIdleConnsPerHost = c.maxIdleConnsPerHost
+		// The idle connections to all hosts mustn't be capped below it.
+		if tuned.MaxIdleConns > 0 && tuned.MaxIdleConns < c.maxIdleConnsPerHost {
+			tuned.MaxIdleConns = c.maxIdleConnsPerHost
+		}
+	}
+	tuned.DisableKeepAlives = c.disableKeepAlives
+	return tuned
+}
+
+func (c *Client) defaultTransport() http.RoundTripper {
+	c.RLock()
+	tuned := c.tunedTransport
+	c.RUnlock()
+
+	if tuned == nil {
+		return http.DefaultTransport
+	}
+	return tuned
+}
@@ -0,0 +1,215 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay_test
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// stallingRoundTripper never responds until the request is cancelled.
+type stallingRoundTripper struct{}
+
+func (srt *stallingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	<-req.Context().Done()
+	return nil, req.Context().Err()
+}
+
+func TestSetHTTPTimeout(t *testing.T) {
+	client := newTestClient(t)
+
+	client.SetHTTPRoundTripper(new(stallingRoundTripper))
+	client.SetHTTPTimeout(50 * time.Millisecond)
+
+	errsChan := make(chan error, 1)
+	go func() {
+		_, err := client.FindTokenByID(tokenID1)
+		errsChan <- err
+	}()
+
+	select {
+	case err := <-errsChan:
+		if err == nil {
+			t.Fatal("expected a timeout error")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the request was not timed out")
+	}
+}
+
+func TestRedirectsAreNotFollowed(t *testing.T) {
+	crt := &cannedRoundTripper{
+		statusCodes: []int{http.StatusFound, http.StatusOK},
+		header:      http.Header{"Location": {"https://attacker.example.com/steal"}},
+		body:        `{"id":"` + tokenID1 + `"}`,
+	}
+	client := newClientWithRoundTripper(t, crt)
+
+	_, err := client.FindTokenByID(tokenID1)
+	if err == nil || !strings.Contains(err.Error(), "refusing to follow a redirect") {
+		t.Errorf("got err=%v, want a refused redirect", err)
+	}
+	reqs := crt.requests()
+	if len(reqs) != 1 {
+		t.Fatalf("got %d requests, want only the original one", len(reqs))
+	}
+	if got := reqs[0].URL.Host; got != "api.securionpay.com" {
+		t.Errorf("gotHost=%q", got)
+	}
+}
+
+func TestSetDisableKeepAlives(t *testing.T) {
+	tests := [...]struct {
+		disable   bool
+		wantClose bool
+	}{
+		0: {disable: false, wantClose: false},
+		1: {disable: true, wantClose: true},
+	}
+
+	for i, tt := range tests {
+		var mu sync.Mutex
+		var closes []bool
+		srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			mu.Lock()
+			closes = append(closes, req.Close)
+			mu.Unlock()
+			rw.Write([]byte(`{"id":"tok_1","objectType":"token"}`))
+		}))
+
+		client := newTestClient(t)
+		if err := client.SetBaseURL(srv.URL); err != nil {
+			t.Fatalf("#%d: setting base URL: %v", i, err)
+		}
+		client.SetDisableKeepAlives(tt.disable)
+
+		for j := 0; j < 2; j++ {
+			if _, err := client.FindTokenByID("tok_1"); err != nil {
+				t.Errorf("#%d: request #%d err: %v", i, j, err)
+			}
+		}
+		srv.Close()
+
+		if len(closes) != 2 {
+			t.Errorf("#%d: got %d requests, want 2", i, len(closes))
+			continue
+		}
+		for j, gotClose := range closes {
+			if gotClose != tt.wantClose {
+				t.Errorf("#%d: request #%d: got Connection close=%v want=%v", i, j, gotClose, tt.wantClose)
+			}
+		}
+	}
+}
+
+func TestSetMaxIdleConnsPerHost(t *testing.T) {
+	const inFlight = 8
+
+	var mu sync.Mutex
+	arrived, newConns := 0, 0
+	release := make(chan struct{})
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		// Hold each request until all of its wave has arrived
+		// so that the wave needs inFlight connections at once.
+		mu.Lock()
+		wave := release
+		arrived++
+		if arrived%inFlight == 0 {
+			close(release)
+			release = make(chan struct{})
+		}
+		mu.Unlock()
+
+		<-wave
+		rw.Write([]byte(`{"id":"tok_1","objectType":"token"}`))
+	}))
+	srv.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			mu.Lock()
+			newConns++
+			mu.Unlock()
+		}
+	}
+	srv.Start()
+	defer srv.Close()
+
+	client := newTestClient(t)
+	if err := client.SetBaseURL(srv.URL); err != nil {
+		t.Fatalf("setting base URL: %v", err)
+	}
+	client.SetMaxIdleConnsPerHost(inFlight)
+
+	for wave := 0; wave < 2; wave++ {
+		var wg sync.WaitGroup
+		for i := 0; i < inFlight; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				if _, err := client.FindTokenByID("tok_1"); err != nil {
+					t.Errorf("wave #%d: request #%d err: %v", wave, i, err)
+				}
+			}(i)
+		}
+		wg.Wait()
+	}
+
+	// The second wave must reuse all of the first wave's connections
+	// whereas by default only 2 of them would have been kept idle.
+	mu.Lock()
+	defer mu.Unlock()
+	if newConns != inFlight {
+		t.Errorf("got %d connections, want %d", newConns, inFlight)
+	}
+}
+
+func TestRetuningClosesIdleConnections(t *testing.T) {
+	closed := make(chan struct{}, 1)
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Write([]byte(`{"id":"tok_1","objectType":"token"}`))
+	}))
+	srv.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateClosed {
+			select {
+			case closed <- struct{}{}:
+			default:
+			}
+		}
+	}
+	srv.Start()
+	defer srv.Close()
+
+	client := newTestClient(t)
+	if err := client.SetBaseURL(srv.URL); err != nil {
+		t.Fatalf("setting base URL: %v", err)
+	}
+	client.SetMaxIdleConnsPerHost(4)
+	if _, err := client.FindTokenByID("tok_1"); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The connection left idle by the replaced transport is closed
+	// rather than kept open until the keep-alive timeout.
+	client.SetDialTimeout(time.Second)
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the idle connection of the replaced transport wasn't closed")
+	}
+}
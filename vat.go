@@ -0,0 +1,94 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// euVATFormats are the formats of the VAT numbers of EU member states,
+// without their country prefix, keyed by the prefix which is the
+// country's ISO 3166 code except for Greece which uses "EL".
+var euVATFormats = map[string]*regexp.Regexp{
+	"AT": regexp.MustCompile(`^U\d{8}$`),
+	"BE": regexp.MustCompile(`^[01]\d{9}$`),
+	"BG": regexp.MustCompile(`^\d{9,10}$`),
+	"CY": regexp.MustCompile(`^\d{8}[A-Z]$`),
+	"CZ": regexp.MustCompile(`^\d{8,10}$`),
+	"DE": regexp.MustCompile(`^\d{9}$`),
+	"DK": regexp.MustCompile(`^\d{8}$`),
+	"EE": regexp.MustCompile(`^\d{9}$`),
+	"EL": regexp.MustCompile(`^\d{9}$`),
+	"ES": regexp.MustCompile(`^[A-Z0-9]\d{7}[A-Z0-9]$`),
+	"FI": regexp.MustCompile(`^\d{8}$`),
+	"FR": regexp.MustCompile(`^[A-HJ-NP-Z0-9]{2}\d{9}$`),
+	"HR": regexp.MustCompile(`^\d{11}$`),
+	"HU": regexp.MustCompile(`^\d{8}$`),
+	"IE": regexp.MustCompile(`^\d[0-9A-Z+*]\d{5}[A-W][A-I]?$`),
+	"IT": regexp.MustCompile(`^\d{11}$`),
+	"LT": regexp.MustCompile(`^(\d{9}|\d{12})$`),
+	"LU": regexp.MustCompile(`^\d{8}$`),
+	"LV": regexp.MustCompile(`^\d{11}$`),
+	"MT": regexp.MustCompile(`^\d{8}$`),
+	"NL": regexp.MustCompile(`^\d{9}B\d{2}$`),
+	"PL": regexp.MustCompile(`^\d{10}$`),
+	"PT": regexp.MustCompile(`^\d{9}$`),
+	"RO": regexp.MustCompile(`^\d{2,10}$`),
+	"SE": regexp.MustCompile(`^\d{12}$`),
+	"SI": regexp.MustCompile(`^\d{8}$`),
+	"SK": regexp.MustCompile(`^\d{10}$`),
+}
+
+// vatPrefix returns the VAT number prefix of an ISO 3166 country code.
+func vatPrefix(country string) string {
+	country = strings.ToUpper(strings.TrimSpace(country))
+	if country == "GR" {
+		return "EL"
+	}
+	return country
+}
+
+// ValidateVAT checks that the billing VAT number has the format of
+// VAT numbers in country, e.g "DE". Only EU formats are checked.
+func (b *Billing) ValidateVAT(country string) error {
+	if b == nil {
+		return nil
+	}
+	vat := strings.NewReplacer(" ", "", "-", "", ".", "").Replace(strings.ToUpper(strings.TrimSpace(b.VAT)))
+	if vat == "" {
+		return nil
+	}
+
+	prefix := vatPrefix(country)
+	format, known := euVATFormats[prefix]
+	if !known {
+		return nil
+	}
+
+	if len(vat) >= 2 {
+		if _, isPrefix := euVATFormats[vat[:2]]; isPrefix {
+			if vat[:2] != prefix {
+				return fmt.Errorf("billing: VAT number %q is from %s not %s", b.VAT, vat[:2], prefix)
+			}
+			vat = vat[2:]
+		}
+	}
+	if !format.MatchString(vat) {
+		return fmt.Errorf("billing: VAT number %q doesn't have the format of %s VAT numbers", b.VAT, prefix)
+	}
+	return nil
+}
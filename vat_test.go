@@ -0,0 +1,72 @@
+// Copyright 2017 orijtech. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securionpay_test
+
+import (
+	"testing"
+
+	"github.com/orijtech/securionpay"
+)
+
+func TestBillingValidateVAT(t *testing.T) {
+	tests := [...]struct {
+		vat     string
+		country string
+		wantErr bool
+	}{
+		0: {vat: "", country: "DE"},
+		1: {vat: "DE123456789", country: "DE"},
+		2: {vat: "123456789", country: "de"},
+		3: {vat: "PL 123-456-78-90", country: "PL"},
+		4: {vat: "EL123456789", country: "GR"},
+		5: {vat: "NL123456789B01", country: "NL"},
+		6: {vat: "IE1234567WA", country: "IE"},
+		7: {vat: "FRXX123456789", country: "FR"},
+
+		// Countries whose formats aren't known aren't checked.
+		8: {vat: "anything", country: "US"},
+
+		9:  {vat: "DE12345678", country: "DE", wantErr: true},
+		10: {vat: "FR123456789", country: "DE", wantErr: true},
+		11: {vat: "NL123456789", country: "NL", wantErr: true},
+		12: {vat: "ATU1234567", country: "AT", wantErr: true},
+	}
+
+	for i, tt := range tests {
+		billing := &securionpay.Billing{VAT: tt.vat}
+		err := billing.ValidateVAT(tt.country)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("#%d: expected a non-nil error", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: err: %v", i, err)
+		}
+	}
+
+	// Charge.Validate checks the VAT number against the billing country.
+	charge := &securionpay.Charge{
+		Card: "tok_NGsyDoJQXop5Pqqi6HizbJTe",
+		Billing: &securionpay.Billing{
+			VAT:     "PL123",
+			Address: &securionpay.Address{Line1: "Cicha 1", Country: "PL"},
+		},
+	}
+	if err := charge.Validate(); err == nil {
+		t.Errorf("expected an invalid VAT number to fail charge validation")
+	}
+}